@@ -0,0 +1,20 @@
+package main
+
+import "os"
+
+// Exit codes of semantic-release, CI scripts can rely on them to tell the outcomes of a run apart
+const (
+	// ExitOK is returned if a release was created or no release is necessary with --allow-no-changes
+	ExitOK = 0
+	// ExitError is returned for all errors, e.g. an invalid configuration or a failed API request
+	ExitError = 1
+	// ExitNoRelease is returned if no release was created as there are no releasable commits or because of --dry
+	ExitNoRelease = 65
+	// ExitConditionFailed is returned if the CI condition prevents the release, e.g. on a branch other than the default branch
+	ExitConditionFailed = 66
+	// ExitVerifyFailed is returned if a check of --verify failed
+	ExitVerifyFailed = 67
+)
+
+// exit terminates the process with the exit code, it is replaced in the tests
+var exit = os.Exit
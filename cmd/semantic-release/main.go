@@ -1,16 +1,19 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io/ioutil"
-	"log"
 	"os"
-	"regexp"
-	"strings"
+	"time"
 
 	"github.com/go-semantic-release/semantic-release/pkg/condition"
 	"github.com/go-semantic-release/semantic-release/pkg/config"
+	"github.com/go-semantic-release/semantic-release/pkg/logging"
+	"github.com/go-semantic-release/semantic-release/pkg/notify"
+	"github.com/go-semantic-release/semantic-release/pkg/output"
 	"github.com/go-semantic-release/semantic-release/pkg/semrel"
 	"github.com/go-semantic-release/semantic-release/pkg/update"
 	"github.com/urfave/cli/v2"
@@ -19,149 +22,380 @@ import (
 // SRVERSION is the semantic-release version (added at compile time)
 var SRVERSION string
 
-func errorHandler(logger *log.Logger) func(error, ...int) {
+func errorHandler(logger *logging.Logger) func(error, ...int) {
 	return func(err error, exitCode ...int) {
 		if err != nil {
-			logger.Println(err)
+			logger.Error(err)
 			if len(exitCode) == 1 {
-				os.Exit(exitCode[0])
+				exit(exitCode[0])
 				return
 			}
-			os.Exit(1)
+			exit(ExitError)
 		}
 	}
 }
 
 func main() {
+	err := newApp().Run(os.Args)
+	if err != nil {
+		fmt.Printf("\n%s\n", err.Error())
+		exit(ExitError)
+	}
+}
+
+func newApp() *cli.App {
 	app := cli.NewApp()
 	app.Name = "semantic-release"
 	app.Usage = "automates the package release workflow including: determining the next version number and generating the change log"
 	app.Version = SRVERSION
 	app.Flags = config.CliFlags
 	app.Action = cliHandler
-
-	err := app.Run(os.Args)
-	if err != nil {
-		fmt.Printf("\n%s\n", err.Error())
-		os.Exit(1)
-	}
+	return app
 }
 
 func cliHandler(c *cli.Context) error {
 
-	logger := log.New(os.Stderr, "[semantic-release]: ", 0)
+	logger := logging.New(os.Stderr, "semantic-release", logging.Info, "text")
 	exitIfError := errorHandler(logger)
 
 	conf, err := config.NewConfig(c)
 	exitIfError(err)
 
-	ci := condition.NewCI()
-	logger.Printf("detected CI: %s\n", ci.Name())
+	// stderr is kept for the logs, stdout is reserved for the --json output
+	logLevel, err := logging.ParseLevel(conf.LogLevel)
+	exitIfError(err)
+	logger = logging.New(os.Stderr, "semantic-release", logLevel, conf.LogFormat)
+	exitIfError = errorHandler(logger)
+
+	if conf.PrintConfig {
+		exitIfError(config.Print(os.Stdout, conf))
+		return nil
+	}
 
-	var repo semrel.Repository
+	uploads, err := semrel.ExpandUploads(conf.Uploads)
+	exitIfError(err)
 
-	if conf.GitLab {
-		repo, err = semrel.NewGitLabRepository(c.Context, conf.GitLabBaseURL, conf.Slug, conf.Token, ci.GetCurrentBranch(), conf.GitLabProjectID)
-	} else {
-		repo, err = semrel.NewGitHubRepository(c.Context, conf.GheHost, conf.Slug, conf.Token)
+	latestConfig, err := semrel.NewLatestReleaseConfig(conf)
+	exitIfError(err)
+
+	ci := condition.WithOverrides(condition.NewCI(), conf.CurrentBranch, conf.CurrentSHA)
+	logger.Infof("detected CI: %s", ci.Name())
+
+	ctx := semrel.WithRetries(c.Context, conf.APIRetries, conf.APIRetryWait)
+	var since time.Time
+	if conf.SinceDuration > 0 {
+		since = time.Now().Add(-conf.SinceDuration)
 	}
 
-	logger.Printf("releasing on: %s\n", repo.Provider())
+	repo, githubRepo, err := newRepository(ctx, conf, ci.GetCurrentBranch(), since, conf.ReadToken)
+	if err == nil && conf.WriteToken != conf.ReadToken {
+		// the release is created with the write token, e.g. the release assets are uploaded with it as well
+		var writeRepo semrel.Repository
+		writeRepo, githubRepo, err = newRepository(ctx, conf, ci.GetCurrentBranch(), since, conf.WriteToken)
+		repo = semrel.NewSplitRepository(repo, writeRepo)
+	}
+
+	if conf.Verify {
+		checks := semrel.Checks{{Name: "configuration valid"}, {Name: "provider client created", Err: err}}
+		if err == nil {
+			checks = append(checks, semrel.Verify(repo, &semrel.VerifyConfig{
+				Branch: ci.GetCurrentBranch(),
+				SHA:    ci.GetCurrentSHA(),
+				Latest: latestConfig,
+			})...)
+		}
+		exitIfError(checks.Write(os.Stdout))
+		if checks.Failed() {
+			exit(ExitVerifyFailed)
+		}
+		return nil
+	}
+
+	logger.Infof("releasing on: %s", repo.Provider())
 
 	exitIfError(err)
 
-	logger.Println("getting default branch...")
+	if conf.Local {
+		logger.Info("reading commits and tags from the local repository")
+		if conf.Unshallow {
+			var shallow bool
+			shallow, err = semrel.Unshallow(".")
+			if shallow && err == nil {
+				logger.Info("fetched the full history of the shallow clone")
+			}
+			exitIfError(err)
+		}
+		var localRepo *semrel.LocalRepository
+		localRepo, err = semrel.NewLocalRepository(repo, ".")
+		exitIfError(err)
+		localRepo.Since = since
+		repo = localRepo
+	}
+
+	if conf.SinceDuration > 0 && !conf.Local && (conf.Bitbucket || conf.Gitea || conf.Gogs) {
+		exitIfError(fmt.Errorf("--since-duration is not supported on %s, use --local to read the commits from the local repository", repo.Provider()))
+	}
+
+	if conf.PkgPath != "" && !conf.Local && (conf.Bitbucket || conf.Gitea || conf.Gogs) {
+		exitIfError(fmt.Errorf("--pkg-path is not supported on %s, use --local to read the changed files from the local repository", repo.Provider()))
+	}
+
+	logger.Info("getting default branch...")
 	defaultBranch, isPrivate, err := repo.GetInfo()
+	if errors.Is(err, semrel.ErrRepoNotFound) {
+		exitIfError(fmt.Errorf("%w, check the slug (or GitLab project id) and the access of the token", err))
+	}
 	exitIfError(err)
-	logger.Println("found default branch: " + defaultBranch)
+	if conf.DefaultBranchOverride != "" {
+		defaultBranch = conf.DefaultBranchOverride
+	}
+	logger.Info("found default branch: " + defaultBranch)
 	if isPrivate {
-		logger.Println("repo is private")
+		logger.Info("repo is private")
 	}
 
 	currentBranch := ci.GetCurrentBranch()
 	if currentBranch == "" {
 		exitIfError(fmt.Errorf("current branch not found"))
 	}
-	logger.Println("found current branch: " + currentBranch)
+	logger.Info("found current branch: " + currentBranch)
 
 	if conf.BetaRelease.MaintainedVersion != "" && currentBranch == defaultBranch {
 		exitIfError(fmt.Errorf("maintained version not allowed on default branch"))
 	}
 
+	if conf.BetaRelease.MaintainedVersion != "" && conf.MaintenanceBranchPattern != "" && !semrel.MatchesMaintenanceBranch(conf.MaintenanceBranchPattern, currentBranch) {
+		exitIfError(fmt.Errorf("maintained version not allowed on branch %s (must match %s)", currentBranch, conf.MaintenanceBranchPattern))
+	}
+
 	if conf.BetaRelease.MaintainedVersion != "" {
-		logger.Println("found maintained version: " + conf.BetaRelease.MaintainedVersion)
+		logger.Info("found maintained version: " + conf.BetaRelease.MaintainedVersion)
+		// the CI condition must not reject the maintenance branch for not being the default branch
 		defaultBranch = "*"
 	}
 
 	currentSha := ci.GetCurrentSHA()
-	logger.Println("found current sha: " + currentSha)
+	logger.Info("found current sha: " + currentSha)
 
 	if !conf.Noci {
-		logger.Println("running CI condition...")
+		logger.Info("running CI condition...")
 		config := condition.CIConfig{
-			"token":         conf.Token,
-			"defaultBranch": defaultBranch,
-			"private":       isPrivate || conf.TravisCom,
+			"token":              conf.ReadToken,
+			"defaultBranch":      defaultBranch,
+			"private":            isPrivate || conf.TravisCom,
+			"allowMergeRequests": conf.GitLabAllowMRPipelines,
 		}
-		exitIfError(ci.RunCondition(config), 66)
+		exitIfError(ci.RunCondition(config), ExitConditionFailed)
 	}
 
-	logger.Println("getting latest release...")
-	var matchRegex *regexp.Regexp
-	match := strings.TrimSpace(conf.Match)
-	if match != "" {
-		logger.Printf("getting latest release matching %s...", match)
-		matchRegex = regexp.MustCompile("^" + match)
+	conf.CurrentBranch, conf.CurrentSHA = currentBranch, currentSha
+	updateOptions := &update.Options{Type: conf.UpdateType, YAMLKey: conf.UpdateYAMLKey, TOMLKey: conf.UpdateTOMLKey}
+	// the files are updated before the release is created so the pushed update commit can be released
+	var beforeRelease semrel.BeforeRelease
+	if len(conf.Update) > 0 {
+		beforeRelease = func(result *semrel.ReleaseResult) (string, error) {
+			return applyUpdates(conf, result, updateOptions, logger)
+		}
 	}
-	release, err := repo.GetLatestRelease(conf.BetaRelease.MaintainedVersion, matchRegex)
-	exitIfError(err)
-	logger.Println("found version: " + release.Version.String())
-
-	if strings.Contains(conf.BetaRelease.MaintainedVersion, "-") && release.Version.Prerelease() == "" {
-		exitIfError(fmt.Errorf("no pre-release for this version possible"))
+	result, err := semrel.RunWithHook(c.Context, conf, repo, logger, beforeRelease)
+	if errors.Is(err, semrel.ErrNoChange) {
+		if conf.JSON {
+			exitIfError(printJSON(result))
+		}
+		if conf.AllowNoChanges {
+			logger.Info("no change")
+			exit(ExitOK)
+		} else {
+			exitIfError(err, ExitNoRelease)
+		}
 	}
-
-	logger.Println("getting commits...")
-	commits, err := repo.GetCommits(currentSha)
 	exitIfError(err)
+	newVer, changelog := result.NewVersion, result.Changelog
 
-	logger.Println("calculating new version...")
-	newVer := semrel.GetNewVersion(conf, commits, release)
-	if newVer == nil {
-		if conf.AllowNoChanges {
-			logger.Println("no change")
-			os.Exit(0)
+	if conf.Dry {
+		if conf.JSON {
+			exitIfError(printJSON(result))
 		} else {
-			exitIfError(errors.New("no change"), 65)
+			fmt.Printf("tag: %s\nversion: %s\n\n%s", result.Tag, result.Version, changelog)
+		}
+		// the files are not modified, only the changes are shown
+		for _, file := range conf.Update {
+			diff, err := update.PlanWithOptions(file, newVer.String(), updateOptions)
+			exitIfError(err)
+			if conf.JSON {
+				logger.Infof("%s would be updated:\n%s", file, diff)
+			} else {
+				fmt.Printf("\n%s:\n%s", file, diff)
+			}
 		}
+		exitIfError(errors.New("DRY RUN: no release was created"), ExitNoRelease)
 	}
-	logger.Println("new version: " + newVer.String())
 
-	if conf.Dry {
-		exitIfError(errors.New("DRY RUN: no release was created"), 65)
+	if len(uploads) > 0 && githubRepo == nil {
+		logger.Warnf("uploading files is not supported on %s yet, ignoring them", repo.Provider())
 	}
 
-	logger.Println("generating changelog...")
-	changelog := semrel.GetChangelog(commits, release, newVer)
-	if conf.Changelog != "" {
-		exitIfError(ioutil.WriteFile(conf.Changelog, []byte(changelog), 0644))
+	if len(uploads) > 0 && githubRepo != nil {
+		logger.Infof("uploading %d file(s) to the release...", len(uploads))
+		exitIfError(githubRepo.UploadReleaseAssets(result.Tag, uploads, conf.UploadChecksums))
 	}
 
-	logger.Println("creating release...")
-	exitIfError(repo.CreateRelease(changelog, newVer, conf.Prerelease, currentBranch, currentSha))
+	if conf.NotifyURL != "" {
+		notifier := &notify.Notifier{URL: conf.NotifyURL, Secret: conf.NotifySecret}
+		if conf.NotifyTemplate != "" {
+			notifier.Template, err = notify.ParseTemplate(conf.NotifyTemplate)
+			exitIfError(err)
+		}
+		// the release already exists, a failed notification must not fail it
+		if err := notifier.Notify(&notify.Payload{
+			Version:    result.Version,
+			Tag:        result.Tag,
+			Changelog:  changelog,
+			Provider:   repo.Provider(),
+			Repository: repo.Owner() + "/" + repo.Repo(),
+		}); err != nil {
+			logger.Warnf("could not send the notification: %v", err)
+		}
+	}
+
+	if conf.Summary {
+		// like the notification a failed summary does not fail the release
+		// the job log section goes to stderr with the logs, stdout is reserved for the --json output
+		if ok, err := condition.WriteJobSummary(ci, os.Stderr, "Changelog of "+result.Tag, changelog); err != nil {
+			logger.Warnf("could not write the job summary: %v", err)
+		} else if !ok {
+			logger.Warnf("job summaries are not supported on %s, ignoring --summary", ci.Name())
+		}
+	}
 
+	outputData := &output.Data{
+		Version:         result.Version,
+		PreviousVersion: result.PreviousVersion,
+		Tag:             result.Tag,
+		Bump:            result.Bump,
+		Prerelease:      result.Prerelease,
+		Changelog:       changelog,
+		ChangelogFile:   conf.Changelog,
+		Owner:           repo.Owner(),
+		Repo:            repo.Repo(),
+		Provider:        repo.Provider(),
+		SHA:             result.SHA,
+	}
 	if conf.Ghr {
-		exitIfError(ioutil.WriteFile(".ghr", []byte(fmt.Sprintf("-u %s -r %s v%s", repo.Owner(), repo.Repo(), newVer.String())), 0644))
+		exitIfError(output.WriteFile(".ghr", output.GhrTemplate, outputData))
 	}
 
 	if conf.Vf {
-		exitIfError(ioutil.WriteFile(".version", []byte(newVer.String()), 0644))
+		exitIfError(output.WriteFile(".version", output.VersionTemplate, outputData))
 	}
 
-	if conf.Update != "" {
-		exitIfError(update.Apply(conf.Update, newVer.String()))
+	if conf.OutputFile != "" {
+		outputTemplate := output.DefaultTemplate
+		if conf.OutputTemplate != "" {
+			outputTemplate, err = output.ParseTemplate(conf.OutputTemplate)
+			exitIfError(err)
+		}
+		exitIfError(output.WriteFile(conf.OutputFile, outputTemplate, outputData))
 	}
 
-	logger.Println("done.")
+	if conf.JSON {
+		exitIfError(printJSON(result))
+	}
+
+	logger.Info("done.")
 	return nil
 }
+
+// applyUpdates writes the new version to the update files and commits them with --commit-updates, the sha of the
+// update commit is returned once it is pushed so the release points to it
+func applyUpdates(conf *config.Config, result *semrel.ReleaseResult, opts *update.Options, logger *logging.Logger) (string, error) {
+	if err := update.ApplyAll(conf.Update, result.NewVersion.String(), opts); err != nil {
+		return "", err
+	}
+	if !conf.CommitUpdates {
+		return "", nil
+	}
+	files, err := update.Files(conf.Update, opts)
+	if err != nil {
+		return "", err
+	}
+	message, err := update.RenderCommitMessage(conf.CommitUpdatesMessage, &update.CommitMessageData{Version: result.NewVersion.String(), Tag: result.Tag})
+	if err != nil {
+		return "", err
+	}
+	committed, err := update.CommitFiles(".", files, message)
+	if err != nil {
+		return "", err
+	}
+	if !committed {
+		logger.Info("the updated files did not change, nothing to commit")
+		return "", nil
+	}
+	if !conf.PushUpdates {
+		logger.Info("the update commit is not pushed, the release points to the released commit")
+		return "", nil
+	}
+	logger.Infof("pushing the updated files to %s...", conf.CurrentBranch)
+	if err := update.PushCommit(".", "origin", conf.CurrentBranch); err != nil {
+		return "", err
+	}
+	return update.HeadSHA(".")
+}
+
+// newRepository creates the client of the configured provider authenticated with the token
+func newRepository(ctx context.Context, conf *config.Config, branch string, since time.Time, token string) (semrel.Repository, *semrel.GitHubRepository, error) {
+	switch {
+	case conf.GitLab:
+		gitlabRepo, err := semrel.NewGitLabRepository(ctx, conf.GitLabBaseURL, conf.Slug, token, branch, conf.GitLabProjectID)
+		if err == nil {
+			gitlabRepo.UseMergeRequestCommits = conf.GitLabUseMRCommits
+			gitlabRepo.ListFiles = conf.PkgPath != "" && !conf.Local
+			gitlabRepo.Since = since
+		}
+		return gitlabRepo, nil, err
+	case conf.Bitbucket:
+		repo, err := semrel.NewBitbucketRepository(ctx, conf.Slug, token)
+		return repo, nil, err
+	case conf.Gitea:
+		repo, err := semrel.NewGiteaRepository(ctx, conf.GiteaBaseURL, conf.Slug, token)
+		return repo, nil, err
+	case conf.Gogs:
+		repo, err := semrel.NewGogsRepository(ctx, conf.GogsBaseURL, conf.Slug, token)
+		if err != nil {
+			return nil, nil, err
+		}
+		repo.Local = conf.Local
+		return repo, nil, nil
+	}
+
+	var githubRepo *semrel.GitHubRepository
+	var err error
+	if conf.GitHubAppID != 0 {
+		var privateKey []byte
+		privateKey, err = ioutil.ReadFile(conf.GitHubAppPrivateKey)
+		if err == nil {
+			githubRepo, err = semrel.NewGitHubAppRepository(ctx, conf.GheHost, conf.GheAPIURL, conf.GheUploadURL, conf.Slug, &semrel.GitHubApp{
+				ID:             conf.GitHubAppID,
+				InstallationID: conf.GitHubAppInstallationID,
+				PrivateKey:     privateKey,
+			})
+		}
+	} else {
+		githubRepo, err = semrel.NewGitHubRepository(ctx, conf.GheHost, conf.GheAPIURL, conf.GheUploadURL, conf.Slug, token)
+	}
+	if err == nil {
+		githubRepo.ListFiles = conf.PkgPath != "" && !conf.Local
+		githubRepo.WaitForRateLimit = conf.WaitForRateLimit
+		githubRepo.Since = since
+		if !conf.NoCache {
+			githubRepo.CacheDir = conf.CacheDir
+		}
+	}
+	return githubRepo, githubRepo, err
+}
+
+func printJSON(v interface{}) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type exitCode int
+
+// run runs the cli in a temporary directory and returns the exit code
+func run(t *testing.T, args ...string) (code int) {
+	cwd, err := os.Getwd()
+	require.NoError(t, err)
+	dir, err := ioutil.TempDir("", "semrel-cli")
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(dir))
+	defer func() {
+		require.NoError(t, os.Chdir(cwd))
+		os.RemoveAll(dir)
+	}()
+
+	exit = func(code int) {
+		panic(exitCode(code))
+	}
+	defer func() {
+		exit = os.Exit
+		if r := recover(); r != nil {
+			c, ok := r.(exitCode)
+			if !ok {
+				panic(r)
+			}
+			code = int(c)
+		}
+	}()
+	if err := newApp().Run(append([]string{"semantic-release"}, args...)); err != nil {
+		return ExitError
+	}
+	return ExitOK
+}
+
+// setCI replaces the CI env vars detected by the condition package, they are restored after the test
+func setCI(t *testing.T, env map[string]string) func() {
+	names := []string{"GITHUB_ACTIONS", "GITHUB_REF", "GITHUB_EVENT_NAME", "GITHUB_EVENT_PATH", "TRAVIS", "GITLAB_CI", "TF_BUILD", "CIRCLECI"}
+	previous := make(map[string]*string, len(names))
+	for _, name := range names {
+		if value, ok := os.LookupEnv(name); ok {
+			previous[name] = &value
+		} else {
+			previous[name] = nil
+		}
+		require.NoError(t, os.Unsetenv(name))
+		if value, ok := env[name]; ok {
+			require.NoError(t, os.Setenv(name, value))
+		}
+	}
+	return func() {
+		for name, value := range previous {
+			if value == nil {
+				os.Unsetenv(name)
+			} else {
+				os.Setenv(name, *value)
+			}
+		}
+	}
+}
+
+func newGithubServer(commitMessage string) *httptest.Server {
+	return newGithubServerWithDefaultBranch("master", commitMessage)
+}
+
+//nolint:errcheck
+func newGithubServerWithDefaultBranch(defaultBranch, commitMessage string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			http.Error(w, `{"message": "Bad credentials"}`, http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/repos/owner/repo":
+			fmt.Fprintf(w, `{"default_branch": %q, "private": false}`, defaultBranch)
+		case "/repos/owner/repo/git/refs/tags":
+			fmt.Fprint(w, `[{"ref": "refs/tags/v1.0.0", "object": {"sha": "release", "type": "commit"}}]`)
+		case "/repos/owner/repo/commits":
+			json.NewEncoder(w).Encode([]map[string]interface{}{
+				{"sha": "head", "commit": map[string]string{"message": commitMessage}},
+				{"sha": "release", "commit": map[string]string{"message": "chore: release 1.0.0"}},
+			})
+		default:
+			http.Error(w, "invalid route", http.StatusNotImplemented)
+		}
+	}))
+}
+
+func TestExitCodes(t *testing.T) {
+	defer setCI(t, nil)()
+	feature := newGithubServer("feat: search")
+	defer feature.Close()
+	chore := newGithubServer("chore: update deps")
+	defer chore.Close()
+	args := func(ts *httptest.Server, extra ...string) []string {
+		return append([]string{"--token", "token", "--slug", "owner/repo", "--ghe-api-url", ts.URL, "--current-branch", "master", "--current-sha", "head"}, extra...)
+	}
+
+	require.Equal(t, ExitError, run(t, args(feature, "--log-format", "xml")...))
+	require.Equal(t, ExitNoRelease, run(t, args(chore, "--noci")...))
+	require.Equal(t, ExitOK, run(t, args(chore, "--noci", "--allow-no-changes")...))
+	require.Equal(t, ExitNoRelease, run(t, args(feature, "--noci", "--dry")...))
+	require.Equal(t, ExitOK, run(t, args(feature, "--verify")...))
+	require.Equal(t, ExitVerifyFailed, run(t, args(feature, "--verify", "--token", "invalid")...))
+}
+
+func TestExitCodeConditionFailed(t *testing.T) {
+	defer setCI(t, map[string]string{"GITHUB_ACTIONS": "true", "GITHUB_REF": "refs/heads/feature", "GITHUB_EVENT_NAME": "push"})()
+	ts := newGithubServer("feat: search")
+	defer ts.Close()
+
+	require.Equal(t, ExitConditionFailed, run(t, "--token", "token", "--slug", "owner/repo", "--ghe-api-url", ts.URL, "--current-sha", "head", "--dry"))
+}
+
+func TestDefaultBranchOverride(t *testing.T) {
+	defer setCI(t, map[string]string{"GITHUB_ACTIONS": "true", "GITHUB_REF": "refs/heads/next", "GITHUB_EVENT_NAME": "push"})()
+	ts := newGithubServer("feat: search")
+	defer ts.Close()
+	args := []string{"--token", "token", "--slug", "owner/repo", "--ghe-api-url", ts.URL, "--current-sha", "head", "--dry"}
+
+	// the CI condition passes on the overridden default branch
+	require.Equal(t, ExitConditionFailed, run(t, args...))
+	require.Equal(t, ExitNoRelease, run(t, append(args, "--default-branch", "next")...))
+	require.Equal(t, ExitConditionFailed, run(t, append(args, "--default-branch", "main")...))
+}
+
+func TestDefaultBranchOfRepository(t *testing.T) {
+	for _, defaultBranch := range []string{"main", "master"} {
+		t.Run(defaultBranch, func(t *testing.T) {
+			ts := newGithubServerWithDefaultBranch(defaultBranch, "feat: search")
+			defer ts.Close()
+			args := []string{"--token", "token", "--slug", "owner/repo", "--ghe-api-url", ts.URL, "--current-sha", "head", "--dry"}
+
+			// only the default branch reported by the repository passes the CI condition
+			for _, branch := range []string{"main", "master"} {
+				restore := setCI(t, map[string]string{"GITHUB_ACTIONS": "true", "GITHUB_REF": "refs/heads/" + branch, "GITHUB_EVENT_NAME": "push"})
+				expected := ExitConditionFailed
+				if branch == defaultBranch {
+					expected = ExitNoRelease
+				}
+				require.Equal(t, expected, run(t, args...), branch)
+				restore()
+			}
+		})
+	}
+}
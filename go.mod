@@ -10,5 +10,5 @@ require (
 	github.com/urfave/cli/v2 v2.2.0
 	github.com/xanzy/go-gitlab v0.31.0
 	golang.org/x/oauth2 v0.0.0-20200107190931-bf48bf16ab8d
-	gopkg.in/yaml.v2 v2.2.8 // indirect
+	gopkg.in/yaml.v2 v2.2.8
 )
@@ -0,0 +1,44 @@
+package condition
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+type AzureDevOps struct {
+}
+
+func (az *AzureDevOps) Name() string {
+	return "Azure DevOps"
+}
+
+func (az *AzureDevOps) GetCurrentBranch() string {
+	// BUILD_SOURCEBRANCHNAME only contains the last path segment of branches like feature/foo
+	if ref := os.Getenv("BUILD_SOURCEBRANCH"); strings.HasPrefix(ref, "refs/heads/") {
+		return strings.TrimPrefix(ref, "refs/heads/")
+	}
+	return os.Getenv("BUILD_SOURCEBRANCHNAME")
+}
+
+func (az *AzureDevOps) GetCurrentSHA() string {
+	return os.Getenv("BUILD_SOURCEVERSION")
+}
+
+func (az *AzureDevOps) IsPullRequest() bool {
+	return os.Getenv("BUILD_REASON") == "PullRequest" || os.Getenv("SYSTEM_PULLREQUEST_PULLREQUESTID") != ""
+}
+
+func (az *AzureDevOps) RunCondition(config CIConfig) error {
+	defaultBranch := config["defaultBranch"].(string)
+	if az.IsPullRequest() {
+		return fmt.Errorf("This test run was triggered by a pull request and therefore a new version won’t be published.")
+	}
+	if ref := os.Getenv("BUILD_SOURCEBRANCH"); ref != "" && !strings.HasPrefix(ref, "refs/heads/") {
+		return fmt.Errorf("This test run is not running on a branch build.")
+	}
+	if branch := az.GetCurrentBranch(); defaultBranch != "*" && branch != defaultBranch {
+		return fmt.Errorf("This test run was triggered on the branch %s, while semantic-release is configured to only publish from %s.", branch, defaultBranch)
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package condition
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func setAzureEnv(env map[string]string) func() {
+	keys := []string{"BUILD_SOURCEBRANCH", "BUILD_SOURCEBRANCHNAME", "BUILD_SOURCEVERSION", "BUILD_REASON", "SYSTEM_PULLREQUEST_PULLREQUESTID"}
+	for _, key := range keys {
+		os.Setenv(key, env[key])
+	}
+	return func() {
+		for _, key := range keys {
+			os.Unsetenv(key)
+		}
+	}
+}
+
+func TestAzureDevOps(t *testing.T) {
+	testCases := []struct {
+		name           string
+		env            map[string]string
+		expectedBranch string
+		expectedSHA    string
+		expectedError  string
+	}{
+		{
+			"push",
+			map[string]string{"BUILD_SOURCEBRANCH": "refs/heads/master", "BUILD_SOURCEBRANCHNAME": "master", "BUILD_SOURCEVERSION": "deadbeef", "BUILD_REASON": "IndividualCI"},
+			"master", "deadbeef", "",
+		},
+		{
+			"nested branch",
+			map[string]string{"BUILD_SOURCEBRANCH": "refs/heads/release/v1", "BUILD_SOURCEBRANCHNAME": "v1", "BUILD_SOURCEVERSION": "deadbeef"},
+			"release/v1", "deadbeef", "This test run was triggered on the branch release/v1, while semantic-release is configured to only publish from master.",
+		},
+		{
+			"pull request",
+			map[string]string{"BUILD_SOURCEBRANCH": "refs/pull/1/merge", "BUILD_SOURCEBRANCHNAME": "merge", "BUILD_SOURCEVERSION": "cafebabe", "BUILD_REASON": "PullRequest", "SYSTEM_PULLREQUEST_PULLREQUESTID": "1"},
+			"merge", "cafebabe", "This test run was triggered by a pull request and therefore a new version won’t be published.",
+		},
+		{
+			"tag",
+			map[string]string{"BUILD_SOURCEBRANCH": "refs/tags/v1.0.0", "BUILD_SOURCEBRANCHNAME": "v1.0.0", "BUILD_SOURCEVERSION": "deadbeef"},
+			"v1.0.0", "deadbeef", "This test run is not running on a branch build.",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			defer setAzureEnv(tc.env)()
+			az := AzureDevOps{}
+			assert.Equal(t, tc.expectedBranch, az.GetCurrentBranch())
+			assert.Equal(t, tc.expectedSHA, az.GetCurrentSHA())
+			err := az.RunCondition(CIConfig{"defaultBranch": "master"})
+			if tc.expectedError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tc.expectedError)
+			}
+		})
+	}
+}
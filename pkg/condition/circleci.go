@@ -0,0 +1,40 @@
+package condition
+
+import (
+	"fmt"
+	"os"
+)
+
+type CircleCI struct {
+}
+
+func (cc *CircleCI) Name() string {
+	return "CircleCI"
+}
+
+func (cc *CircleCI) GetCurrentBranch() string {
+	return os.Getenv("CIRCLE_BRANCH")
+}
+
+func (cc *CircleCI) GetCurrentSHA() string {
+	return os.Getenv("CIRCLE_SHA1")
+}
+
+func (cc *CircleCI) IsPullRequest() bool {
+	// CIRCLE_PR_NUMBER is only set for pull requests from forks
+	return os.Getenv("CIRCLE_PULL_REQUEST") != "" || os.Getenv("CIRCLE_PR_NUMBER") != ""
+}
+
+func (cc *CircleCI) RunCondition(config CIConfig) error {
+	defaultBranch := config["defaultBranch"].(string)
+	if cc.IsPullRequest() {
+		return fmt.Errorf("This test run was triggered by a pull request and therefore a new version won’t be published.")
+	}
+	if os.Getenv("CIRCLE_TAG") != "" || cc.GetCurrentBranch() == "" {
+		return fmt.Errorf("This test run is not running on a branch build.")
+	}
+	if branch := cc.GetCurrentBranch(); defaultBranch != "*" && branch != defaultBranch {
+		return fmt.Errorf("This test run was triggered on the branch %s, while semantic-release is configured to only publish from %s.", branch, defaultBranch)
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package condition
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func setCircleCIEnv(env map[string]string) func() {
+	keys := []string{"CIRCLE_BRANCH", "CIRCLE_SHA1", "CIRCLE_TAG", "CIRCLE_PULL_REQUEST", "CIRCLE_PR_NUMBER"}
+	for _, key := range keys {
+		os.Setenv(key, env[key])
+	}
+	return func() {
+		for _, key := range keys {
+			os.Unsetenv(key)
+		}
+	}
+}
+
+func TestCircleCI(t *testing.T) {
+	testCases := []struct {
+		name           string
+		env            map[string]string
+		expectedBranch string
+		expectedSHA    string
+		expectedError  string
+	}{
+		{
+			"push",
+			map[string]string{"CIRCLE_BRANCH": "master", "CIRCLE_SHA1": "deadbeef"},
+			"master", "deadbeef", "",
+		},
+		{
+			"other branch",
+			map[string]string{"CIRCLE_BRANCH": "feature", "CIRCLE_SHA1": "deadbeef"},
+			"feature", "deadbeef", "This test run was triggered on the branch feature, while semantic-release is configured to only publish from master.",
+		},
+		{
+			"pull request",
+			map[string]string{"CIRCLE_BRANCH": "feature", "CIRCLE_SHA1": "cafebabe", "CIRCLE_PULL_REQUEST": "https://github.com/owner/repo/pull/1"},
+			"feature", "cafebabe", "This test run was triggered by a pull request and therefore a new version won’t be published.",
+		},
+		{
+			"pull request from fork",
+			map[string]string{"CIRCLE_BRANCH": "pull/1", "CIRCLE_SHA1": "cafebabe", "CIRCLE_PR_NUMBER": "1"},
+			"pull/1", "cafebabe", "This test run was triggered by a pull request and therefore a new version won’t be published.",
+		},
+		{
+			"tag",
+			map[string]string{"CIRCLE_TAG": "v1.0.0", "CIRCLE_SHA1": "deadbeef"},
+			"", "deadbeef", "This test run is not running on a branch build.",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			defer setCircleCIEnv(tc.env)()
+			cc := CircleCI{}
+			assert.Equal(t, "CircleCI", cc.Name())
+			assert.Equal(t, tc.expectedBranch, cc.GetCurrentBranch())
+			assert.Equal(t, tc.expectedSHA, cc.GetCurrentSHA())
+			err := cc.RunCondition(CIConfig{"defaultBranch": "master"})
+			if tc.expectedError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tc.expectedError)
+			}
+		})
+	}
+}
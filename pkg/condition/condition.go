@@ -52,5 +52,11 @@ func NewCI() CI {
 	if os.Getenv("GITLAB_CI") == "true" {
 		return &GitLab{}
 	}
+	if strings.EqualFold(os.Getenv("TF_BUILD"), "true") {
+		return &AzureDevOps{}
+	}
+	if os.Getenv("CIRCLECI") == "true" {
+		return &CircleCI{}
+	}
 	return &DefaultCI{}
 }
@@ -1,7 +1,9 @@
 package condition
 
 import (
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"strings"
 )
@@ -9,15 +11,55 @@ import (
 type GitHubActions struct {
 }
 
+// gitHubEvent contains the used fields of the webhook payload stored at GITHUB_EVENT_PATH
+type gitHubEvent struct {
+	Repository struct {
+		Fork bool `json:"fork"`
+	} `json:"repository"`
+	PullRequest struct {
+		Head struct {
+			SHA  string `json:"sha"`
+			Repo struct {
+				FullName string `json:"full_name"`
+			} `json:"repo"`
+		} `json:"head"`
+	} `json:"pull_request"`
+}
+
 func (gha *GitHubActions) Name() string {
 	return "GitHub Actions"
 }
 
+func (gha *GitHubActions) IsPullRequest() bool {
+	event := os.Getenv("GITHUB_EVENT_NAME")
+	return event == "pull_request" || event == "pull_request_target"
+}
+
+func (gha *GitHubActions) readEvent() *gitHubEvent {
+	event := new(gitHubEvent)
+	data, err := ioutil.ReadFile(os.Getenv("GITHUB_EVENT_PATH"))
+	if err != nil {
+		return event
+	}
+	_ = json.Unmarshal(data, event)
+	return event
+}
+
 func (gha *GitHubActions) GetCurrentBranch() string {
+	// GITHUB_REF points to the merge ref (refs/pull/1/merge) for pull requests
+	if gha.IsPullRequest() {
+		return os.Getenv("GITHUB_HEAD_REF")
+	}
 	return strings.TrimPrefix(os.Getenv("GITHUB_REF"), "refs/heads/")
 }
 
 func (gha *GitHubActions) GetCurrentSHA() string {
+	// GITHUB_SHA is the merge commit for pull requests
+	if gha.IsPullRequest() {
+		if sha := gha.readEvent().PullRequest.Head.SHA; sha != "" {
+			return sha
+		}
+	}
 	return os.Getenv("GITHUB_SHA")
 }
 
@@ -28,8 +70,20 @@ func (gha *GitHubActions) IsBranchRef() bool {
 	return false
 }
 
+func (gha *GitHubActions) IsFork() bool {
+	event := gha.readEvent()
+	if gha.IsPullRequest() {
+		headRepo := event.PullRequest.Head.Repo.FullName
+		return headRepo != "" && headRepo != os.Getenv("GITHUB_REPOSITORY")
+	}
+	return event.Repository.Fork
+}
+
 func (gha *GitHubActions) RunCondition(config CIConfig) error {
 	defaultBranch := config["defaultBranch"].(string)
+	if gha.IsFork() {
+		return fmt.Errorf("This test run was triggered from a fork and therefore a new version won’t be published.")
+	}
 	if !gha.IsBranchRef() {
 		return fmt.Errorf("This test run is not running on a branch build.")
 	}
@@ -1,10 +1,13 @@
 package condition
 
 import (
+	"io/ioutil"
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestGithubValid(t *testing.T) {
@@ -13,3 +16,73 @@ func TestGithubValid(t *testing.T) {
 	err := gha.RunCondition(CIConfig{"defaultBranch": ""})
 	assert.EqualError(t, err, "This test run is not running on a branch build.")
 }
+
+func TestGithubEvents(t *testing.T) {
+	dir, err := ioutil.TempDir("", "semrel-github")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	testCases := []struct {
+		name           string
+		env            map[string]string
+		payload        string
+		expectedBranch string
+		expectedSHA    string
+		expectedError  string
+	}{
+		{
+			"push",
+			map[string]string{"GITHUB_EVENT_NAME": "push", "GITHUB_REF": "refs/heads/main", "GITHUB_SHA": "deadbeef"},
+			`{"repository": {"fork": false}}`,
+			"main", "deadbeef", "",
+		},
+		{
+			"push to fork",
+			map[string]string{"GITHUB_EVENT_NAME": "push", "GITHUB_REF": "refs/heads/main", "GITHUB_SHA": "deadbeef"},
+			`{"repository": {"fork": true}}`,
+			"main", "deadbeef", "This test run was triggered from a fork and therefore a new version won’t be published.",
+		},
+		{
+			"tag push",
+			map[string]string{"GITHUB_EVENT_NAME": "push", "GITHUB_REF": "refs/tags/v1.0.0", "GITHUB_SHA": "deadbeef"},
+			`{"repository": {"fork": false}}`,
+			"refs/tags/v1.0.0", "deadbeef", "This test run is not running on a branch build.",
+		},
+		{
+			"pull request",
+			map[string]string{"GITHUB_EVENT_NAME": "pull_request", "GITHUB_REF": "refs/pull/1/merge", "GITHUB_SHA": "merge", "GITHUB_HEAD_REF": "feature"},
+			`{"pull_request": {"head": {"sha": "cafebabe", "repo": {"full_name": "owner/repo"}}}}`,
+			"feature", "cafebabe", "This test run is not running on a branch build.",
+		},
+		{
+			"pull request from fork",
+			map[string]string{"GITHUB_EVENT_NAME": "pull_request", "GITHUB_REF": "refs/pull/1/merge", "GITHUB_SHA": "merge", "GITHUB_HEAD_REF": "feature"},
+			`{"pull_request": {"head": {"sha": "cafebabe", "repo": {"full_name": "someone/repo"}}}}`,
+			"feature", "cafebabe", "This test run was triggered from a fork and therefore a new version won’t be published.",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			eventPath := filepath.Join(dir, "event.json")
+			require.NoError(t, ioutil.WriteFile(eventPath, []byte(tc.payload), 0644))
+			env := map[string]string{"GITHUB_REPOSITORY": "owner/repo", "GITHUB_EVENT_PATH": eventPath, "GITHUB_HEAD_REF": ""}
+			for key, value := range tc.env {
+				env[key] = value
+			}
+			for key, value := range env {
+				os.Setenv(key, value)
+				defer os.Unsetenv(key)
+			}
+
+			gha := GitHubActions{}
+			assert.Equal(t, tc.expectedBranch, gha.GetCurrentBranch())
+			assert.Equal(t, tc.expectedSHA, gha.GetCurrentSHA())
+			err := gha.RunCondition(CIConfig{"defaultBranch": "main"})
+			if tc.expectedError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tc.expectedError)
+			}
+		})
+	}
+}
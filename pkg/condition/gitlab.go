@@ -13,19 +13,43 @@ func (gl *GitLab) Name() string {
 }
 
 func (gl *GitLab) GetCurrentBranch() string {
-	return os.Getenv("CI_COMMIT_BRANCH")
+	// CI_COMMIT_BRANCH is not set in merge request pipelines
+	if branch := os.Getenv("CI_COMMIT_BRANCH"); branch != "" {
+		return branch
+	}
+	if gl.IsMergeRequest() {
+		return os.Getenv("CI_MERGE_REQUEST_SOURCE_BRANCH_NAME")
+	}
+	if gl.IsTag() {
+		return ""
+	}
+	return os.Getenv("CI_COMMIT_REF_NAME")
 }
 
 func (gl *GitLab) GetCurrentSHA() string {
 	return os.Getenv("CI_COMMIT_SHA")
 }
 
+func (gl *GitLab) IsTag() bool {
+	return os.Getenv("CI_COMMIT_TAG") != ""
+}
+
+func (gl *GitLab) IsMergeRequest() bool {
+	return os.Getenv("CI_MERGE_REQUEST_IID") != "" || os.Getenv("CI_PIPELINE_SOURCE") == "merge_request_event"
+}
+
 func (gl *GitLab) IsBranchRef() bool {
 	return gl.GetCurrentBranch() != ""
 }
 
 func (gl *GitLab) RunCondition(config CIConfig) error {
 	defaultBranch := config["defaultBranch"].(string)
+	if gl.IsTag() {
+		return fmt.Errorf("This test run was triggered by the tag %s and therefore a new version won’t be published.", os.Getenv("CI_COMMIT_TAG"))
+	}
+	if allowed, _ := config["allowMergeRequests"].(bool); gl.IsMergeRequest() && !allowed {
+		return fmt.Errorf("This test run was triggered by a merge request and therefore a new version won’t be published.")
+	}
 	if !gl.IsBranchRef() {
 		return fmt.Errorf("This test run is not running on a branch build.")
 	}
@@ -13,3 +13,65 @@ func TestGitlabValid(t *testing.T) {
 	err := gl.RunCondition(CIConfig{"defaultBranch": ""})
 	assert.EqualError(t, err, "This test run is not running on a branch build.")
 }
+
+func TestGitlabPipelines(t *testing.T) {
+	testCases := []struct {
+		name               string
+		env                map[string]string
+		allowMergeRequests bool
+		expectedBranch     string
+		expectedError      string
+	}{
+		{
+			"branch",
+			map[string]string{"CI_COMMIT_BRANCH": "main", "CI_COMMIT_REF_NAME": "main", "CI_PIPELINE_SOURCE": "push"},
+			false, "main", "",
+		},
+		{
+			"other branch",
+			map[string]string{"CI_COMMIT_BRANCH": "feature", "CI_COMMIT_REF_NAME": "feature", "CI_PIPELINE_SOURCE": "push"},
+			false, "feature", "This test run was triggered on the branch feature, while semantic-release is configured to only publish from main.",
+		},
+		{
+			"branch without CI_COMMIT_BRANCH",
+			map[string]string{"CI_COMMIT_REF_NAME": "main", "CI_PIPELINE_SOURCE": "web"},
+			false, "main", "",
+		},
+		{
+			"tag",
+			map[string]string{"CI_COMMIT_TAG": "v1.0.0", "CI_COMMIT_REF_NAME": "v1.0.0", "CI_PIPELINE_SOURCE": "push"},
+			false, "", "This test run was triggered by the tag v1.0.0 and therefore a new version won’t be published.",
+		},
+		{
+			"merge request",
+			map[string]string{"CI_MERGE_REQUEST_IID": "7", "CI_MERGE_REQUEST_SOURCE_BRANCH_NAME": "main", "CI_COMMIT_REF_NAME": "main", "CI_PIPELINE_SOURCE": "merge_request_event"},
+			false, "main", "This test run was triggered by a merge request and therefore a new version won’t be published.",
+		},
+		{
+			"allowed merge request",
+			map[string]string{"CI_MERGE_REQUEST_IID": "7", "CI_MERGE_REQUEST_SOURCE_BRANCH_NAME": "main", "CI_COMMIT_REF_NAME": "main", "CI_PIPELINE_SOURCE": "merge_request_event"},
+			true, "main", "",
+		},
+	}
+	keys := []string{"CI_COMMIT_BRANCH", "CI_COMMIT_REF_NAME", "CI_COMMIT_TAG", "CI_PIPELINE_SOURCE", "CI_MERGE_REQUEST_IID", "CI_MERGE_REQUEST_SOURCE_BRANCH_NAME"}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			for _, key := range keys {
+				os.Setenv(key, tc.env[key])
+				defer os.Unsetenv(key)
+			}
+			os.Setenv("CI_COMMIT_SHA", "deadbeef")
+			defer os.Unsetenv("CI_COMMIT_SHA")
+
+			gl := GitLab{}
+			assert.Equal(t, tc.expectedBranch, gl.GetCurrentBranch())
+			assert.Equal(t, "deadbeef", gl.GetCurrentSHA())
+			err := gl.RunCondition(CIConfig{"defaultBranch": "main", "allowMergeRequests": tc.allowMergeRequests})
+			if tc.expectedError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tc.expectedError)
+			}
+		})
+	}
+}
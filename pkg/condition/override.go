@@ -0,0 +1,31 @@
+package condition
+
+// overrideCI replaces the branch and/or sha detected by the wrapped CI
+type overrideCI struct {
+	CI
+	branch string
+	sha    string
+}
+
+// WithOverrides returns a CI whose current branch and sha are replaced by the given values if they are set,
+// the branch and the sha are overridden independently of each other
+func WithOverrides(ci CI, branch, sha string) CI {
+	if branch == "" && sha == "" {
+		return ci
+	}
+	return &overrideCI{CI: ci, branch: branch, sha: sha}
+}
+
+func (o *overrideCI) GetCurrentBranch() string {
+	if o.branch != "" {
+		return o.branch
+	}
+	return o.CI.GetCurrentBranch()
+}
+
+func (o *overrideCI) GetCurrentSHA() string {
+	if o.sha != "" {
+		return o.sha
+	}
+	return o.CI.GetCurrentSHA()
+}
@@ -0,0 +1,24 @@
+package condition
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithOverrides(t *testing.T) {
+	os.Setenv("CI_COMMIT_BRANCH", "master")
+	os.Setenv("CI_COMMIT_SHA", "deadbeef")
+	defer os.Unsetenv("CI_COMMIT_BRANCH")
+	defer os.Unsetenv("CI_COMMIT_SHA")
+
+	ci := WithOverrides(&GitLab{}, "release", "")
+	assert.Equal(t, "release", ci.GetCurrentBranch())
+	assert.Equal(t, "deadbeef", ci.GetCurrentSHA(), "the branch override must not change the sha")
+	assert.Equal(t, "GitLab CI", ci.Name())
+
+	ci = WithOverrides(&GitLab{}, "", "cafebabe")
+	assert.Equal(t, "master", ci.GetCurrentBranch())
+	assert.Equal(t, "cafebabe", ci.GetCurrentSHA())
+}
@@ -0,0 +1,49 @@
+package condition
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// WriteJobSummary shows the markdown in the UI of the CI job: on GitHub Actions it is appended to the step summary
+// and on GitLab CI it is written to w (the job log, e.g. stderr) as collapsed section with the title as header.
+// It returns false if the CI has no job summary.
+func WriteJobSummary(ci CI, w io.Writer, title, markdown string) (bool, error) {
+	if o, ok := ci.(*overrideCI); ok {
+		ci = o.CI
+	}
+	switch ci.(type) {
+	case *GitHubActions:
+		return true, appendStepSummary(markdown)
+	case *GitLab:
+		return true, writeLogSection(w, time.Now(), title, markdown)
+	}
+	return false, nil
+}
+
+func appendStepSummary(markdown string) error {
+	file := os.Getenv("GITHUB_STEP_SUMMARY")
+	if file == "" {
+		return errors.New("GITHUB_STEP_SUMMARY is not set")
+	}
+	f, err := os.OpenFile(file, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(f, "%s\n", strings.TrimRight(markdown, "\n")); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// writeLogSection uses the section markers of the GitLab job log, see https://docs.gitlab.com/ee/ci/jobs/#custom-collapsible-sections
+func writeLogSection(w io.Writer, now time.Time, title, content string) error {
+	_, err := fmt.Fprintf(w, "\x1b[0Ksection_start:%d:semantic_release_summary[collapsed=true]\r\x1b[0K%s\n%s\n\x1b[0Ksection_end:%d:semantic_release_summary\r\x1b[0K\n",
+		now.Unix(), title, strings.TrimRight(content, "\n"), now.Unix())
+	return err
+}
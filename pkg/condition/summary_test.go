@@ -0,0 +1,64 @@
+package condition
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteJobSummaryGitHub(t *testing.T) {
+	dir, err := ioutil.TempDir("", "semrel-summary")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "step_summary.md")
+	require.NoError(t, ioutil.WriteFile(file, []byte("## Tests\n"), 0644))
+	previous, set := os.LookupEnv("GITHUB_STEP_SUMMARY")
+	defer func() {
+		if set {
+			os.Setenv("GITHUB_STEP_SUMMARY", previous)
+		} else {
+			os.Unsetenv("GITHUB_STEP_SUMMARY")
+		}
+	}()
+	require.NoError(t, os.Setenv("GITHUB_STEP_SUMMARY", file))
+
+	var stdout bytes.Buffer
+	ok, err := WriteJobSummary(&GitHubActions{}, &stdout, "v1.1.0", "## 1.1.0 (2020-05-01)\n\n#### Feature\n\n* search (abcd)\n\n")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Empty(t, stdout.String())
+	content, err := ioutil.ReadFile(file)
+	require.NoError(t, err)
+	// the summary is appended to the summary of the previous steps
+	assert.Equal(t, "## Tests\n## 1.1.0 (2020-05-01)\n\n#### Feature\n\n* search (abcd)\n", string(content))
+
+	os.Unsetenv("GITHUB_STEP_SUMMARY")
+	_, err = WriteJobSummary(&GitHubActions{}, &stdout, "v1.1.0", "changelog")
+	assert.EqualError(t, err, "GITHUB_STEP_SUMMARY is not set")
+}
+
+func TestWriteJobSummaryGitLab(t *testing.T) {
+	var stdout bytes.Buffer
+	// the overrides of the current branch and sha do not hide the CI
+	ok, err := WriteJobSummary(WithOverrides(&GitLab{}, "main", ""), &stdout, "v1.1.0", "changelog\n")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Contains(t, stdout.String(), "[collapsed=true]\r\x1b[0Kv1.1.0\nchangelog\n\x1b[0Ksection_end:")
+
+	stdout.Reset()
+	require.NoError(t, writeLogSection(&stdout, time.Unix(1588291200, 0), "v1.1.0", "changelog"))
+	assert.Equal(t, "\x1b[0Ksection_start:1588291200:semantic_release_summary[collapsed=true]\r\x1b[0Kv1.1.0\nchangelog\n\x1b[0Ksection_end:1588291200:semantic_release_summary\r\x1b[0K\n", stdout.String())
+}
+
+func TestWriteJobSummaryNoCI(t *testing.T) {
+	var stdout bytes.Buffer
+	ok, err := WriteJobSummary(&DefaultCI{}, &stdout, "v1.1.0", "changelog")
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
@@ -2,33 +2,148 @@ package config
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
 	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
 
+	"github.com/Masterminds/semver"
+	"github.com/go-semantic-release/semantic-release/pkg/logging"
+	"github.com/go-semantic-release/semantic-release/pkg/notify"
+	"github.com/go-semantic-release/semantic-release/pkg/output"
+	"github.com/go-semantic-release/semantic-release/pkg/update"
 	"github.com/urfave/cli/v2"
 )
 
+var prereleaseChannelPattern = regexp.MustCompile(`^[0-9A-Za-z-]+$`)
+
+// noReleaseScopePattern matches a scope (e.g. deps) or a type of a scope (e.g. docs(api)) of --no-release-if-only-scopes
+var noReleaseScopePattern = regexp.MustCompile(`^(?:\w+\([^()]+\)|[^()]+)$`)
+
 type (
 	// Config is a complete set of app configuration
 	Config struct {
 		Token                           string
+		ReadToken                       string
+		WriteToken                      string
 		Slug                            string
 		Changelog                       string
+		ChangelogPrepend                bool
+		ChangelogHeaderMarker           string
 		Ghr                             bool
 		Noci                            bool
 		Dry                             bool
 		Vf                              bool
-		Update                          string
+		Update                          []string
+		UpdateType                      string
+		UpdateYAMLKey                   string
+		UpdateTOMLKey                   string
+		CommitUpdates                   bool
+		CommitUpdatesMessage            string
+		PushUpdates                     bool
 		GheHost                         string
+		GheAPIURL                       string
+		GheUploadURL                    string
+		GitHubAppID                     int64
+		GitHubAppInstallationID         int64
+		GitHubAppPrivateKey             string
 		Prerelease                      bool
+		PrereleaseChannel               string
+		IncludePrereleases              bool
 		TravisCom                       bool
 		BetaRelease                     *BetaRelease
 		Match                           string
 		AllowInitialDevelopmentVersions bool
+		InitialVersion                  string
 		AllowNoChanges                  bool
+		MajorOnDeprecation              bool
+		ReleaseTimestamp                time.Time
+		ExcludeCommitters               []string
+		IgnoreMergeCommits              bool
+		CreditBy                        string
+		ReleaseTitleFromChangelog       bool
+		MovingTags                      []string
+		NoReleaseScopes                 []string
+		CoerceVersions                  bool
+		PrintConfig                     bool
+		JSON                            bool
+		LogLevel                        string
+		LogFormat                       string
+		TagFormat                       string
+		CommitPattern                   string
+		PkgName                         string
+		PkgPath                         string
+		NoReleaseOnScopeMismatch        bool
+		BuildMetadata                   string
+		Assets                          []string
+		ChangelogGroupByScope           bool
+		ChangelogIssueLinks             bool
+		ChangelogContributors           bool
+		ChangelogIncludeBody            bool
+		ChangelogSince                  string
+		ChangelogExcludeTypes           []string
+		ChangelogIncludeTypes           []string
+		ChangelogSectionTitles          map[string]string
+		ChangelogSectionOrder           []string
+		ChangelogDateFormat             string
+		ChangelogTimezone               *time.Location `json:"-"`
+		IssueURLTemplate                string
+		IssueURL                        *template.Template `json:"-"`
+		ChangelogTemplateFile           string
+		ChangelogTemplate               *template.Template `json:"-"`
+		ChangelogCommand                string
+		BumpRules                       map[string]string
+		ForceBump                       string
+		ForceBumpStrict                 bool
+		ReleaseDiscussionCategory       string
+		MarkLatest                      string
+		UseGitHubGeneratedNotes         bool
+		GitHubGeneratedNotesChangelog   bool
+		CurrentBranch                   string
+		DefaultBranchOverride           string
+		CurrentSHA                      string
+		APIRetries                      int
+		APIRetryWait                    time.Duration
+		SinceDuration                   time.Duration
+		WaitForRateLimit                bool
+		CacheDir                        string
+		NotifyURL                       string
+		NotifyTemplate                  string
+		NotifySecret                    string
+		OutputFile                      string
+		OutputTemplate                  string
+		Summary                         bool
+		NoCache                         bool
+		SignTags                        bool
+		RequireSignedCommits            bool
+		RequireSignedReleasingCommits   bool
+		SigningKey                      string
+		Local                           bool
+		Unshallow                       bool
+		ParseSquashBody                 bool
+		MaintenanceBranchPattern        string
+		Promote                         bool
+		Verify                          bool
+		Uploads                         []string
+		UploadChecksums                 bool
 		GitLab                          bool
 		GitLabBaseURL                   string
+		GitLabUseMRCommits              bool
+		GitLabAllowMRPipelines          bool
 		GitLabProjectID                 string
+		Bitbucket                       bool
+		Gitea                           bool
+		GiteaBaseURL                    string
+		Gogs                            bool
+		GogsBaseURL                     string
 	}
 
 	BetaRelease struct {
@@ -38,27 +153,385 @@ type (
 
 // NewConfig returns a new Config instance
 func NewConfig(c *cli.Context) (*Config, error) {
+	if err := applyConfigFile(c); err != nil {
+		return nil, err
+	}
+
 	conf := &Config{
 		Token:                           c.String("token"),
+		ReadToken:                       c.String("read-token"),
+		WriteToken:                      c.String("write-token"),
 		Slug:                            c.String("slug"),
 		Changelog:                       c.String("changelog"),
+		ChangelogPrepend:                c.Bool("changelog-prepend"),
+		ChangelogHeaderMarker:           c.String("changelog-header-marker"),
 		Ghr:                             c.Bool("ghr"),
 		Noci:                            c.Bool("noci"),
 		Dry:                             c.Bool("dry"),
 		Vf:                              c.Bool("vf"),
-		Update:                          c.String("update"),
+		Update:                          c.StringSlice("update"),
+		UpdateType:                      c.String("update-type"),
+		UpdateYAMLKey:                   c.String("update-yaml-key"),
+		UpdateTOMLKey:                   c.String("update-toml-key"),
+		CommitUpdates:                   c.Bool("commit-updates"),
+		CommitUpdatesMessage:            c.String("commit-updates-message"),
+		PushUpdates:                     c.Bool("push-updates"),
 		GheHost:                         c.String("ghe-host"),
+		GheAPIURL:                       c.String("ghe-api-url"),
+		GheUploadURL:                    c.String("ghe-upload-url"),
+		GitHubAppID:                     c.Int64("github-app-id"),
+		GitHubAppInstallationID:         c.Int64("github-app-installation-id"),
+		GitHubAppPrivateKey:             c.String("github-app-private-key"),
 		Prerelease:                      c.Bool("prerelease"),
+		PrereleaseChannel:               c.String("prerelease-channel"),
+		IncludePrereleases:              c.Bool("include-prereleases"),
 		TravisCom:                       c.Bool("travis-com"),
 		Match:                           c.String("match"),
 		AllowInitialDevelopmentVersions: c.Bool("allow-initial-development-versions"),
+		InitialVersion:                  c.String("initial-version"),
 		AllowNoChanges:                  c.Bool("allow-no-changes"),
+		MajorOnDeprecation:              c.Bool("major-on-deprecation"),
 		GitLab:                          c.Bool("gitlab"),
 		GitLabBaseURL:                   c.String("gitlab-base-url"),
+		GitLabUseMRCommits:              c.Bool("gitlab-use-mr-commits"),
+		GitLabAllowMRPipelines:          c.Bool("gitlab-allow-mr-pipelines"),
 		GitLabProjectID:                 c.String("gitlab-project-id"),
+		Bitbucket:                       c.Bool("bitbucket"),
+		Gitea:                           c.Bool("gitea"),
+		GiteaBaseURL:                    c.String("gitea-base-url"),
+		Gogs:                            c.Bool("gogs"),
+		GogsBaseURL:                     c.String("gogs-base-url"),
+		ExcludeCommitters:               c.StringSlice("exclude-committers"),
+		IgnoreMergeCommits:              c.Bool("ignore-merge-commits"),
+		CreditBy:                        c.String("credit-by"),
+		ReleaseTitleFromChangelog:       c.Bool("release-title-from-changelog"),
+		MovingTags:                      c.StringSlice("moving-tags"),
+		NoReleaseScopes:                 c.StringSlice("no-release-if-only-scopes"),
+		CoerceVersions:                  c.Bool("coerce-versions"),
+		PrintConfig:                     c.Bool("print-config"),
+		JSON:                            c.Bool("json"),
+		LogLevel:                        c.String("log-level"),
+		LogFormat:                       c.String("log-format"),
+		TagFormat:                       c.String("tag-format"),
+		CommitPattern:                   c.String("commit-pattern"),
+		PkgName:                         c.String("pkg-name"),
+		PkgPath:                         c.String("pkg-path"),
+		NoReleaseOnScopeMismatch:        c.Bool("no-release-on-scope-mismatch"),
+		BuildMetadata:                   c.String("build-metadata"),
+		Assets:                          c.StringSlice("asset"),
+		ChangelogGroupByScope:           c.Bool("changelog-group-by-scope"),
+		ChangelogIssueLinks:             c.Bool("changelog-issue-links"),
+		ChangelogContributors:           c.Bool("changelog-contributors"),
+		ChangelogIncludeBody:            c.Bool("changelog-include-body"),
+		ChangelogSince:                  c.String("changelog-since"),
+		ChangelogExcludeTypes:           c.StringSlice("changelog-exclude-types"),
+		ChangelogIncludeTypes:           c.StringSlice("changelog-include-types"),
+		ChangelogDateFormat:             c.String("changelog-date-format"),
+		IssueURLTemplate:                c.String("issue-url-template"),
+		ChangelogTemplateFile:           c.String("changelog-template"),
+		ChangelogCommand:                c.String("changelog-command"),
+		ForceBump:                       c.String("force-bump"),
+		ForceBumpStrict:                 c.Bool("force-bump-strict"),
+		ReleaseDiscussionCategory:       c.String("release-discussion-category"),
+		MarkLatest:                      c.String("mark-latest"),
+		UseGitHubGeneratedNotes:         c.Bool("use-github-generated-notes"),
+		GitHubGeneratedNotesChangelog:   c.Bool("github-generated-notes-changelog"),
+		CurrentBranch:                   c.String("current-branch"),
+		DefaultBranchOverride:           c.String("default-branch"),
+		CurrentSHA:                      c.String("current-sha"),
+		APIRetries:                      c.Int("api-retries"),
+		APIRetryWait:                    c.Duration("api-retry-wait"),
+		SinceDuration:                   c.Duration("since-duration"),
+		WaitForRateLimit:                c.Bool("wait-for-rate-limit"),
+		CacheDir:                        c.String("cache-dir"),
+		NotifyURL:                       c.String("notify-url"),
+		NotifyTemplate:                  c.String("notify-template"),
+		NotifySecret:                    c.String("notify-secret"),
+		OutputFile:                      c.String("output-file"),
+		OutputTemplate:                  c.String("output-template"),
+		Summary:                         c.Bool("summary"),
+		NoCache:                         c.Bool("no-cache"),
+		SignTags:                        c.Bool("sign-tags"),
+		RequireSignedCommits:            c.Bool("require-signed-commits"),
+		RequireSignedReleasingCommits:   c.Bool("require-signed-releasing-commits"),
+		SigningKey:                      c.String("signing-key"),
+		Local:                           c.Bool("local"),
+		Unshallow:                       c.Bool("unshallow"),
+		ParseSquashBody:                 c.Bool("parse-squash-body"),
+		MaintenanceBranchPattern:        c.String("maintenance-branch-pattern"),
+		Promote:                         c.Bool("promote"),
+		Verify:                          c.Bool("verify"),
+		Uploads:                         c.StringSlice("upload"),
+		UploadChecksums:                 c.Bool("upload-checksums"),
 		BetaRelease:                     &BetaRelease{},
 	}
 
+	// the slug is checked here instead of by the flag as it may be set in the config file
+	if conf.Slug == "" {
+		return nil, errors.New("--slug is required (set the flag, a CI variable or slug in the config file)")
+	}
+
+	// urfave/cli does not set the flag of an alias, the github names are separate flags
+	if conf.GheAPIURL == "" {
+		conf.GheAPIURL = c.String("github-base-url")
+	}
+	if conf.GheUploadURL == "" {
+		conf.GheUploadURL = c.String("github-upload-url")
+	}
+
+	if conf.GitHubAppID != 0 || conf.GitHubAppInstallationID != 0 || conf.GitHubAppPrivateKey != "" {
+		if conf.GitHubAppID == 0 || conf.GitHubAppInstallationID == 0 || conf.GitHubAppPrivateKey == "" {
+			return nil, errors.New("--github-app-id, --github-app-installation-id and --github-app-private-key must be set together")
+		}
+		if conf.GitLab || conf.Bitbucket || conf.Gitea || conf.Gogs {
+			return nil, errors.New("GitHub App authentication is only supported on GitHub")
+		}
+	}
+
+	if err := readToken(conf, c.String("token-file")); err != nil {
+		return nil, err
+	}
+
+	if conf.CreditBy != "" && conf.CreditBy != "author" && conf.CreditBy != "committer" {
+		return nil, fmt.Errorf("invalid credit-by value: %s (must be author or committer)", conf.CreditBy)
+	}
+
+	switch conf.ChangelogSince {
+	case "", "previous", "last-stable":
+	default:
+		return nil, fmt.Errorf("invalid changelog-since value: %s (must be previous or last-stable)", conf.ChangelogSince)
+	}
+
+	switch conf.MarkLatest {
+	case "", "true", "false", "auto":
+	default:
+		return nil, fmt.Errorf("invalid mark-latest value: %s (must be true, false or auto)", conf.MarkLatest)
+	}
+
+	if conf.InitialVersion != "" {
+		if _, err := semver.NewVersion(conf.InitialVersion); err != nil {
+			return nil, fmt.Errorf("invalid initial version: %s: %w", conf.InitialVersion, err)
+		}
+	}
+
+	if conf.PrereleaseChannel != "" && !prereleaseChannelPattern.MatchString(conf.PrereleaseChannel) {
+		return nil, fmt.Errorf("invalid prerelease channel: %s (must only contain alphanumerics and hyphens)", conf.PrereleaseChannel)
+	}
+
+	switch conf.ForceBump {
+	case "", "major", "minor", "patch":
+	default:
+		return nil, fmt.Errorf("invalid force-bump value: %s (must be major, minor or patch)", conf.ForceBump)
+	}
+	if conf.ForceBumpStrict && conf.ForceBump == "" {
+		return nil, errors.New("--force-bump-strict requires a --force-bump")
+	}
+
+	if rules := c.StringSlice("bump-rules"); len(rules) > 0 {
+		conf.BumpRules = make(map[string]string, len(rules))
+		for _, rule := range rules {
+			split := strings.SplitN(rule, "=", 2)
+			if len(split) != 2 || split[0] == "" {
+				return nil, fmt.Errorf("invalid bump rule: %s (must be type=level)", rule)
+			}
+			switch split[1] {
+			case "major", "minor", "patch", "none":
+			default:
+				return nil, fmt.Errorf("invalid bump rule: %s (level must be major, minor, patch or none)", rule)
+			}
+			conf.BumpRules[split[0]] = split[1]
+		}
+	}
+
+	if titles := c.StringSlice("changelog-section-titles"); len(titles) > 0 {
+		conf.ChangelogSectionTitles = make(map[string]string, len(titles))
+		for _, title := range titles {
+			split := strings.SplitN(title, "=", 2)
+			if len(split) != 2 || split[0] == "" || strings.TrimSpace(split[1]) == "" {
+				return nil, fmt.Errorf("invalid changelog section title: %s (must be type=title)", title)
+			}
+			if _, ok := conf.ChangelogSectionTitles[split[0]]; !ok {
+				conf.ChangelogSectionOrder = append(conf.ChangelogSectionOrder, split[0])
+			}
+			conf.ChangelogSectionTitles[split[0]] = strings.TrimSpace(split[1])
+		}
+	}
+
+	for _, level := range conf.MovingTags {
+		if level != "major" && level != "minor" {
+			return nil, fmt.Errorf("invalid moving tag: %s (must be major or minor)", level)
+		}
+	}
+
+	for _, target := range conf.Update {
+		if _, _, err := update.ParseTarget(target); err != nil {
+			return nil, err
+		}
+	}
+
+	if conf.CommitUpdates {
+		if len(conf.Update) == 0 {
+			return nil, errors.New("--commit-updates requires an --update file")
+		}
+		if _, err := update.ParseCommitMessage(conf.CommitUpdatesMessage); err != nil {
+			return nil, fmt.Errorf("invalid commit message: %w", err)
+		}
+	}
+	if conf.PushUpdates && !conf.CommitUpdates {
+		return nil, errors.New("--push-updates requires --commit-updates")
+	}
+
+	if conf.UpdateType != "" {
+		supported := update.SupportedTypes()
+		i := sort.SearchStrings(supported, conf.UpdateType)
+		if i == len(supported) || supported[i] != conf.UpdateType {
+			return nil, fmt.Errorf("invalid update type: %s (supported: %s)", conf.UpdateType, strings.Join(supported, ", "))
+		}
+	}
+
+	if conf.CommitPattern != "" {
+		re, err := regexp.Compile(conf.CommitPattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid commit pattern: %w", err)
+		}
+		if !hasCommitPatternGroups(re) {
+			return nil, errors.New("invalid commit pattern: must contain groups for the type and subject (named type and subject or 3 to 4 positional groups)")
+		}
+	}
+
+	if _, err := logging.ParseLevel(conf.LogLevel); err != nil {
+		return nil, err
+	}
+
+	switch conf.LogFormat {
+	case "", "text", "json":
+	default:
+		return nil, fmt.Errorf("invalid log format: %s (must be text or json)", conf.LogFormat)
+	}
+
+	if conf.Promote && conf.PrereleaseChannel == "" {
+		return nil, errors.New("--promote requires a --prerelease-channel")
+	}
+	if conf.IncludePrereleases && conf.PrereleaseChannel != "" {
+		return nil, errors.New("--include-prereleases can not be used with --prerelease-channel")
+	}
+	if conf.Promote && conf.ForceBump != "" {
+		return nil, errors.New("--force-bump can not be used with --promote")
+	}
+
+	for _, scope := range conf.NoReleaseScopes {
+		if !noReleaseScopePattern.MatchString(scope) {
+			return nil, fmt.Errorf("invalid no-release scope: %s (must be scope or type(scope))", scope)
+		}
+	}
+
+	if conf.NoReleaseOnScopeMismatch && conf.PkgPath == "" {
+		return nil, errors.New("--no-release-on-scope-mismatch requires a --pkg-path")
+	}
+
+	if conf.SinceDuration < 0 {
+		return nil, fmt.Errorf("invalid since-duration: %s (must be positive)", conf.SinceDuration)
+	}
+
+	if conf.UploadChecksums && len(conf.Uploads) == 0 {
+		return nil, errors.New("--upload-checksums requires at least one --upload")
+	}
+
+	if conf.SignTags && conf.SigningKey == "" {
+		return nil, errors.New("--sign-tags requires a --signing-key")
+	}
+
+	if conf.GitHubGeneratedNotesChangelog && !conf.UseGitHubGeneratedNotes {
+		return nil, errors.New("--github-generated-notes-changelog requires --use-github-generated-notes")
+	}
+
+	if conf.Unshallow && !conf.Local {
+		return nil, errors.New("--unshallow requires --local")
+	}
+
+	if conf.Gogs && len(conf.MovingTags) > 0 {
+		return nil, errors.New("--moving-tags is not supported on Gogs")
+	}
+
+	// only the GitHub API reports the verification of the commit signatures
+	if conf.RequireSignedCommits || conf.RequireSignedReleasingCommits {
+		if conf.GitLab || conf.Bitbucket || conf.Gitea || conf.Gogs {
+			return nil, errors.New("requiring signed commits is only supported on GitHub")
+		}
+		if conf.Local {
+			return nil, errors.New("requiring signed commits is not supported with --local")
+		}
+	}
+
+	if ts := c.String("release-timestamp"); ts != "" {
+		releaseTimestamp, err := time.Parse(time.RFC3339, ts)
+		if err != nil {
+			return nil, fmt.Errorf("invalid release timestamp: %w", err)
+		}
+		conf.ReleaseTimestamp = releaseTimestamp
+	}
+
+	// a layout without any time element renders the same text for every date
+	if conf.ChangelogDateFormat != "" && (time.Time{}).Format(conf.ChangelogDateFormat) == conf.ChangelogDateFormat {
+		return nil, fmt.Errorf("invalid changelog date format: %s (must be a Go time layout like 2006-01-02)", conf.ChangelogDateFormat)
+	}
+
+	if tz := c.String("changelog-timezone"); tz != "" {
+		location, err := time.LoadLocation(tz)
+		if err != nil {
+			return nil, fmt.Errorf("invalid changelog timezone: %w", err)
+		}
+		conf.ChangelogTimezone = location
+	}
+
+	if conf.ChangelogTemplateFile != "" {
+		data, err := ioutil.ReadFile(conf.ChangelogTemplateFile)
+		if err != nil {
+			return nil, fmt.Errorf("could not read changelog template: %w", err)
+		}
+		tmpl, err := template.New(filepath.Base(conf.ChangelogTemplateFile)).Parse(string(data))
+		if err != nil {
+			return nil, fmt.Errorf("invalid changelog template: %w", err)
+		}
+		conf.ChangelogTemplate = tmpl
+	}
+
+	if conf.IssueURLTemplate != "" {
+		tmpl, err := template.New("issue").Parse(conf.IssueURLTemplate)
+		if err != nil {
+			return nil, fmt.Errorf("invalid issue url template: %w", err)
+		}
+		conf.IssueURL = tmpl
+	}
+
+	if conf.NotifyURL != "" {
+		if u, err := url.Parse(conf.NotifyURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			return nil, fmt.Errorf("invalid notify url: %s (must be a http or https url)", conf.NotifyURL)
+		}
+	}
+
+	if conf.NotifyTemplate != "" {
+		if _, err := notify.ParseTemplate(conf.NotifyTemplate); err != nil {
+			return nil, fmt.Errorf("invalid notify template: %w", err)
+		}
+	}
+
+	if conf.ChangelogPrepend && conf.Changelog == "" {
+		return nil, errors.New("--changelog-prepend requires a --changelog file")
+	}
+	if conf.ChangelogHeaderMarker != "" && !conf.ChangelogPrepend {
+		return nil, errors.New("--changelog-header-marker requires --changelog-prepend")
+	}
+
+	if conf.OutputTemplate != "" {
+		if conf.OutputFile == "" {
+			return nil, errors.New("--output-template requires an --output-file")
+		}
+		if _, err := output.ParseTemplate(conf.OutputTemplate); err != nil {
+			return nil, fmt.Errorf("invalid output template: %w", err)
+		}
+	}
+
 	f, err := os.OpenFile(".semrelrc", os.O_RDONLY, 0)
 	if err != nil {
 		return conf, nil
@@ -71,3 +544,83 @@ func NewConfig(c *cli.Context) (*Config, error) {
 
 	return conf, nil
 }
+
+// stdin is read for the token if --token @- or --token-file - is set
+var stdin io.Reader = os.Stdin
+
+// readToken reads the token from the token file or stdin, the token must not be passed inline at the same time
+func readToken(conf *Config, tokenFile string) error {
+	if conf.Token == "@-" {
+		if tokenFile != "" {
+			return errors.New("--token and --token-file are mutually exclusive")
+		}
+		tokenFile = "-"
+		conf.Token = ""
+	}
+	if tokenFile != "" {
+		if conf.Token != "" {
+			return errors.New("--token and --token-file are mutually exclusive")
+		}
+		var data []byte
+		var err error
+		if tokenFile == "-" {
+			data, err = ioutil.ReadAll(stdin)
+		} else {
+			data, err = ioutil.ReadFile(tokenFile)
+		}
+		if err != nil {
+			return fmt.Errorf("could not read token: %w", err)
+		}
+		conf.Token = strings.TrimRight(string(data), "\r\n")
+	}
+	if conf.GitHubAppID != 0 {
+		// a GitHub App authenticates with its installation token instead
+		if conf.ReadToken != "" || conf.WriteToken != "" {
+			return errors.New("--read-token and --write-token can not be used with a GitHub App")
+		}
+		return nil
+	}
+	if conf.ReadToken == "" {
+		conf.ReadToken = conf.Token
+	}
+	if conf.WriteToken == "" {
+		conf.WriteToken = conf.Token
+	}
+	if conf.ReadToken == "" || conf.WriteToken == "" {
+		return errors.New("token is required, set it with --token, --token-file or an env var")
+	}
+	return nil
+}
+
+// Print writes the effective configuration as JSON with the token redacted
+func Print(w io.Writer, conf *Config) error {
+	redacted := *conf
+	if redacted.Token != "" {
+		redacted.Token = "[REDACTED]"
+	}
+	if redacted.ReadToken != "" {
+		redacted.ReadToken = "[REDACTED]"
+	}
+	if redacted.WriteToken != "" {
+		redacted.WriteToken = "[REDACTED]"
+	}
+	if redacted.NotifySecret != "" {
+		redacted.NotifySecret = "[REDACTED]"
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(redacted)
+}
+
+// hasCommitPatternGroups reports whether the commit pattern has the groups semrel.NewCommitPattern requires,
+// named type and subject groups or 3 to 4 positional groups. The pattern is compiled by semrel which imports config.
+func hasCommitPatternGroups(re *regexp.Regexp) bool {
+	named := make(map[string]bool)
+	for _, name := range re.SubexpNames() {
+		named[name] = true
+	}
+	if named["type"] || named["subject"] {
+		return named["type"] && named["subject"]
+	}
+	return re.NumSubexp() == 3 || re.NumSubexp() == 4
+}
@@ -0,0 +1,600 @@
+package config
+
+import (
+	"bytes"
+	"flag"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli/v2"
+)
+
+// newTestContext parses the arguments with a slug unless it is set by the arguments
+func newTestContext(t *testing.T, args []string) *cli.Context {
+	for _, arg := range args {
+		if arg == "--slug" {
+			return newCliContext(t, args)
+		}
+	}
+	return newCliContext(t, append([]string{"--slug", "owner/test-repo"}, args...))
+}
+
+func newCliContext(t *testing.T, args []string) *cli.Context {
+	set := flag.NewFlagSet("test", flag.ContinueOnError)
+	for _, f := range CliFlags {
+		// slice flags keep their values between parses, every context needs a fresh value
+		if sf, ok := f.(*cli.StringSliceFlag); ok {
+			fresh := *sf
+			fresh.Value = nil
+			f = &fresh
+		}
+		require.NoError(t, f.Apply(set))
+	}
+	require.NoError(t, set.Parse(args))
+	app := cli.NewApp()
+	app.Flags = CliFlags
+	return cli.NewContext(app, set, nil)
+}
+
+func chdirTemp(t *testing.T) func() {
+	cwd, err := os.Getwd()
+	require.NoError(t, err)
+	dir, err := ioutil.TempDir("", "semrel-config")
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(dir))
+	return func() {
+		os.Chdir(cwd)
+		os.RemoveAll(dir)
+	}
+}
+
+func TestNewConfigPrecedence(t *testing.T) {
+	defer chdirTemp(t)()
+	require.NoError(t, ioutil.WriteFile(".semrelrc", []byte(`{"maintainedVersion": "2-beta"}`), 0644))
+	os.Setenv("GITHUB_ENTERPRISE_HOST", "env.example.com")
+	defer os.Unsetenv("GITHUB_ENTERPRISE_HOST")
+
+	conf, err := NewConfig(newTestContext(t, []string{"--token", "secret", "--slug", "owner/repo"}))
+	require.NoError(t, err)
+	require.Equal(t, "env.example.com", conf.GheHost)
+	require.Equal(t, "2-beta", conf.BetaRelease.MaintainedVersion)
+
+	conf, err = NewConfig(newTestContext(t, []string{"--token", "secret", "--ghe-host", "flag.example.com"}))
+	require.NoError(t, err)
+	require.Equal(t, "flag.example.com", conf.GheHost)
+}
+
+func TestPrintConfig(t *testing.T) {
+	defer chdirTemp(t)()
+	conf, err := NewConfig(newTestContext(t, []string{"--token", "secret", "--slug", "owner/repo", "--print-config"}))
+	require.NoError(t, err)
+	require.True(t, conf.PrintConfig)
+
+	var buf bytes.Buffer
+	require.NoError(t, Print(&buf, conf))
+	require.NotContains(t, buf.String(), "secret")
+	require.Contains(t, buf.String(), `"Token": "[REDACTED]"`)
+	require.Contains(t, buf.String(), `"Slug": "owner/repo"`)
+	require.Equal(t, "secret", conf.Token, "token of the original config must not be modified")
+}
+
+func TestNewConfigFromFile(t *testing.T) {
+	defer chdirTemp(t)()
+	require.NoError(t, ioutil.WriteFile(DefaultConfigFile, []byte("match: ^v\nghe-host: file.example.com\ngitlab-base-url: https://file.example.com\nmoving-tags: [major, minor]\nallow-no-changes: true\n"), 0644))
+	os.Setenv("CI_SERVER_URL", "https://env.example.com")
+	defer os.Unsetenv("CI_SERVER_URL")
+
+	conf, err := NewConfig(newTestContext(t, []string{"--token", "secret", "--ghe-host", "flag.example.com"}))
+	require.NoError(t, err)
+	require.Equal(t, "flag.example.com", conf.GheHost)
+	require.Equal(t, "https://env.example.com", conf.GitLabBaseURL)
+	require.Equal(t, "^v", conf.Match)
+	require.Equal(t, []string{"major", "minor"}, conf.MovingTags)
+	require.True(t, conf.AllowNoChanges)
+	require.False(t, conf.Dry)
+}
+
+func TestNewConfigSlugFromFile(t *testing.T) {
+	defer chdirTemp(t)()
+	for _, key := range []string{"GITHUB_REPOSITORY", "TRAVIS_REPO_SLUG", "CI_PROJECT_PATH_SLUG", "BITBUCKET_REPO_FULL_NAME"} {
+		if value, ok := os.LookupEnv(key); ok {
+			os.Unsetenv(key)
+			defer os.Setenv(key, value)
+		}
+	}
+	_, err := NewConfig(newCliContext(t, []string{"--token", "secret"}))
+	require.EqualError(t, err, "--slug is required (set the flag, a CI variable or slug in the config file)")
+
+	// empty values keep the default
+	require.NoError(t, ioutil.WriteFile(DefaultConfigFile, []byte("slug: owner/file-repo\nmatch:\nmoving-tags: [major, ~]\n"), 0644))
+	conf, err := NewConfig(newCliContext(t, []string{"--token", "secret"}))
+	require.NoError(t, err)
+	require.Equal(t, "owner/file-repo", conf.Slug)
+	require.Equal(t, "", conf.Match)
+	require.Equal(t, []string{"major"}, conf.MovingTags)
+}
+
+func TestNewConfigFromFileErrors(t *testing.T) {
+	defer chdirTemp(t)()
+	require.NoError(t, ioutil.WriteFile("release.json", []byte(`{"match": "^v", "unknown-option": true}`), 0644))
+
+	_, err := NewConfig(newTestContext(t, []string{"--token", "secret", "--config", "release.json"}))
+	require.EqualError(t, err, `config file release.json: unknown key "unknown-option"`)
+
+	_, err = NewConfig(newTestContext(t, []string{"--token", "secret", "--config", "missing.yaml"}))
+	require.Error(t, err)
+}
+
+func TestNewConfigChangelogTemplate(t *testing.T) {
+	defer chdirTemp(t)()
+	require.NoError(t, ioutil.WriteFile("changelog.tmpl", []byte("## {{.Version}}"), 0644))
+	conf, err := NewConfig(newTestContext(t, []string{"--token", "secret", "--changelog-template", "changelog.tmpl"}))
+	require.NoError(t, err)
+	require.NotNil(t, conf.ChangelogTemplate)
+
+	require.NoError(t, ioutil.WriteFile("broken.tmpl", []byte("## {{.Version"), 0644))
+	_, err = NewConfig(newTestContext(t, []string{"--token", "secret", "--changelog-template", "broken.tmpl"}))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "invalid changelog template")
+}
+
+func TestNewConfigTokenFile(t *testing.T) {
+	defer chdirTemp(t)()
+	// a token of the environment would conflict with the token file
+	for _, key := range []string{"GITHUB_TOKEN", "GH_TOKEN", "GITLAB_TOKEN", "BITBUCKET_TOKEN", "GITEA_TOKEN"} {
+		if value, ok := os.LookupEnv(key); ok {
+			os.Unsetenv(key)
+			defer os.Setenv(key, value)
+		}
+	}
+	require.NoError(t, ioutil.WriteFile("token", []byte("file-secret\n"), 0600))
+
+	conf, err := NewConfig(newTestContext(t, []string{"--token-file", "token"}))
+	require.NoError(t, err)
+	require.Equal(t, "file-secret", conf.Token)
+
+	_, err = NewConfig(newTestContext(t, []string{"--token-file", "missing"}))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "could not read token")
+
+	_, err = NewConfig(newTestContext(t, []string{"--token", "secret", "--token-file", "token"}))
+	require.EqualError(t, err, "--token and --token-file are mutually exclusive")
+
+	stdin = strings.NewReader("stdin-secret\n")
+	defer func() { stdin = os.Stdin }()
+	conf, err = NewConfig(newTestContext(t, []string{"--token", "@-"}))
+	require.NoError(t, err)
+	require.Equal(t, "stdin-secret", conf.Token)
+}
+
+func TestNewConfigPrereleaseChannel(t *testing.T) {
+	defer chdirTemp(t)()
+	conf, err := NewConfig(newTestContext(t, []string{"--token", "secret", "--prerelease-channel", "beta"}))
+	require.NoError(t, err)
+	require.Equal(t, "beta", conf.PrereleaseChannel)
+
+	_, err = NewConfig(newTestContext(t, []string{"--token", "secret", "--prerelease-channel", "beta.1"}))
+	require.EqualError(t, err, "invalid prerelease channel: beta.1 (must only contain alphanumerics and hyphens)")
+}
+
+func TestNewConfigBumpRules(t *testing.T) {
+	defer chdirTemp(t)()
+	conf, err := NewConfig(newTestContext(t, []string{"--token", "secret", "--bump-rules", "perf=patch", "--bump-rules", "security=minor"}))
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"perf": "patch", "security": "minor"}, conf.BumpRules)
+
+	_, err = NewConfig(newTestContext(t, []string{"--token", "secret", "--bump-rules", "perf=huge"}))
+	require.EqualError(t, err, "invalid bump rule: perf=huge (level must be major, minor, patch or none)")
+	_, err = NewConfig(newTestContext(t, []string{"--token", "secret", "--bump-rules", "perf"}))
+	require.EqualError(t, err, "invalid bump rule: perf (must be type=level)")
+}
+
+func TestNewConfigForceBump(t *testing.T) {
+	defer chdirTemp(t)()
+	conf, err := NewConfig(newTestContext(t, []string{"--token", "secret", "--force-bump", "major", "--force-bump-strict"}))
+	require.NoError(t, err)
+	require.Equal(t, "major", conf.ForceBump)
+	require.True(t, conf.ForceBumpStrict)
+
+	_, err = NewConfig(newTestContext(t, []string{"--token", "secret", "--force-bump", "huge"}))
+	require.EqualError(t, err, "invalid force-bump value: huge (must be major, minor or patch)")
+	_, err = NewConfig(newTestContext(t, []string{"--token", "secret", "--force-bump-strict"}))
+	require.EqualError(t, err, "--force-bump-strict requires a --force-bump")
+	_, err = NewConfig(newTestContext(t, []string{"--token", "secret", "--force-bump", "major", "--promote", "--prerelease-channel", "beta"}))
+	require.EqualError(t, err, "--force-bump can not be used with --promote")
+}
+
+func TestNewConfigSignTags(t *testing.T) {
+	defer chdirTemp(t)()
+	if value, ok := os.LookupEnv("SIGNING_KEY"); ok {
+		os.Unsetenv("SIGNING_KEY")
+		defer os.Setenv("SIGNING_KEY", value)
+	}
+	_, err := NewConfig(newTestContext(t, []string{"--token", "secret", "--sign-tags"}))
+	require.EqualError(t, err, "--sign-tags requires a --signing-key")
+
+	conf, err := NewConfig(newTestContext(t, []string{"--token", "secret", "--sign-tags", "--signing-key", "ABCDEF"}))
+	require.NoError(t, err)
+	require.True(t, conf.SignTags)
+	require.Equal(t, "ABCDEF", conf.SigningKey)
+}
+
+func TestNewConfigUpdateType(t *testing.T) {
+	defer chdirTemp(t)()
+	conf, err := NewConfig(newTestContext(t, []string{"--token", "secret", "--update", "Chart.yaml", "--update", "package.json", "--update-type", "yaml"}))
+	require.NoError(t, err)
+	require.Equal(t, []string{"Chart.yaml", "package.json"}, conf.Update)
+	require.Equal(t, "yaml", conf.UpdateType)
+	require.Equal(t, "version", conf.UpdateYAMLKey)
+
+	conf, err = NewConfig(newTestContext(t, []string{"--token", "secret", "--update", `Dockerfile:ARG VERSION=([\d.]+)`}))
+	require.NoError(t, err)
+	require.Equal(t, []string{`Dockerfile:ARG VERSION=([\d.]+)`}, conf.Update)
+	_, err = NewConfig(newTestContext(t, []string{"--token", "secret", "--update", "Dockerfile:ARG VERSION=[\\d.]+"}))
+	require.EqualError(t, err, `invalid update pattern ARG VERSION=[\d.]+: the version must be matched by a capture group`)
+
+	_, err = NewConfig(newTestContext(t, []string{"--token", "secret", "--update-type", "xml"}))
+	require.EqualError(t, err, "invalid update type: xml (supported: Cargo.toml, package.json, pyproject.toml, toml, yaml, yml)")
+}
+
+func TestNewConfigIssueURLTemplate(t *testing.T) {
+	defer chdirTemp(t)()
+	conf, err := NewConfig(newTestContext(t, []string{"--token", "secret", "--issue-url-template", "https://jira.example.com/browse/{{.Issue}}"}))
+	require.NoError(t, err)
+	require.Equal(t, "https://jira.example.com/browse/{{.Issue}}", conf.IssueURLTemplate)
+	require.NotNil(t, conf.IssueURL)
+
+	_, err = NewConfig(newTestContext(t, []string{"--token", "secret", "--issue-url-template", "https://jira.example.com/browse/{{.Issue"}))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "invalid issue url template")
+}
+
+func TestNewConfigPromote(t *testing.T) {
+	defer chdirTemp(t)()
+	_, err := NewConfig(newTestContext(t, []string{"--token", "secret", "--promote"}))
+	require.EqualError(t, err, "--promote requires a --prerelease-channel")
+
+	conf, err := NewConfig(newTestContext(t, []string{"--token", "secret", "--promote", "--prerelease-channel", "beta"}))
+	require.NoError(t, err)
+	require.True(t, conf.Promote)
+}
+
+func TestNewConfigUploads(t *testing.T) {
+	defer chdirTemp(t)()
+	_, err := NewConfig(newTestContext(t, []string{"--token", "secret", "--upload-checksums"}))
+	require.EqualError(t, err, "--upload-checksums requires at least one --upload")
+
+	conf, err := NewConfig(newTestContext(t, []string{"--token", "secret", "--upload", "dist/*", "--upload", "README.md", "--upload-checksums"}))
+	require.NoError(t, err)
+	require.Equal(t, []string{"dist/*", "README.md"}, conf.Uploads)
+	require.True(t, conf.UploadChecksums)
+}
+
+func TestNewConfigLogging(t *testing.T) {
+	defer chdirTemp(t)()
+	conf, err := NewConfig(newTestContext(t, []string{"--token", "secret"}))
+	require.NoError(t, err)
+	require.Equal(t, "info", conf.LogLevel)
+	require.Equal(t, "text", conf.LogFormat)
+
+	conf, err = NewConfig(newTestContext(t, []string{"--token", "secret", "--log-level", "debug", "--log-format", "json"}))
+	require.NoError(t, err)
+	require.Equal(t, "debug", conf.LogLevel)
+	require.Equal(t, "json", conf.LogFormat)
+
+	_, err = NewConfig(newTestContext(t, []string{"--token", "secret", "--log-level", "verbose"}))
+	require.EqualError(t, err, "invalid log level: verbose (supported: debug, info, warn, error)")
+	_, err = NewConfig(newTestContext(t, []string{"--token", "secret", "--log-format", "xml"}))
+	require.EqualError(t, err, "invalid log format: xml (must be text or json)")
+}
+
+func TestNewConfigNoReleaseScopes(t *testing.T) {
+	defer chdirTemp(t)()
+	conf, err := NewConfig(newTestContext(t, []string{"--token", "secret", "--no-release-if-only-scopes", "deps", "--no-release-if-only-scopes", "docs(api)"}))
+	require.NoError(t, err)
+	require.Equal(t, []string{"deps", "docs(api)"}, conf.NoReleaseScopes)
+
+	for _, scope := range []string{"docs()", "(api)", "docs(api"} {
+		_, err = NewConfig(newTestContext(t, []string{"--token", "secret", "--no-release-if-only-scopes", scope}))
+		require.EqualError(t, err, "invalid no-release scope: "+scope+" (must be scope or type(scope))")
+	}
+}
+
+func TestNewConfigCommitPattern(t *testing.T) {
+	defer chdirTemp(t)()
+	conf, err := NewConfig(newTestContext(t, []string{"--token", "secret", "--commit-pattern", `^\[\w+-\d+\] (\w+)(?:\((.*)\))?: (.*)$`}))
+	require.NoError(t, err)
+	require.Equal(t, `^\[\w+-\d+\] (\w+)(?:\((.*)\))?: (.*)$`, conf.CommitPattern)
+
+	_, err = NewConfig(newTestContext(t, []string{"--token", "secret", "--commit-pattern", `^(\w+`}))
+	require.EqualError(t, err, "invalid commit pattern: error parsing regexp: missing closing ): `^(\\w+`")
+
+	for _, pattern := range []string{`^(\w+): (.*)$`, `^(?P<type>\w+): .*$`} {
+		_, err = NewConfig(newTestContext(t, []string{"--token", "secret", "--commit-pattern", pattern}))
+		require.EqualError(t, err, "invalid commit pattern: must contain groups for the type and subject (named type and subject or 3 to 4 positional groups)")
+	}
+	_, err = NewConfig(newTestContext(t, []string{"--token", "secret", "--commit-pattern", `^(?P<subject>.*) \((?P<type>\w+)\)$`}))
+	require.NoError(t, err)
+}
+
+func TestNewConfigCache(t *testing.T) {
+	defer chdirTemp(t)()
+	conf, err := NewConfig(newTestContext(t, []string{"--token", "secret", "--cache-dir", ".cache/semrel", "--no-cache"}))
+	require.NoError(t, err)
+	require.Equal(t, ".cache/semrel", conf.CacheDir)
+	require.True(t, conf.NoCache)
+}
+
+func TestNewConfigChangelogSince(t *testing.T) {
+	defer chdirTemp(t)()
+	conf, err := NewConfig(newTestContext(t, []string{"--token", "secret"}))
+	require.NoError(t, err)
+	require.Equal(t, "previous", conf.ChangelogSince)
+
+	conf, err = NewConfig(newTestContext(t, []string{"--token", "secret", "--changelog-since", "last-stable"}))
+	require.NoError(t, err)
+	require.Equal(t, "last-stable", conf.ChangelogSince)
+
+	_, err = NewConfig(newTestContext(t, []string{"--token", "secret", "--changelog-since", "beginning"}))
+	require.EqualError(t, err, "invalid changelog-since value: beginning (must be previous or last-stable)")
+}
+
+func TestNewConfigNotify(t *testing.T) {
+	defer chdirTemp(t)()
+	conf, err := NewConfig(newTestContext(t, []string{"--token", "secret", "--notify-url", "https://hooks.example.com/release", "--notify-template", `{"text": {{json .Tag}}}`, "--notify-secret", "hmac"}))
+	require.NoError(t, err)
+	require.Equal(t, "https://hooks.example.com/release", conf.NotifyURL)
+	require.Equal(t, "hmac", conf.NotifySecret)
+
+	var buf bytes.Buffer
+	require.NoError(t, Print(&buf, conf))
+	require.NotContains(t, buf.String(), "hmac")
+
+	_, err = NewConfig(newTestContext(t, []string{"--token", "secret", "--notify-url", "hooks.example.com"}))
+	require.EqualError(t, err, "invalid notify url: hooks.example.com (must be a http or https url)")
+	_, err = NewConfig(newTestContext(t, []string{"--token", "secret", "--notify-url", "https://hooks.example.com", "--notify-template", "{{.Tag"}))
+	require.Error(t, err)
+}
+
+func TestNewConfigInitialVersion(t *testing.T) {
+	defer chdirTemp(t)()
+	conf, err := NewConfig(newTestContext(t, []string{"--token", "secret", "--initial-version", "1.0.0"}))
+	require.NoError(t, err)
+	require.Equal(t, "1.0.0", conf.InitialVersion)
+
+	_, err = NewConfig(newTestContext(t, []string{"--token", "secret", "--initial-version", "one"}))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "invalid initial version: one")
+}
+
+func TestNewConfigGitHubApp(t *testing.T) {
+	defer chdirTemp(t)()
+	conf, err := NewConfig(newTestContext(t, []string{"--github-app-id", "7", "--github-app-installation-id", "42", "--github-app-private-key", "app.pem"}))
+	require.NoError(t, err)
+	require.Equal(t, int64(7), conf.GitHubAppID)
+	require.Equal(t, int64(42), conf.GitHubAppInstallationID)
+	require.Equal(t, "app.pem", conf.GitHubAppPrivateKey)
+
+	_, err = NewConfig(newTestContext(t, []string{"--github-app-id", "7", "--github-app-private-key", "app.pem"}))
+	require.EqualError(t, err, "--github-app-id, --github-app-installation-id and --github-app-private-key must be set together")
+	_, err = NewConfig(newTestContext(t, []string{"--gitlab", "--github-app-id", "7", "--github-app-installation-id", "42", "--github-app-private-key", "app.pem"}))
+	require.EqualError(t, err, "GitHub App authentication is only supported on GitHub")
+}
+
+func TestNewConfigChangelogDate(t *testing.T) {
+	defer chdirTemp(t)()
+	conf, err := NewConfig(newTestContext(t, []string{"--token", "secret"}))
+	require.NoError(t, err)
+	require.Equal(t, "2006-01-02", conf.ChangelogDateFormat)
+	require.Equal(t, time.UTC, conf.ChangelogTimezone)
+
+	conf, err = NewConfig(newTestContext(t, []string{"--token", "secret", "--changelog-date-format", "January 2, 2006"}))
+	require.NoError(t, err)
+	require.Equal(t, "January 2, 2006", conf.ChangelogDateFormat)
+
+	_, err = NewConfig(newTestContext(t, []string{"--token", "secret", "--changelog-date-format", "today"}))
+	require.EqualError(t, err, "invalid changelog date format: today (must be a Go time layout like 2006-01-02)")
+	_, err = NewConfig(newTestContext(t, []string{"--token", "secret", "--changelog-timezone", "Mars/Olympus_Mons"}))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "invalid changelog timezone")
+}
+
+func TestNewConfigSinceDuration(t *testing.T) {
+	defer chdirTemp(t)()
+	conf, err := NewConfig(newTestContext(t, []string{"--token", "secret"}))
+	require.NoError(t, err)
+	require.Zero(t, conf.SinceDuration)
+
+	conf, err = NewConfig(newTestContext(t, []string{"--token", "secret", "--since-duration", "720h"}))
+	require.NoError(t, err)
+	require.Equal(t, 30*24*time.Hour, conf.SinceDuration)
+
+	_, err = NewConfig(newTestContext(t, []string{"--token", "secret", "--since-duration", "-1h"}))
+	require.EqualError(t, err, "invalid since-duration: -1h0m0s (must be positive)")
+}
+
+func TestNewConfigNoReleaseOnScopeMismatch(t *testing.T) {
+	defer chdirTemp(t)()
+	conf, err := NewConfig(newTestContext(t, []string{"--token", "secret", "--pkg-path", "packages/b", "--no-release-on-scope-mismatch"}))
+	require.NoError(t, err)
+	require.True(t, conf.NoReleaseOnScopeMismatch)
+
+	_, err = NewConfig(newTestContext(t, []string{"--token", "secret", "--no-release-on-scope-mismatch"}))
+	require.EqualError(t, err, "--no-release-on-scope-mismatch requires a --pkg-path")
+}
+
+func TestNewConfigIgnoreMergeCommits(t *testing.T) {
+	defer chdirTemp(t)()
+	conf, err := NewConfig(newTestContext(t, []string{"--token", "secret"}))
+	require.NoError(t, err)
+	require.True(t, conf.IgnoreMergeCommits)
+
+	conf, err = NewConfig(newTestContext(t, []string{"--token", "secret", "--ignore-merge-commits=false"}))
+	require.NoError(t, err)
+	require.False(t, conf.IgnoreMergeCommits)
+}
+
+func TestNewConfigOutputFile(t *testing.T) {
+	defer chdirTemp(t)()
+	conf, err := NewConfig(newTestContext(t, []string{"--token", "secret", "--output-file", "release.json", "--output-template", `{"version": {{json .Version}}}`}))
+	require.NoError(t, err)
+	require.Equal(t, "release.json", conf.OutputFile)
+
+	_, err = NewConfig(newTestContext(t, []string{"--token", "secret", "--output-template", "{{.Version}}"}))
+	require.EqualError(t, err, "--output-template requires an --output-file")
+	_, err = NewConfig(newTestContext(t, []string{"--token", "secret", "--output-file", "release.env", "--output-template", "{{.Version"}))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "invalid output template")
+}
+
+func TestNewConfigRequireSignedCommits(t *testing.T) {
+	defer chdirTemp(t)()
+	conf, err := NewConfig(newTestContext(t, []string{"--token", "secret", "--require-signed-commits"}))
+	require.NoError(t, err)
+	require.True(t, conf.RequireSignedCommits)
+
+	_, err = NewConfig(newTestContext(t, []string{"--token", "secret", "--gitlab", "--require-signed-releasing-commits"}))
+	require.EqualError(t, err, "requiring signed commits is only supported on GitHub")
+	_, err = NewConfig(newTestContext(t, []string{"--token", "secret", "--local", "--require-signed-commits"}))
+	require.EqualError(t, err, "requiring signed commits is not supported with --local")
+}
+
+func TestNewConfigGitHubBaseURL(t *testing.T) {
+	defer chdirTemp(t)()
+	conf, err := NewConfig(newTestContext(t, []string{"--token", "secret", "--github-base-url", "https://proxy.example.com/github", "--github-upload-url", "https://proxy.example.com/uploads"}))
+	require.NoError(t, err)
+	require.Equal(t, "https://proxy.example.com/github", conf.GheAPIURL)
+	require.Equal(t, "https://proxy.example.com/uploads", conf.GheUploadURL)
+}
+
+func TestNewConfigReadWriteToken(t *testing.T) {
+	defer chdirTemp(t)()
+	for _, key := range []string{"GITHUB_TOKEN", "GH_TOKEN", "GITLAB_TOKEN", "BITBUCKET_TOKEN", "GITEA_TOKEN"} {
+		if value, ok := os.LookupEnv(key); ok {
+			os.Unsetenv(key)
+			defer os.Setenv(key, value)
+		}
+	}
+
+	conf, err := NewConfig(newTestContext(t, []string{"--token", "secret"}))
+	require.NoError(t, err)
+	require.Equal(t, "secret", conf.ReadToken)
+	require.Equal(t, "secret", conf.WriteToken)
+
+	conf, err = NewConfig(newTestContext(t, []string{"--token", "secret", "--read-token", "read"}))
+	require.NoError(t, err)
+	require.Equal(t, "read", conf.ReadToken)
+	require.Equal(t, "secret", conf.WriteToken)
+
+	conf, err = NewConfig(newTestContext(t, []string{"--read-token", "read", "--write-token", "write"}))
+	require.NoError(t, err)
+	require.Equal(t, "read", conf.ReadToken)
+	require.Equal(t, "write", conf.WriteToken)
+
+	_, err = NewConfig(newTestContext(t, []string{"--read-token", "read"}))
+	require.EqualError(t, err, "token is required, set it with --token, --token-file or an env var")
+
+	_, err = NewConfig(newTestContext(t, []string{"--github-app-id", "7", "--github-app-installation-id", "42", "--github-app-private-key", "app.pem", "--write-token", "write"}))
+	require.EqualError(t, err, "--read-token and --write-token can not be used with a GitHub App")
+}
+
+func TestNewConfigChangelogIncludeBody(t *testing.T) {
+	defer chdirTemp(t)()
+	conf, err := NewConfig(newTestContext(t, []string{"--token", "secret"}))
+	require.NoError(t, err)
+	require.False(t, conf.ChangelogIncludeBody)
+
+	conf, err = NewConfig(newTestContext(t, []string{"--token", "secret", "--changelog-include-body"}))
+	require.NoError(t, err)
+	require.True(t, conf.ChangelogIncludeBody)
+}
+
+func TestNewConfigChangelogPrepend(t *testing.T) {
+	defer chdirTemp(t)()
+	conf, err := NewConfig(newTestContext(t, []string{"--token", "secret", "--changelog", "CHANGELOG.md", "--changelog-prepend", "--changelog-header-marker", "<!-- releases -->"}))
+	require.NoError(t, err)
+	require.True(t, conf.ChangelogPrepend)
+	require.Equal(t, "<!-- releases -->", conf.ChangelogHeaderMarker)
+
+	_, err = NewConfig(newTestContext(t, []string{"--token", "secret", "--changelog-prepend"}))
+	require.EqualError(t, err, "--changelog-prepend requires a --changelog file")
+
+	_, err = NewConfig(newTestContext(t, []string{"--token", "secret", "--changelog", "CHANGELOG.md", "--changelog-header-marker", "<!-- releases -->"}))
+	require.EqualError(t, err, "--changelog-header-marker requires --changelog-prepend")
+}
+
+func TestNewConfigUnshallow(t *testing.T) {
+	defer chdirTemp(t)()
+	conf, err := NewConfig(newTestContext(t, []string{"--token", "secret", "--local", "--unshallow"}))
+	require.NoError(t, err)
+	require.True(t, conf.Unshallow)
+
+	_, err = NewConfig(newTestContext(t, []string{"--token", "secret", "--unshallow"}))
+	require.EqualError(t, err, "--unshallow requires --local")
+}
+
+func TestNewConfigGitHubGeneratedNotes(t *testing.T) {
+	defer chdirTemp(t)()
+	conf, err := NewConfig(newTestContext(t, []string{"--token", "secret", "--use-github-generated-notes", "--github-generated-notes-changelog"}))
+	require.NoError(t, err)
+	require.True(t, conf.UseGitHubGeneratedNotes)
+	require.True(t, conf.GitHubGeneratedNotesChangelog)
+
+	_, err = NewConfig(newTestContext(t, []string{"--token", "secret", "--github-generated-notes-changelog"}))
+	require.EqualError(t, err, "--github-generated-notes-changelog requires --use-github-generated-notes")
+}
+
+func TestNewConfigCommitUpdates(t *testing.T) {
+	defer chdirTemp(t)()
+	conf, err := NewConfig(newTestContext(t, []string{"--token", "secret", "--update", "package.json", "--commit-updates", "--push-updates"}))
+	require.NoError(t, err)
+	require.True(t, conf.CommitUpdates)
+	require.True(t, conf.PushUpdates)
+	require.Equal(t, "chore(release): {{.Tag}} [skip ci]", conf.CommitUpdatesMessage)
+
+	_, err = NewConfig(newTestContext(t, []string{"--token", "secret", "--commit-updates"}))
+	require.EqualError(t, err, "--commit-updates requires an --update file")
+
+	_, err = NewConfig(newTestContext(t, []string{"--token", "secret", "--update", "package.json", "--commit-updates", "--commit-updates-message", "release {{.Tag"}))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "invalid commit message")
+
+	_, err = NewConfig(newTestContext(t, []string{"--token", "secret", "--update", "package.json", "--push-updates"}))
+	require.EqualError(t, err, "--push-updates requires --commit-updates")
+}
+
+func TestNewConfigIncludePrereleases(t *testing.T) {
+	defer chdirTemp(t)()
+	conf, err := NewConfig(newTestContext(t, []string{"--token", "secret", "--include-prereleases"}))
+	require.NoError(t, err)
+	require.True(t, conf.IncludePrereleases)
+
+	_, err = NewConfig(newTestContext(t, []string{"--token", "secret", "--include-prereleases", "--prerelease-channel", "beta"}))
+	require.EqualError(t, err, "--include-prereleases can not be used with --prerelease-channel")
+}
+
+func TestNewConfigGogs(t *testing.T) {
+	defer chdirTemp(t)()
+	conf, err := NewConfig(newTestContext(t, []string{"--token", "secret", "--gogs", "--gogs-base-url", "https://gogs.example.com"}))
+	require.NoError(t, err)
+	require.True(t, conf.Gogs)
+	require.Equal(t, "https://gogs.example.com", conf.GogsBaseURL)
+
+	_, err = NewConfig(newTestContext(t, []string{"--token", "secret", "--gogs", "--moving-tags", "major"}))
+	require.EqualError(t, err, "--moving-tags is not supported on Gogs")
+}
+
+func TestNewConfigChangelogSectionTitles(t *testing.T) {
+	defer chdirTemp(t)()
+	conf, err := NewConfig(newTestContext(t, []string{"--token", "secret", "--changelog-section-titles", "fix=Patches", "--changelog-section-titles", "feat=New Features"}))
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"fix": "Patches", "feat": "New Features"}, conf.ChangelogSectionTitles)
+	require.Equal(t, []string{"fix", "feat"}, conf.ChangelogSectionOrder)
+
+	_, err = NewConfig(newTestContext(t, []string{"--token", "secret", "--changelog-section-titles", "feat"}))
+	require.EqualError(t, err, "invalid changelog section title: feat (must be type=title)")
+}
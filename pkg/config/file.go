@@ -0,0 +1,68 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/urfave/cli/v2"
+	"gopkg.in/yaml.v2"
+)
+
+// DefaultConfigFile is loaded if it exists and no other file was set with --config
+const DefaultConfigFile = ".semantic-release.yaml"
+
+// applyConfigFile sets every flag of the config file that was neither set on the command line nor via an env var.
+// The keys of the file are the flag names, JSON files are supported as well as JSON is a subset of YAML.
+func applyConfigFile(c *cli.Context) error {
+	file := c.String("config")
+	if file == "" {
+		file = DefaultConfigFile
+	}
+	data, err := ioutil.ReadFile(file)
+	if os.IsNotExist(err) && !c.IsSet("config") {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("could not read config file: %w", err)
+	}
+
+	values := make(map[string]interface{})
+	if err := yaml.Unmarshal(data, &values); err != nil {
+		return fmt.Errorf("could not parse config file %s: %w", file, err)
+	}
+
+	known := make(map[string]bool, len(CliFlags))
+	for _, f := range CliFlags {
+		for _, name := range f.Names() {
+			known[name] = true
+		}
+	}
+
+	for key, value := range values {
+		if !known[key] || key == "config" {
+			return fmt.Errorf("config file %s: unknown key %q", file, key)
+		}
+		if c.IsSet(key) {
+			continue
+		}
+		list, ok := value.([]interface{})
+		if !ok {
+			list = []interface{}{value}
+		}
+		for _, v := range list {
+			// an empty value (e.g. "match:") keeps the default
+			if v == nil {
+				continue
+			}
+			switch v.(type) {
+			case []interface{}, map[interface{}]interface{}:
+				return fmt.Errorf("config file %s: invalid value for key %q", file, key)
+			}
+			if err := c.Set(key, fmt.Sprint(v)); err != nil {
+				return fmt.Errorf("config file %s: invalid value for key %q: %w", file, key, err)
+			}
+		}
+	}
+	return nil
+}
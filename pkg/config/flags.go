@@ -1,27 +1,48 @@
 package config
 
 import (
+	"time"
+
+	"github.com/go-semantic-release/semantic-release/pkg/update"
 	"github.com/urfave/cli/v2"
 )
 
 // CliFlags cli flags
 var CliFlags = []cli.Flag{
 	&cli.StringFlag{
-		Name:     "token",
-		Usage:    "github or gitlab token",
-		EnvVars:  []string{"GITHUB_TOKEN", "GH_TOKEN", "GITLAB_TOKEN"},
-		Required: true,
+		Name:    "token",
+		Usage:   "github, gitlab, bitbucket, gitea or gogs token, @- reads it from stdin",
+		EnvVars: []string{"GITHUB_TOKEN", "GH_TOKEN", "GITLAB_TOKEN", "BITBUCKET_TOKEN", "GITEA_TOKEN", "GOGS_TOKEN"},
+	},
+	&cli.StringFlag{
+		Name:  "token-file",
+		Usage: "read the token from the given file, - reads it from stdin",
+	},
+	&cli.StringFlag{
+		Name:  "read-token",
+		Usage: "token used to read the repository, commits and tags (default: --token)",
+	},
+	&cli.StringFlag{
+		Name:  "write-token",
+		Usage: "token used to create the tag and release (default: --token)",
 	},
 	&cli.StringFlag{
-		Name:     "slug",
-		Usage:    "slug of the repository",
-		EnvVars:  []string{"GITHUB_REPOSITORY", "TRAVIS_REPO_SLUG", "CI_PROJECT_PATH_SLUG"},
-		Required: true,
+		Name:    "slug",
+		Usage:   "slug of the repository",
+		EnvVars: []string{"GITHUB_REPOSITORY", "TRAVIS_REPO_SLUG", "CI_PROJECT_PATH_SLUG", "BITBUCKET_REPO_FULL_NAME"},
 	},
 	&cli.StringFlag{
 		Name:  "changelog",
 		Usage: "creates a changelog file",
 	},
+	&cli.BoolFlag{
+		Name:  "changelog-prepend",
+		Usage: "insert the changelog at the top of the existing changelog file instead of overwriting it",
+	},
+	&cli.StringFlag{
+		Name:  "changelog-header-marker",
+		Usage: "insert the changelog below the line containing the marker, e.g. <!-- releases -->",
+	},
 	&cli.BoolFlag{
 		Name:  "ghr",
 		Usage: "create a .ghr file with the parameters for ghr",
@@ -38,19 +59,96 @@ var CliFlags = []cli.Flag{
 		Name:  "vf",
 		Usage: "create a .version file",
 	},
+	&cli.BoolFlag{
+		Name:  "summary",
+		Usage: "show the changelog in the GitHub Actions step summary or in a collapsed section of the GitLab CI job log",
+	},
+	&cli.StringFlag{
+		Name:  "output-file",
+		Usage: "write the version, tag, prerelease flag and changelog path of the release to the file as key=value lines",
+	},
 	&cli.StringFlag{
+		Name:  "output-template",
+		Usage: "Go template of the --output-file content, e.g. {\"version\": {{json .Version}}}",
+	},
+	&cli.StringSliceFlag{
 		Name:  "update",
-		Usage: "updates the version of a certain file",
+		Usage: "updates the version of a certain file, file:regex replaces the first capture group of the regex in any file (e.g. 'Dockerfile:ARG VERSION=([\\d.]+)'), can be repeated",
+	},
+	&cli.StringFlag{
+		Name:  "update-type",
+		Usage: "updater used for the --update files instead of detecting it by the file name (e.g. yaml)",
+	},
+	&cli.StringFlag{
+		Name:  "update-yaml-key",
+		Usage: "dot separated path of the key set in yaml files (e.g. appVersion or image.tag)",
+		Value: "version",
+	},
+	&cli.StringFlag{
+		Name:  "update-toml-key",
+		Usage: "dot separated path of the key set in toml files including the table (e.g. tool.poetry.version), defaults to package.version in Cargo.toml, project.version or tool.poetry.version in pyproject.toml and version in other files",
+	},
+	&cli.BoolFlag{
+		Name:  "commit-updates",
+		Usage: "commit the --update files to the local repository if they changed",
+	},
+	&cli.StringFlag{
+		Name:  "commit-updates-message",
+		Usage: "Go template of the message of the update commit, gets .Version and .Tag",
+		Value: update.DefaultCommitMessage,
+	},
+	&cli.BoolFlag{
+		Name:  "push-updates",
+		Usage: "push the update commit to the current branch of origin",
 	},
 	&cli.StringFlag{
 		Name:    "ghe-host",
 		Usage:   "github enterprise host",
 		EnvVars: []string{"GITHUB_ENTERPRISE_HOST"},
 	},
+	&cli.StringFlag{
+		Name:  "ghe-api-url",
+		Usage: "github api url used as is (e.g. of a proxy or mirror of api.github.com), overrides the url derived from --ghe-host",
+	},
+	&cli.StringFlag{
+		Name:  "github-base-url",
+		Usage: "same as --ghe-api-url",
+	},
+	&cli.StringFlag{
+		Name:  "ghe-upload-url",
+		Usage: "github upload url, defaults to --ghe-api-url",
+	},
+	&cli.StringFlag{
+		Name:  "github-upload-url",
+		Usage: "same as --ghe-upload-url",
+	},
+	&cli.Int64Flag{
+		Name:    "github-app-id",
+		Usage:   "authenticate as installation of the GitHub App with the given id instead of with a token",
+		EnvVars: []string{"GITHUB_APP_ID"},
+	},
+	&cli.Int64Flag{
+		Name:    "github-app-installation-id",
+		Usage:   "installation id of the GitHub App",
+		EnvVars: []string{"GITHUB_APP_INSTALLATION_ID"},
+	},
+	&cli.StringFlag{
+		Name:    "github-app-private-key",
+		Usage:   "path of the PEM encoded private key of the GitHub App",
+		EnvVars: []string{"GITHUB_APP_PRIVATE_KEY_PATH"},
+	},
 	&cli.BoolFlag{
 		Name:  "prerelease",
 		Usage: "flags the release as a prerelease",
 	},
+	&cli.StringFlag{
+		Name:  "prerelease-channel",
+		Usage: "release prereleases on the given channel (e.g. alpha, beta, rc) like 1.3.0-beta.1",
+	},
+	&cli.BoolFlag{
+		Name:  "include-prereleases",
+		Usage: "calculate the new version from the latest release including the prereleases of all channels, by default only stable releases are considered",
+	},
 	&cli.BoolFlag{
 		Name:  "travis-com",
 		Usage: "force semantic-release to use the travis-ci.com API endpoint",
@@ -74,13 +172,319 @@ var CliFlags = []cli.Flag{
 		Usage:   "GitLab project unique id",
 		EnvVars: []string{"CI_PROJECT_ID"},
 	},
+	&cli.BoolFlag{
+		Name:  "gitlab-use-mr-commits",
+		Usage: "analyze the commits of squash merged merge requests instead of the squash commit",
+	},
+	&cli.BoolFlag{
+		Name:  "gitlab-allow-mr-pipelines",
+		Usage: "allow releases from merge request pipelines on GitLab CI",
+	},
+	&cli.BoolFlag{
+		Name:  "bitbucket",
+		Usage: "run semantic-release on Bitbucket Cloud, the token may be an OAuth token or username:app-password",
+	},
+	&cli.BoolFlag{
+		Name:  "gitea",
+		Usage: "run semantic-release on Gitea or Forgejo",
+	},
+	&cli.StringFlag{
+		Name:    "gitea-base-url",
+		Usage:   "Gitea instance url",
+		EnvVars: []string{"GITEA_BASE_URL"},
+	},
+	&cli.BoolFlag{
+		Name:  "gogs",
+		Usage: "run semantic-release on Gogs",
+	},
+	&cli.StringFlag{
+		Name:    "gogs-base-url",
+		Usage:   "Gogs instance url",
+		EnvVars: []string{"GOGS_BASE_URL"},
+	},
 	&cli.BoolFlag{
 		Name:  "allow-initial-development-versions",
 		Usage: "semantic-release will start your initial development release at 0.1.0",
 	},
+	&cli.StringFlag{
+		Name:  "initial-version",
+		Usage: "version of the first release if the repository has no release yet, e.g. 1.0.0",
+	},
 	&cli.BoolFlag{
 		Name:  "allow-no-changes",
 		Value: false,
 		Usage: "Exit with code 0 if no changes are found, useful if semantic-release is automatically run",
 	},
+	&cli.BoolFlag{
+		Name:  "major-on-deprecation",
+		Usage: "trigger a major release for deprecations",
+	},
+	&cli.StringFlag{
+		Name:  "release-timestamp",
+		Usage: "RFC3339 timestamp used for the changelog date and tag, defaults to now",
+	},
+	&cli.StringSliceFlag{
+		Name:  "exclude-committers",
+		Usage: "ignore commits committed by the given names (e.g. bots)",
+	},
+	&cli.BoolFlag{
+		Name:  "ignore-merge-commits",
+		Usage: "ignore merge commits (commits with more than one parent), disable with --ignore-merge-commits=false",
+		Value: true,
+	},
+	&cli.StringFlag{
+		Name:  "credit-by",
+		Usage: "credit changelog entries by author or committer",
+	},
+	&cli.BoolFlag{
+		Name:  "release-title-from-changelog",
+		Usage: "use the first line of the changelog as release title",
+	},
+	&cli.StringSliceFlag{
+		Name:  "moving-tags",
+		Usage: "move the major and/or minor version tags (e.g. v1, v1.2) to the new release, skipped for prereleases",
+	},
+	&cli.StringSliceFlag{
+		Name:  "no-release-if-only-scopes",
+		Usage: "do not release if all releasing commits belong to the given scopes or types of a scope (e.g. deps, docs(api))",
+	},
+	&cli.BoolFlag{
+		Name:  "coerce-versions",
+		Usage: "consider loose tags (e.g. 1.2, v1) as releases by padding the missing version components",
+	},
+	&cli.BoolFlag{
+		Name:  "print-config",
+		Usage: "print the effective configuration (token redacted) and exit",
+	},
+	&cli.BoolFlag{
+		Name:  "json",
+		Usage: "print the computed release as JSON to stdout",
+	},
+	&cli.StringFlag{
+		Name:  "log-level",
+		Usage: "minimum level of the logs written to stderr (debug, info, warn or error)",
+		Value: "info",
+	},
+	&cli.StringFlag{
+		Name:  "log-format",
+		Usage: "format of the logs written to stderr (text or json)",
+		Value: "text",
+	},
+	&cli.StringFlag{
+		Name:  "config",
+		Usage: "load the configuration from the given YAML or JSON file, command line flags and env vars take precedence (default: .semantic-release.yaml)",
+	},
+	&cli.StringFlag{
+		Name:  "tag-format",
+		Usage: "Go template of the release tags with .Version and .Pkg available (default: v{{.Version}}, {{if .Pkg}}{{.Pkg}}-{{end}}v{{.Version}} on GitHub)",
+	},
+	&cli.StringFlag{
+		Name:  "commit-pattern",
+		Usage: "regex matching the first line of commits with the groups type, scope, breaking and subject (default: conventional commits)",
+	},
+	&cli.StringFlag{
+		Name:    "pkg-name",
+		Usage:   "package name available as .Pkg in the tag format",
+		EnvVars: []string{"pkg_name"},
+	},
+	&cli.StringFlag{
+		Name:    "pkg-path",
+		Usage:   "directory of the package in a monorepo, only commits changing files in it are analyzed",
+		EnvVars: []string{"pkg_path"},
+	},
+	&cli.BoolFlag{
+		Name:  "no-release-on-scope-mismatch",
+		Usage: "do not release the package of --pkg-path if no commit since its latest release changed it, not even the first or a 0.x release",
+	},
+	&cli.StringFlag{
+		Name:  "build-metadata",
+		Usage: "Go template of the build metadata added to the version in the changelog, .version file and JSON output (e.g. sha.{{.ShortSHA}}), the tag uses the version without metadata",
+	},
+	&cli.StringSliceFlag{
+		Name:  "asset",
+		Usage: "attach a name:url link to the release, only supported on GitLab",
+	},
+	&cli.BoolFlag{
+		Name:  "changelog-group-by-scope",
+		Usage: "group the changelog entries of each section by their scope",
+	},
+	&cli.BoolFlag{
+		Name:  "changelog-issue-links",
+		Usage: "turn issue references (e.g. #123) in the changelog into references of the repository (e.g. owner/repo#123)",
+	},
+	&cli.BoolFlag{
+		Name:  "changelog-contributors",
+		Usage: "add a contributors section with the authors and co-authors of the commits to the changelog",
+	},
+	&cli.BoolFlag{
+		Name:  "changelog-include-body",
+		Usage: "list the body of the commits below their entry in the changelog, without trailers like Signed-off-by",
+	},
+	&cli.StringFlag{
+		Name:  "changelog-since",
+		Usage: "start of the changelog of prereleases, the previous release or the last-stable release",
+		Value: "previous",
+	},
+	&cli.StringSliceFlag{
+		Name:  "changelog-exclude-types",
+		Usage: "commit types left out of the changelog (e.g. chore), can be repeated",
+	},
+	&cli.StringSliceFlag{
+		Name:  "changelog-include-types",
+		Usage: "only list the commits of the given types in the changelog, takes precedence over changelog-exclude-types",
+	},
+	&cli.StringSliceFlag{
+		Name:  "changelog-section-titles",
+		Usage: "type=title headings of the changelog sections in the given order, e.g. feat=New Features, can be repeated",
+	},
+	&cli.StringFlag{
+		Name:  "changelog-date-format",
+		Usage: "Go time layout of the release date in the changelog, e.g. 02.01.2006 or January 2, 2006",
+		Value: "2006-01-02",
+	},
+	&cli.StringFlag{
+		Name:  "changelog-timezone",
+		Usage: "IANA timezone of the release date in the changelog, e.g. Europe/Berlin",
+		Value: "UTC",
+	},
+	&cli.StringFlag{
+		Name:  "issue-url-template",
+		Usage: "Go template of the url of issue references of external trackers (e.g. JIRA-7) with .Issue available, e.g. https://jira.example.com/browse/{{.Issue}}",
+	},
+	&cli.StringFlag{
+		Name:  "changelog-template",
+		Usage: "path of a Go template used to render the changelog instead of the default format",
+	},
+	&cli.StringFlag{
+		Name:  "changelog-command",
+		Usage: "shell command rendering the changelog, it gets the version and the commits as JSON on stdin and prints the changelog",
+	},
+	&cli.StringSliceFlag{
+		Name:  "bump-rules",
+		Usage: "type=level rules overriding which commit types bump which level (major, minor, patch or none), e.g. perf=patch",
+	},
+	&cli.StringFlag{
+		Name:  "force-bump",
+		Usage: "bump at least the given level (major, minor or patch), also without releasable commits",
+	},
+	&cli.BoolFlag{
+		Name:  "force-bump-strict",
+		Usage: "bump exactly the level of --force-bump instead of the higher of the forced and the computed level",
+	},
+	&cli.StringFlag{
+		Name:  "release-discussion-category",
+		Usage: "create a discussion of the release in the given category, only supported on GitHub",
+	},
+	&cli.StringFlag{
+		Name:  "mark-latest",
+		Usage: "mark the GitHub release as latest (true, false or auto), auto only marks releases newer than all existing releases",
+	},
+	&cli.BoolFlag{
+		Name:  "use-github-generated-notes",
+		Usage: "use the release notes generated by GitHub from the pull requests instead of the changelog, only supported on GitHub",
+	},
+	&cli.BoolFlag{
+		Name:  "github-generated-notes-changelog",
+		Usage: "keep the changelog above the release notes generated by GitHub",
+	},
+	&cli.StringFlag{
+		Name:  "current-branch",
+		Usage: "override the branch detected from the CI environment",
+	},
+	&cli.StringFlag{
+		Name:  "default-branch",
+		Usage: "override the default branch of the repository, releases are only created on it",
+	},
+	&cli.StringFlag{
+		Name:  "current-sha",
+		Usage: "override the commit sha detected from the CI environment, the newest commit is released if neither is set",
+	},
+	&cli.IntFlag{
+		Name:  "api-retries",
+		Usage: "retry failed API requests (network errors, 5xx and rate limits) the given number of times",
+		Value: 3,
+	},
+	&cli.DurationFlag{
+		Name:  "api-retry-wait",
+		Usage: "wait before the first retry, doubled for every further retry unless the API sends a Retry-After header",
+		Value: time.Second,
+	},
+	&cli.DurationFlag{
+		Name:  "since-duration",
+		Usage: "only list the commits of the given duration (e.g. 720h) on GitHub and GitLab, fails if the previous release is older",
+	},
+	&cli.BoolFlag{
+		Name:  "wait-for-rate-limit",
+		Usage: "wait until the GitHub rate limit resets instead of failing read requests",
+	},
+	&cli.StringFlag{
+		Name:  "cache-dir",
+		Usage: "directory caching the GitHub tag listing between runs, unchanged tags are revalidated with conditional requests",
+	},
+	&cli.BoolFlag{
+		Name:  "no-cache",
+		Usage: "disable the cache of --cache-dir",
+	},
+	&cli.StringFlag{
+		Name:  "notify-url",
+		Usage: "url a JSON payload with the version, tag, changelog and provider is posted to after the release",
+	},
+	&cli.StringFlag{
+		Name:  "notify-template",
+		Usage: "Go template of the notification body, e.g. {\"text\": {{json .Changelog}}} for Slack",
+	},
+	&cli.StringFlag{
+		Name:    "notify-secret",
+		Usage:   "secret of the HMAC-SHA256 signature of the notification sent in the X-Semantic-Release-Signature header",
+		EnvVars: []string{"NOTIFY_SECRET"},
+	},
+	&cli.BoolFlag{
+		Name:  "require-signed-commits",
+		Usage: "fail if a commit since the latest release has no signature verified by GitHub",
+	},
+	&cli.BoolFlag{
+		Name:  "require-signed-releasing-commits",
+		Usage: "like --require-signed-commits but only for the commits causing the version bump",
+	},
+	&cli.BoolFlag{
+		Name:  "sign-tags",
+		Usage: "create GPG signed annotated tags, only supported by GitHub",
+	},
+	&cli.StringFlag{
+		Name:    "signing-key",
+		Usage:   "id of the GPG key used by --sign-tags, the key must be available to the local gpg",
+		EnvVars: []string{"SIGNING_KEY"},
+	},
+	&cli.BoolFlag{
+		Name:  "local",
+		Usage: "read the commits and tags from the git repository in the working directory instead of the API, requires the full history",
+	},
+	&cli.BoolFlag{
+		Name:  "unshallow",
+		Usage: "fetch the full history and tags if the local repository is a shallow clone (with --local)",
+	},
+	&cli.BoolFlag{
+		Name:  "parse-squash-body",
+		Usage: "also consider the conventional commits listed in the body of squash merged commits for the version bump",
+	},
+	&cli.StringFlag{
+		Name:  "maintenance-branch-pattern",
+		Usage: "only allow maintained version releases on branches matching the pattern, N matches a number and * anything (e.g. N.N.x)",
+	},
+	&cli.BoolFlag{
+		Name:  "promote",
+		Usage: "release the latest prerelease of the --prerelease-channel as stable version on the same commit instead of calculating the version",
+	},
+	&cli.StringSliceFlag{
+		Name:  "upload",
+		Usage: "glob of files attached to the GitHub release, can be repeated",
+	},
+	&cli.BoolFlag{
+		Name:  "upload-checksums",
+		Usage: "attach the SHA256SUMS of the uploaded files to the release",
+	},
+	&cli.BoolFlag{
+		Name:  "verify",
+		Usage: "check the configuration, the token and the CI environment without releasing",
+	},
 }
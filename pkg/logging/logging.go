@@ -0,0 +1,106 @@
+package logging
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Level is the severity of a log line, lines below the level of the logger are discarded
+type Level int
+
+const (
+	Debug Level = iota
+	Info
+	Warn
+	Error
+)
+
+var levelNames = []string{"debug", "info", "warn", "error"}
+
+func (l Level) String() string {
+	if l < Debug || l > Error {
+		return fmt.Sprintf("level(%d)", int(l))
+	}
+	return levelNames[l]
+}
+
+// ParseLevel returns the level of the name (debug, info, warn or error), an empty name is the info level
+func ParseLevel(name string) (Level, error) {
+	if name == "" {
+		return Info, nil
+	}
+	for i, n := range levelNames {
+		if strings.EqualFold(name, n) {
+			return Level(i), nil
+		}
+	}
+	return Info, fmt.Errorf("invalid log level: %s (supported: %s)", name, strings.Join(levelNames, ", "))
+}
+
+// Logger writes leveled lines in the text format "[prefix]: message" or as JSON objects, one per line
+type Logger struct {
+	mu     sync.Mutex
+	out    io.Writer
+	prefix string
+	level  Level
+	json   bool
+	now    func() time.Time
+}
+
+// New creates a logger writing the lines of the level and above to out, format is text (the default) or json
+func New(out io.Writer, prefix string, level Level, format string) *Logger {
+	return &Logger{out: out, prefix: prefix, level: level, json: format == "json", now: time.Now}
+}
+
+func (l *Logger) log(level Level, msg string) {
+	if level < l.level {
+		return
+	}
+	msg = strings.TrimRight(msg, "\n")
+	var line []byte
+	if l.json {
+		line, _ = json.Marshal(struct {
+			Time    string `json:"time"`
+			Level   string `json:"level"`
+			Message string `json:"msg"`
+		}{l.now().UTC().Format(time.RFC3339), level.String(), msg})
+	} else if level == Info {
+		line = []byte(fmt.Sprintf("[%s]: %s", l.prefix, msg))
+	} else {
+		line = []byte(fmt.Sprintf("[%s]: %s: %s", l.prefix, level, msg))
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.out.Write(append(line, '\n')) //nolint:errcheck
+}
+
+func (l *Logger) Debugf(format string, v ...interface{}) { l.log(Debug, fmt.Sprintf(format, v...)) }
+func (l *Logger) Infof(format string, v ...interface{})  { l.log(Info, fmt.Sprintf(format, v...)) }
+func (l *Logger) Warnf(format string, v ...interface{})  { l.log(Warn, fmt.Sprintf(format, v...)) }
+func (l *Logger) Errorf(format string, v ...interface{}) { l.log(Error, fmt.Sprintf(format, v...)) }
+
+// Info logs the operands formatted like fmt.Sprint
+func (l *Logger) Info(v ...interface{}) { l.log(Info, fmt.Sprint(v...)) }
+
+// Error logs the operands formatted like fmt.Sprint
+func (l *Logger) Error(v ...interface{}) { l.log(Error, fmt.Sprint(v...)) }
+
+// StdLogger returns a *log.Logger writing every line at the level for packages expecting the standard logger
+func (l *Logger) StdLogger(level Level) *log.Logger {
+	return log.New(levelWriter{l, level}, "", 0)
+}
+
+type levelWriter struct {
+	logger *Logger
+	level  Level
+}
+
+func (w levelWriter) Write(p []byte) (int, error) {
+	w.logger.log(w.level, string(p))
+	return len(p), nil
+}
@@ -0,0 +1,63 @@
+package logging
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLevel(t *testing.T) {
+	for name, expected := range map[string]Level{"debug": Debug, "INFO": Info, "": Info, "warn": Warn, "error": Error} {
+		level, err := ParseLevel(name)
+		require.NoError(t, err)
+		require.Equal(t, expected, level)
+	}
+	_, err := ParseLevel("verbose")
+	require.EqualError(t, err, "invalid log level: verbose (supported: debug, info, warn, error)")
+}
+
+func TestLoggerLevel(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&buf, "semantic-release", Info, "text")
+	logger.Debugf("found %d commits", 3)
+	require.Empty(t, buf.String(), "debug lines must be suppressed at info level")
+
+	logger.Infof("new version: %s", "1.2.0")
+	logger.Warnf("assets are not supported on %s", "Gitea")
+	logger.Error(errors.New("release failed"))
+	require.Equal(t, "[semantic-release]: new version: 1.2.0\n"+
+		"[semantic-release]: warn: assets are not supported on Gitea\n"+
+		"[semantic-release]: error: release failed\n", buf.String())
+
+	buf.Reset()
+	logger = New(&buf, "semantic-release", Debug, "text")
+	logger.Debugf("found %d commits", 3)
+	require.Equal(t, "[semantic-release]: debug: found 3 commits\n", buf.String())
+
+	buf.Reset()
+	logger = New(&buf, "semantic-release", Error, "text")
+	logger.Info("getting commits...")
+	logger.Warnf("ignored")
+	require.Empty(t, buf.String())
+}
+
+func TestLoggerJSON(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&buf, "semantic-release", Info, "json")
+	logger.now = func() time.Time { return time.Date(2020, 4, 19, 12, 0, 0, 0, time.UTC) }
+	logger.Debugf("suppressed")
+	logger.Infof("new version: %s\n", "1.2.0")
+	require.Equal(t, `{"time":"2020-04-19T12:00:00Z","level":"info","msg":"new version: 1.2.0"}`+"\n", buf.String())
+}
+
+func TestStdLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&buf, "semantic-release", Warn, "text")
+	logger.StdLogger(Info).Printf("tag %s already exists", "v1.0.0")
+	require.Empty(t, buf.String())
+	logger.StdLogger(Warn).Printf("tag %s already exists", "v1.0.0")
+	require.Equal(t, "[semantic-release]: warn: tag v1.0.0 already exists\n", buf.String())
+}
@@ -0,0 +1,98 @@
+package notify
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"text/template"
+	"time"
+)
+
+// SignatureHeader contains the hex encoded HMAC-SHA256 of the body prefixed with sha256= if a secret is set
+const SignatureHeader = "X-Semantic-Release-Signature"
+
+// Payload is sent as JSON to the notification URL and is available in the notification template
+type Payload struct {
+	Version    string `json:"version"`
+	Tag        string `json:"tag"`
+	Changelog  string `json:"changelog"`
+	Provider   string `json:"provider"`
+	Repository string `json:"repository"`
+}
+
+var templateFuncs = template.FuncMap{
+	// json encodes a value for templates of JSON bodies, e.g. {"text": {{json .Changelog}}}
+	"json": func(v interface{}) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+}
+
+// ParseTemplate parses a template rendering the request body from the Payload
+func ParseTemplate(text string) (*template.Template, error) {
+	return template.New("notify").Funcs(templateFuncs).Option("missingkey=error").Parse(text)
+}
+
+// Notifier posts the payload of a release to a webhook
+type Notifier struct {
+	URL string
+	// Template renders the body, nil sends the Payload as JSON
+	Template *template.Template
+	// Secret signs the body in the SignatureHeader if set
+	Secret string
+	Client *http.Client
+}
+
+func (n *Notifier) body(payload *Payload) ([]byte, error) {
+	if n.Template == nil {
+		return json.Marshal(payload)
+	}
+	var buf bytes.Buffer
+	if err := n.Template.Execute(&buf, payload); err != nil {
+		return nil, fmt.Errorf("could not render notify template: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// Sign returns the value of the SignatureHeader of the body
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body) //nolint:errcheck
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Notify posts the payload, responses other than 2xx are returned as error
+func (n *Notifier) Notify(payload *Payload) error {
+	body, err := n.body(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, n.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if n.Secret != "" {
+		req.Header.Set(SignatureHeader, Sign(n.Secret, body))
+	}
+	client := n.Client
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("notification failed with %s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+	return nil
+}
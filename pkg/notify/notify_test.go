@@ -0,0 +1,88 @@
+package notify
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var testPayload = &Payload{
+	Version:    "1.2.0",
+	Tag:        "v1.2.0",
+	Changelog:  "#### Feature\n\n* \"search\" (abcd)\n",
+	Provider:   "GitHub",
+	Repository: "owner/repo",
+}
+
+type receivedRequest struct {
+	header http.Header
+	body   []byte
+}
+
+func newReceiver(status int, received *receivedRequest) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received.header = r.Header
+		received.body, _ = ioutil.ReadAll(r.Body)
+		w.WriteHeader(status)
+		w.Write([]byte("bad payload\n")) //nolint:errcheck
+	}))
+}
+
+func TestNotify(t *testing.T) {
+	var received receivedRequest
+	ts := newReceiver(http.StatusNoContent, &received)
+	defer ts.Close()
+
+	require.NoError(t, (&Notifier{URL: ts.URL}).Notify(testPayload))
+	require.Equal(t, "application/json", received.header.Get("Content-Type"))
+	require.Empty(t, received.header.Get(SignatureHeader))
+	var payload Payload
+	require.NoError(t, json.Unmarshal(received.body, &payload))
+	require.Equal(t, *testPayload, payload)
+}
+
+func TestNotifyTemplate(t *testing.T) {
+	var received receivedRequest
+	ts := newReceiver(http.StatusOK, &received)
+	defer ts.Close()
+
+	tmpl, err := ParseTemplate(`{"text": {{json (printf "%s released %s\n%s" .Repository .Tag .Changelog)}}}`)
+	require.NoError(t, err)
+	require.NoError(t, (&Notifier{URL: ts.URL, Template: tmpl}).Notify(testPayload))
+	var body map[string]string
+	require.NoError(t, json.Unmarshal(received.body, &body))
+	require.Equal(t, "owner/repo released v1.2.0\n"+testPayload.Changelog, body["text"])
+
+	_, err = ParseTemplate("{{json .Tag")
+	require.Error(t, err)
+	tmpl, err = ParseTemplate("{{.Missing}}")
+	require.NoError(t, err)
+	require.Error(t, (&Notifier{URL: ts.URL, Template: tmpl}).Notify(testPayload))
+}
+
+func TestNotifySignature(t *testing.T) {
+	var received receivedRequest
+	ts := newReceiver(http.StatusOK, &received)
+	defer ts.Close()
+
+	require.NoError(t, (&Notifier{URL: ts.URL, Secret: "secret"}).Notify(testPayload))
+	require.Equal(t, Sign("secret", received.body), received.header.Get(SignatureHeader))
+	require.Regexp(t, "^sha256=[0-9a-f]{64}$", received.header.Get(SignatureHeader))
+	require.NotEqual(t, Sign("other", received.body), received.header.Get(SignatureHeader))
+}
+
+func TestNotifyFailure(t *testing.T) {
+	var received receivedRequest
+	ts := newReceiver(http.StatusBadRequest, &received)
+	defer ts.Close()
+
+	err := (&Notifier{URL: ts.URL}).Notify(testPayload)
+	require.EqualError(t, err, "notification failed with 400 Bad Request: bad payload")
+
+	err = (&Notifier{URL: "http://127.0.0.1:0"}).Notify(testPayload)
+	require.Error(t, err)
+}
@@ -0,0 +1,56 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"text/template"
+)
+
+// Data are the values of the release available in the output templates
+type Data struct {
+	Version         string
+	PreviousVersion string
+	Tag             string
+	Bump            string
+	Prerelease      bool
+	// Changelog is the rendered changelog, ChangelogFile the path it was written to if any
+	Changelog     string
+	ChangelogFile string
+	Owner         string
+	Repo          string
+	Provider      string
+	SHA           string
+}
+
+var templateFuncs = template.FuncMap{
+	// json encodes a value for templates of JSON files, e.g. {"version": {{json .Version}}}
+	"json": func(v interface{}) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+}
+
+// ParseTemplate parses a template rendering an output file from the Data
+func ParseTemplate(text string) (*template.Template, error) {
+	return template.New("output").Funcs(templateFuncs).Option("missingkey=error").Parse(text)
+}
+
+// DefaultTemplate renders key=value lines which can be sourced by a shell or appended to $GITHUB_OUTPUT
+var DefaultTemplate = template.Must(ParseTemplate("version={{.Version}}\ntag={{.Tag}}\nprerelease={{.Prerelease}}\nchangelog_file={{.ChangelogFile}}\n"))
+
+// GhrTemplate renders the parameters for ghr of the .ghr file
+var GhrTemplate = template.Must(ParseTemplate("-u {{.Owner}} -r {{.Repo}} {{.Tag}}"))
+
+// VersionTemplate renders the version of the .version file
+var VersionTemplate = template.Must(ParseTemplate("{{.Version}}"))
+
+// WriteFile renders the template to the file, the file is not created if the template fails
+func WriteFile(file string, tmpl *template.Template, data *Data) error {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return fmt.Errorf("could not render %s: %w", file, err)
+	}
+	return ioutil.WriteFile(file, buf.Bytes(), 0644)
+}
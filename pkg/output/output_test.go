@@ -0,0 +1,71 @@
+package output
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var testData = &Data{
+	Version:       "1.2.0-beta.1",
+	Tag:           "v1.2.0-beta.1",
+	Prerelease:    true,
+	ChangelogFile: "CHANGELOG.md",
+	Owner:         "owner",
+	Repo:          "repo",
+}
+
+func renderFile(t *testing.T, tmpl string) (string, error) {
+	dir, err := ioutil.TempDir("", "semrel-output")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "release.env")
+
+	parsed := DefaultTemplate
+	if tmpl != "" {
+		if parsed, err = ParseTemplate(tmpl); err != nil {
+			return "", err
+		}
+	}
+	if err := WriteFile(file, parsed, testData); err != nil {
+		_, statErr := os.Stat(file)
+		require.True(t, os.IsNotExist(statErr))
+		return "", err
+	}
+	content, err := ioutil.ReadFile(file)
+	require.NoError(t, err)
+	return string(content), nil
+}
+
+func TestWriteFile(t *testing.T) {
+	content, err := renderFile(t, "")
+	require.NoError(t, err)
+	require.Equal(t, "version=1.2.0-beta.1\ntag=v1.2.0-beta.1\nprerelease=true\nchangelog_file=CHANGELOG.md\n", content)
+
+	content, err = renderFile(t, `{"version": {{json .Version}}, "prerelease": {{.Prerelease}}}`)
+	require.NoError(t, err)
+	require.Equal(t, `{"version": "1.2.0-beta.1", "prerelease": true}`, content)
+
+	_, err = renderFile(t, "{{.Unknown}}")
+	require.Error(t, err)
+	_, err = renderFile(t, "{{.Version")
+	require.Error(t, err)
+}
+
+func TestCompatibilityTemplates(t *testing.T) {
+	dir, err := ioutil.TempDir("", "semrel-output")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	require.NoError(t, WriteFile(filepath.Join(dir, ".ghr"), GhrTemplate, testData))
+	require.NoError(t, WriteFile(filepath.Join(dir, ".version"), VersionTemplate, testData))
+	content, err := ioutil.ReadFile(filepath.Join(dir, ".ghr"))
+	require.NoError(t, err)
+	require.Equal(t, "-u owner -r repo v1.2.0-beta.1", string(content))
+	content, err = ioutil.ReadFile(filepath.Join(dir, ".version"))
+	require.NoError(t, err)
+	require.Equal(t, "1.2.0-beta.1", string(content))
+}
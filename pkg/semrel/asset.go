@@ -0,0 +1,39 @@
+package semrel
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Asset is a link attached to a release, e.g. to a binary or package
+type Asset struct {
+	Name string
+	URL  string
+}
+
+// ParseAsset parses a name:url pair, the url must be an absolute http(s) url
+func ParseAsset(s string) (*Asset, error) {
+	split := strings.SplitN(s, ":", 2)
+	if len(split) != 2 || split[0] == "" {
+		return nil, fmt.Errorf("invalid asset %q (must be name:url)", s)
+	}
+	u, err := url.Parse(split[1])
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid asset %q: %s is not an absolute http(s) url", s, split[1])
+	}
+	return &Asset{Name: split[0], URL: split[1]}, nil
+}
+
+// ParseAssets parses all name:url pairs and fails on the first invalid one
+func ParseAssets(assets []string) ([]*Asset, error) {
+	ret := make([]*Asset, 0, len(assets))
+	for _, s := range assets {
+		asset, err := ParseAsset(s)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, asset)
+	}
+	return ret, nil
+}
@@ -0,0 +1,27 @@
+package semrel
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseAsset(t *testing.T) {
+	asset, err := ParseAsset("linux-amd64:https://example.com/releases/app_linux_amd64.tar.gz")
+	require.NoError(t, err)
+	require.Equal(t, &Asset{Name: "linux-amd64", URL: "https://example.com/releases/app_linux_amd64.tar.gz"}, asset)
+
+	for _, s := range []string{"", "binary", ":https://example.com", "binary:example.com/app", "binary:ftp://example.com/app", "binary:https://"} {
+		_, err := ParseAsset(s)
+		require.Error(t, err, s)
+	}
+}
+
+func TestParseAssets(t *testing.T) {
+	assets, err := ParseAssets([]string{"a:https://example.com/a", "b:http://example.com/b"})
+	require.NoError(t, err)
+	require.Len(t, assets, 2)
+
+	_, err = ParseAssets([]string{"a:https://example.com/a", "b"})
+	require.EqualError(t, err, `invalid asset "b" (must be name:url)`)
+}
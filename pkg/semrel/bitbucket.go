@@ -0,0 +1,238 @@
+package semrel
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const bitbucketAPIURL = "https://api.bitbucket.org/2.0"
+
+// errStopListing can be returned by the list callback to stop the pagination early
+var errStopListing = errors.New("stop listing")
+
+type BitbucketRepository struct {
+	owner   string
+	repo    string
+	token   string
+	baseURL string
+	Ctx     context.Context
+	client  *http.Client
+}
+
+type bitbucketCommit struct {
+	Hash    string `json:"hash"`
+	Message string `json:"message"`
+	Author  struct {
+		Raw string `json:"raw"`
+	} `json:"author"`
+	Parents []struct {
+		Hash string `json:"hash"`
+	} `json:"parents"`
+}
+
+type bitbucketTag struct {
+	Name   string `json:"name"`
+	Target struct {
+		Hash string `json:"hash"`
+	} `json:"target"`
+}
+
+type bitbucketPage struct {
+	Next   string          `json:"next"`
+	Values json.RawMessage `json:"values"`
+}
+
+// NewBitbucketRepository creates a Bitbucket Cloud repository, the token is either an OAuth token or a "username:app-password" pair
+func NewBitbucketRepository(ctx context.Context, slug, token string) (*BitbucketRepository, error) {
+	owner, repoName, err := ParseSlug(slug)
+	if err != nil {
+		return nil, err
+	}
+	repo := new(BitbucketRepository)
+	repo.owner = owner
+	repo.repo = repoName
+	repo.token = token
+	repo.baseURL = bitbucketAPIURL
+	repo.Ctx = ctx
+	repo.client = httpClient(ctx)
+	return repo, nil
+}
+
+func (repo *BitbucketRepository) repoURL(path string) string {
+	return fmt.Sprintf("%s/repositories/%s/%s%s", repo.baseURL, url.PathEscape(repo.owner), url.PathEscape(repo.repo), path)
+}
+
+func (repo *BitbucketRepository) do(method, u string, body, v interface{}) (*http.Response, error) {
+	return doJSONRequest(repo.Ctx, repo.client, method, u, body, v, func(req *http.Request) {
+		if split := strings.SplitN(repo.token, ":", 2); len(split) == 2 {
+			req.SetBasicAuth(split[0], split[1])
+		} else {
+			req.Header.Set("Authorization", "Bearer "+repo.token)
+		}
+	})
+}
+
+// list follows the pagination of the given url and calls fn for every page
+func (repo *BitbucketRepository) list(u string, fn func(values json.RawMessage) error) (*http.Response, error) {
+	for u != "" {
+		var page bitbucketPage
+		resp, err := repo.do("GET", u, nil, &page)
+		if err != nil {
+			return resp, err
+		}
+		if err := fn(page.Values); err == errStopListing {
+			return resp, nil
+		} else if err != nil {
+			return resp, err
+		}
+		u = page.Next
+	}
+	return nil, nil
+}
+
+func (repo *BitbucketRepository) GetInfo() (string, bool, error) {
+	var info struct {
+		IsPrivate  bool `json:"is_private"`
+		MainBranch struct {
+			Name string `json:"name"`
+		} `json:"mainbranch"`
+	}
+	resp, err := repo.do("GET", repo.repoURL(""), nil, &info)
+	if resp != nil && resp.StatusCode == 404 {
+		return "", false, fmt.Errorf("%w: %v", ErrRepoNotFound, err)
+	}
+	if err != nil {
+		return "", false, err
+	}
+	return info.MainBranch.Name, info.IsPrivate, nil
+}
+
+// GetCommits returns all commits reachable from sha, newest first
+func (repo *BitbucketRepository) GetCommits(sha string) ([]*Commit, error) {
+	return repo.GetCommitsUntil(sha, "")
+}
+
+// GetCommitsUntil returns the commits reachable from sha, newest first, stopping after the commit releaseSHA
+func (repo *BitbucketRepository) GetCommitsUntil(sha, releaseSHA string) ([]*Commit, error) {
+	path := "/commits"
+	if sha != "" {
+		path += "/" + url.PathEscape(sha)
+	}
+	allCommits := make([]*Commit, 0)
+	_, err := repo.list(repo.repoURL(path)+"?pagelen=100", func(values json.RawMessage) error {
+		var commits []*bitbucketCommit
+		if err := json.Unmarshal(values, &commits); err != nil {
+			return err
+		}
+		for _, commit := range commits {
+			allCommits = append(allCommits, parseBitbucketCommit(commit))
+			if releaseSHA != "" && commit.Hash == releaseSHA {
+				return errStopListing
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return allCommits, nil
+}
+
+func (repo *BitbucketRepository) GetLatestRelease(latest *GetLatestReleaseConfig) (*Release, error) {
+	allReleases := make(Releases, 0)
+	tagCount := 0
+	resp, err := repo.list(repo.repoURL("/refs/tags")+"?pagelen=100", func(values json.RawMessage) error {
+		var tags []*bitbucketTag
+		if err := json.Unmarshal(values, &tags); err != nil {
+			return err
+		}
+		tagCount += len(tags)
+		for _, tag := range tags {
+			if latest.Match != nil && !latest.Match.MatchString(tag.Name) {
+				continue
+			}
+			tagVersion, ok := latest.TagFormat.Version(tag.Name)
+			if !ok {
+				continue
+			}
+			version, err := parseTagVersion(tagVersion, latest.CoerceVersions)
+			if err != nil {
+				continue
+			}
+			allReleases = append(allReleases, &Release{tag.Target.Hash, version})
+		}
+		return nil
+	})
+	if resp != nil && resp.StatusCode == 404 {
+		return nil, ErrNoReleases
+	}
+	if err != nil {
+		return nil, err
+	}
+	if tagCount == 0 {
+		return nil, ErrNoReleases
+	}
+	return latest.selectRelease(allReleases)
+}
+
+// CreateRelease creates an annotated tag containing the changelog as Bitbucket has no notion of releases
+func (repo *BitbucketRepository) CreateRelease(release *CreateReleaseConfig) error {
+	tag := release.TagFormat.Format(release.NewVersion.String())
+	if err := repo.createTag(tag, release.SHA, release.Changelog); err != nil {
+		return err
+	}
+	for _, v := range GetMovingTagVersions(release.NewVersion, release.MovingTags) {
+		if err := repo.moveTag(release.TagFormat.Format(v), release.SHA); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (repo *BitbucketRepository) createTag(tag, sha, message string) error {
+	body := map[string]interface{}{
+		"name":   tag,
+		"target": map[string]string{"hash": sha},
+	}
+	if message != "" {
+		body["message"] = message
+	}
+	_, err := repo.do("POST", repo.repoURL("/refs/tags"), body, nil)
+	return err
+}
+
+// moveTag recreates the tag on the given sha as Bitbucket tags can not be updated
+func (repo *BitbucketRepository) moveTag(tag, sha string) error {
+	resp, err := repo.do("DELETE", repo.repoURL("/refs/tags/"+url.PathEscape(tag)), nil, nil)
+	if err != nil && (resp == nil || resp.StatusCode != 404) {
+		return err
+	}
+	return repo.createTag(tag, sha, "")
+}
+
+func parseBitbucketCommit(commit *bitbucketCommit) *Commit {
+	c := ParseCommit(commit.Hash, commit.Message)
+	// the raw author has the format "Name <email>"
+	c.Author = strings.TrimSpace(strings.SplitN(commit.Author.Raw, "<", 2)[0])
+	for _, parent := range commit.Parents {
+		c.Parents = append(c.Parents, parent.Hash)
+	}
+	return c
+}
+
+func (repo *BitbucketRepository) Owner() string {
+	return repo.owner
+}
+
+func (repo *BitbucketRepository) Repo() string {
+	return repo.repo
+}
+
+func (repo *BitbucketRepository) Provider() string {
+	return "Bitbucket"
+}
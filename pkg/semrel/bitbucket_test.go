@@ -0,0 +1,179 @@
+package semrel
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+
+	"github.com/Masterminds/semver"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewBitbucketRepository(t *testing.T) {
+	require := require.New(t)
+
+	repo, err := NewBitbucketRepository(context.TODO(), "", "")
+	require.Nil(repo)
+	require.EqualError(err, `invalid slug "": must be owner/repo`)
+
+	repo, err = NewBitbucketRepository(context.TODO(), "owner/test-repo", "token")
+	require.NotNil(repo)
+	require.NoError(err)
+	require.Equal("owner", repo.Owner())
+	require.Equal("test-repo", repo.Repo())
+	require.Equal("Bitbucket", repo.Provider())
+}
+
+func createBitbucketCommit(sha, message string) map[string]interface{} {
+	return map[string]interface{}{"hash": sha, "message": message, "author": map[string]string{"raw": "Jane Doe <jane@example.com>"}}
+}
+
+func createBitbucketTag(name, sha string) map[string]interface{} {
+	return map[string]interface{}{"name": name, "target": map[string]string{"hash": sha}}
+}
+
+var (
+	BITBUCKET_COMMITS = []map[string]interface{}{
+		createBitbucketCommit("abcd", "feat(app): new feature"),
+		createBitbucketCommit("dcba", "Fix: bug"),
+		createBitbucketCommit("cdba", "Initial commit"),
+		createBitbucketCommit("efcd", "chore: break\nBREAKING CHANGE: breaks everything"),
+	}
+	BITBUCKET_TAGS = []map[string]interface{}{
+		createBitbucketTag("test-tag", "deadbeef"),
+		createBitbucketTag("v1.0.0", "deadbeef"),
+		createBitbucketTag("v2.0.0", "deadbeef"),
+		createBitbucketTag("v2.1.0-beta", "deadbeef"),
+		createBitbucketTag("v3.0.0-beta.2", "deadbeef"),
+		createBitbucketTag("v3.0.0-beta.1", "deadbeef"),
+		createBitbucketTag("2020.04.19", "deadbeef"),
+	}
+)
+
+//nolint:errcheck
+func bitbucketHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != "Bearer token" {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if r.Method == "GET" && r.URL.Path == "/repositories/owner/test-repo" {
+		fmt.Fprint(w, `{"is_private": true, "mainbranch": {"name": "master"}}`)
+		return
+	}
+	if r.Method == "GET" && r.URL.Path == "/repositories/owner/test-repo/commits/deadbeef" {
+		// the commits are split across two pages to test the pagination
+		if r.URL.Query().Get("page") == "2" {
+			json.NewEncoder(w).Encode(map[string]interface{}{"values": BITBUCKET_COMMITS[2:]})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"values": BITBUCKET_COMMITS[:2],
+			"next":   fmt.Sprintf("http://%s%s?pagelen=100&page=2", r.Host, r.URL.Path),
+		})
+		return
+	}
+	if r.Method == "GET" && r.URL.Path == "/repositories/owner/test-repo/refs/tags" {
+		json.NewEncoder(w).Encode(map[string]interface{}{"values": BITBUCKET_TAGS})
+		return
+	}
+	if r.Method == "POST" && r.URL.Path == "/repositories/owner/test-repo/refs/tags" {
+		var data struct {
+			Name    string `json:"name"`
+			Message string `json:"message"`
+			Target  struct {
+				Hash string `json:"hash"`
+			} `json:"target"`
+		}
+		json.NewDecoder(r.Body).Decode(&data)
+		r.Body.Close()
+		if data.Name != "v2.0.0" || data.Target.Hash != "deadbeef" || data.Message != "changelog" {
+			http.Error(w, "invalid tag", http.StatusBadRequest)
+			return
+		}
+		fmt.Fprint(w, "{}")
+		return
+	}
+	http.Error(w, "invalid route", http.StatusNotImplemented)
+}
+
+func getNewBitbucketTestRepo(t *testing.T) (*BitbucketRepository, *httptest.Server) {
+	repo, err := NewBitbucketRepository(context.TODO(), "owner/test-repo", "token")
+	require.NoError(t, err)
+	ts := httptest.NewServer(http.HandlerFunc(bitbucketHandler))
+	repo.baseURL = ts.URL
+	return repo, ts
+}
+
+func TestBitbucketGetInfo(t *testing.T) {
+	repo, ts := getNewBitbucketTestRepo(t)
+	defer ts.Close()
+	defaultBranch, isPrivate, err := repo.GetInfo()
+	require.NoError(t, err)
+	require.Equal(t, "master", defaultBranch)
+	require.True(t, isPrivate)
+}
+
+func TestBitbucketGetCommits(t *testing.T) {
+	repo, ts := getNewBitbucketTestRepo(t)
+	defer ts.Close()
+	commits, err := repo.GetCommitsUntil("deadbeef", "")
+	require.NoError(t, err)
+	require.Len(t, commits, 4)
+
+	if !compareCommit(commits[0], "feat", "app", Change{false, true, false}) ||
+		!compareCommit(commits[1], "fix", "", Change{false, false, true}) ||
+		!compareCommit(commits[2], "", "", Change{false, false, false}) ||
+		!compareCommit(commits[3], "chore", "", Change{true, false, false}) {
+		t.Fatal("invalid commits")
+	}
+	require.Equal(t, "Jane Doe", commits[0].Author)
+}
+
+func TestBitbucketGetCommitsStopsAtRelease(t *testing.T) {
+	repo, ts := getNewBitbucketTestRepo(t)
+	defer ts.Close()
+	// the release commit is on the first page, the second page must not be requested
+	commits, err := repo.GetCommitsUntil("deadbeef", "dcba")
+	require.NoError(t, err)
+	require.Len(t, commits, 2)
+	require.Equal(t, "dcba", commits[1].SHA)
+}
+
+func TestBitbucketGetLatestRelease(t *testing.T) {
+	repo, ts := getNewBitbucketTestRepo(t)
+	defer ts.Close()
+
+	testCases := []struct {
+		vrange          string
+		re              *regexp.Regexp
+		expectedSHA     string
+		expectedVersion string
+	}{
+		{"", nil, "deadbeef", "2020.4.19"},
+		{"", regexp.MustCompile("^v[0-9]*"), "deadbeef", "2.0.0"},
+		{"2-beta", nil, "deadbeef", "2.1.0-beta"},
+		{"3-beta", nil, "deadbeef", "3.0.0-beta.2"},
+		{"4-beta", nil, "deadbeef", "4.0.0-beta"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("VersionRange: %s, RE: %s", tc.vrange, tc.re), func(t *testing.T) {
+			release, err := repo.GetLatestRelease(&GetLatestReleaseConfig{VersionRange: tc.vrange, Match: tc.re})
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedSHA, release.SHA)
+			require.Equal(t, tc.expectedVersion, release.Version.String())
+		})
+	}
+}
+
+func TestBitbucketCreateRelease(t *testing.T) {
+	repo, ts := getNewBitbucketTestRepo(t)
+	defer ts.Close()
+	newVersion := semver.MustParse("2.0.0")
+	err := repo.CreateRelease(&CreateReleaseConfig{Changelog: "changelog", NewVersion: newVersion, SHA: "deadbeef"})
+	require.NoError(t, err)
+}
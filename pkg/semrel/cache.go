@@ -0,0 +1,98 @@
+package semrel
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// cachedResponse is a response stored in the cache directory with the ETag used to revalidate it
+type cachedResponse struct {
+	ETag   string
+	Header http.Header
+	Body   []byte
+}
+
+// etagCacheTransport caches the tag listing of GitHub on disk. Every request is still sent, but with the
+// ETag of the cached response, so an unchanged listing is answered with a 304 without a body (which is
+// not counted against the rate limit) and a changed listing always replaces the cached one.
+type etagCacheTransport struct {
+	base http.RoundTripper
+	repo *GitHubRepository
+}
+
+func isTagRequest(req *http.Request) bool {
+	return req.Method == http.MethodGet && (strings.HasSuffix(req.URL.Path, "/git/refs/tags") || strings.Contains(req.URL.Path, "/git/tags/"))
+}
+
+func cacheFile(dir string, req *http.Request) string {
+	key := sha256.Sum256([]byte(req.URL.String() + "\n" + req.Header.Get("Accept")))
+	return filepath.Join(dir, hex.EncodeToString(key[:])+".json")
+}
+
+func readCachedResponse(file string) *cachedResponse {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil
+	}
+	cached := new(cachedResponse)
+	if err := json.Unmarshal(data, cached); err != nil || cached.ETag == "" {
+		return nil
+	}
+	return cached
+}
+
+func (t *etagCacheTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.repo.CacheDir == "" || !isTagRequest(req) {
+		return t.base.RoundTrip(req)
+	}
+	file := cacheFile(t.repo.CacheDir, req)
+	cached := readCachedResponse(file)
+	if cached != nil {
+		req = req.Clone(req.Context())
+		req.Header.Set("If-None-Match", cached.ETag)
+	}
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusNotModified && cached != nil {
+		resp.Body.Close()
+		// the headers of the 304 (e.g. the rate limit) take precedence over the cached ones
+		header := cached.Header.Clone()
+		for key, values := range resp.Header {
+			header[key] = values
+		}
+		resp.StatusCode = http.StatusOK
+		resp.Status = "200 OK"
+		resp.Header = header
+		resp.Body = ioutil.NopCloser(bytes.NewReader(cached.Body))
+		resp.ContentLength = int64(len(cached.Body))
+		return resp, nil
+	}
+
+	etag := resp.Header.Get("ETag")
+	if resp.StatusCode != http.StatusOK || etag == "" {
+		return resp, nil
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+	// the cache is an optimization, failing to write it must not fail the release
+	if data, err := json.Marshal(cachedResponse{etag, resp.Header, body}); err == nil {
+		if err := os.MkdirAll(t.repo.CacheDir, 0700); err == nil {
+			_ = ioutil.WriteFile(file, data, 0600)
+		}
+	}
+	return resp, nil
+}
@@ -0,0 +1,79 @@
+package semrel
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/semver"
+	"github.com/go-semantic-release/semantic-release/pkg/config"
+)
+
+type changelogCommandCommit struct {
+	SHA         string    `json:"sha"`
+	Type        string    `json:"type"`
+	Scope       string    `json:"scope"`
+	Message     string    `json:"message"`
+	Raw         string    `json:"raw"`
+	Breaking    bool      `json:"breaking"`
+	Deprecation string    `json:"deprecation,omitempty"`
+	Author      string    `json:"author"`
+	AuthorLogin string    `json:"authorLogin,omitempty"`
+	CoAuthors   []string  `json:"coAuthors,omitempty"`
+	Links       []string  `json:"links,omitempty"`
+	Date        time.Time `json:"date"`
+}
+
+type changelogCommandInput struct {
+	Version         string                    `json:"version"`
+	PreviousVersion string                    `json:"previousVersion"`
+	Date            string                    `json:"date"`
+	Commits         []*changelogCommandCommit `json:"commits"`
+}
+
+// runChangelogCommand renders the changelog with an external command run by sh, the version and the commits
+// since the latest release are written as JSON to its stdin and its stdout is the changelog
+func runChangelogCommand(conf *config.Config, commits []*Commit, latestRelease *Release, newVersion *semver.Version) (string, error) {
+	data := NewChangelogData(conf, commits, latestRelease, newVersion)
+	input := &changelogCommandInput{
+		Version:         data.Version,
+		PreviousVersion: data.PreviousVersion,
+		Date:            data.Date,
+		Commits:         make([]*changelogCommandCommit, 0, len(commits)),
+	}
+	for _, commit := range commits {
+		if latestRelease.SHA == commit.SHA {
+			break
+		}
+		input.Commits = append(input.Commits, &changelogCommandCommit{
+			SHA:         commit.SHA,
+			Type:        commit.Type,
+			Scope:       commit.Scope,
+			Message:     commit.Message,
+			Raw:         strings.Join(commit.Raw, "\n"),
+			Breaking:    commit.Change.Major,
+			Deprecation: commit.Deprecation,
+			Author:      commit.Author,
+			AuthorLogin: commit.AuthorLogin,
+			CoAuthors:   commit.CoAuthors,
+			Links:       commit.Links,
+			Date:        commit.Date,
+		})
+	}
+	payload, err := json.Marshal(input)
+	if err != nil {
+		return "", err
+	}
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("sh", "-c", conf.ChangelogCommand)
+	cmd.Stdin = bytes.NewReader(payload)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("changelog command failed: %w: %s", err, bytes.TrimSpace(stderr.Bytes()))
+	}
+	return stdout.String(), nil
+}
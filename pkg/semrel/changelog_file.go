@@ -0,0 +1,45 @@
+package semrel
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+// WriteChangelogFile writes the changelog to the file. With prepend the changelog is inserted above the entries of
+// the existing file, below the line containing the marker if it is set (e.g. below the title of the file), an
+// existing file without the marker is not modified and an error is returned. A missing file is created with the
+// marker followed by the changelog.
+func WriteChangelogFile(file, changelog string, prepend bool, marker string) error {
+	if !prepend {
+		return ioutil.WriteFile(file, []byte(changelog), 0644)
+	}
+	existing, err := ioutil.ReadFile(file)
+	if os.IsNotExist(err) {
+		existing, err = []byte(marker), nil
+	}
+	if err != nil {
+		return err
+	}
+	content := string(existing)
+	head := ""
+	if marker != "" {
+		i := strings.Index(content, marker)
+		if i < 0 {
+			return fmt.Errorf("changelog header marker %s not found in %s", marker, file)
+		}
+		end := i + len(marker)
+		if nl := strings.IndexByte(content[end:], '\n'); nl >= 0 {
+			end += nl
+		} else {
+			end = len(content)
+		}
+		head, content = content[:end]+"\n\n", content[end:]
+	}
+	updated := head + strings.TrimRight(changelog, "\n") + "\n"
+	if rest := strings.TrimLeft(content, "\r\n"); rest != "" {
+		updated += "\n" + rest
+	}
+	return ioutil.WriteFile(file, []byte(updated), 0644)
+}
@@ -0,0 +1,49 @@
+package semrel
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteChangelogFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "semrel-changelog-file")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "CHANGELOG.md")
+	read := func() string {
+		data, err := ioutil.ReadFile(file)
+		require.NoError(t, err)
+		return string(data)
+	}
+
+	// a missing file is created
+	require.NoError(t, WriteChangelogFile(file, "## 1.0.0\n\n* first\n\n", true, ""))
+	require.Equal(t, "## 1.0.0\n\n* first\n", read())
+
+	require.NoError(t, WriteChangelogFile(file, "## 1.1.0\n\n* second\n\n", true, ""))
+	require.Equal(t, "## 1.1.0\n\n* second\n\n## 1.0.0\n\n* first\n", read())
+
+	// the release is inserted below the marker, the header is kept
+	require.NoError(t, ioutil.WriteFile(file, []byte("# Changelog\n\nAll notable changes.\n<!-- releases -->\n\n## 1.0.0\n\n* first\n"), 0644))
+	require.NoError(t, WriteChangelogFile(file, "## 1.1.0\n\n* second\n\n", true, "<!-- releases -->"))
+	require.Equal(t, "# Changelog\n\nAll notable changes.\n<!-- releases -->\n\n## 1.1.0\n\n* second\n\n## 1.0.0\n\n* first\n", read())
+
+	// a file without the marker is not modified
+	err = WriteChangelogFile(file, "## 1.2.0\n\n* third\n", true, "<!-- missing -->")
+	require.EqualError(t, err, "changelog header marker <!-- missing --> not found in "+file)
+	require.True(t, strings.HasPrefix(read(), "# Changelog\n"))
+
+	// a new file starts with the marker
+	require.NoError(t, os.Remove(file))
+	require.NoError(t, WriteChangelogFile(file, "## 1.0.0\n\n* first\n", true, "<!-- releases -->"))
+	require.Equal(t, "<!-- releases -->\n\n## 1.0.0\n\n* first\n", read())
+
+	// without prepend the file is overwritten
+	require.NoError(t, WriteChangelogFile(file, "## 2.0.0\n", false, ""))
+	require.Equal(t, "## 2.0.0\n", read())
+}
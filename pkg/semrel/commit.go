@@ -0,0 +1,293 @@
+package semrel
+
+import (
+	"errors"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultCommitPattern matches conventional commits like feat(scope)!: subject
+const DefaultCommitPattern = `^(\w*)(?:\((.*)\))?(\!)?\: (.*)$`
+
+var commitPattern = MustCommitPattern(DefaultCommitPattern)
+var breakingPattern = regexp.MustCompile(`(?m)^BREAKING[ -]CHANGES?:`)
+var deprecatedPattern = regexp.MustCompile(`(?m)^DEPRECATED: (.*)$`)
+var revertPattern = regexp.MustCompile(`(?m)^This reverts commit ([0-9a-fA-F]{7,40})`)
+var issueRefsPattern = regexp.MustCompile(`\b(?i:close[sd]?|fix(?:e[sd])?|resolve[sd]?|refs?)\b:?[ \t]+((?:#\d+|[A-Z][A-Z0-9]+-\d+)(?:(?:[ \t]*,[ \t]*|[ \t]+and[ \t]+)(?:#\d+|[A-Z][A-Z0-9]+-\d+))*)`)
+var issueRefPattern = regexp.MustCompile(`#\d+|[A-Z][A-Z0-9]+-\d+`)
+var coAuthorPattern = regexp.MustCompile(`(?mi)^Co-authored-by:[ \t]*([^<\n]*?)[ \t]*(?:<[^>\n]*>)?[ \t]*$`)
+
+// CommitPattern matches the first line of a commit, the groups named type, scope, breaking and subject are used.
+// Without named groups the groups are type, scope and subject, or type, scope, breaking and subject if there are four.
+type CommitPattern struct {
+	re                            *regexp.Regexp
+	typ, scope, breaking, subject int
+}
+
+// NewCommitPattern compiles the pattern, it must contain a group for the type and the subject
+func NewCommitPattern(pattern string) (*CommitPattern, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	p := &CommitPattern{re: re, typ: -1, scope: -1, breaking: -1, subject: -1}
+	for i, name := range re.SubexpNames() {
+		switch name {
+		case "type":
+			p.typ = i
+		case "scope":
+			p.scope = i
+		case "breaking":
+			p.breaking = i
+		case "subject":
+			p.subject = i
+		}
+	}
+	if p.typ < 0 && p.subject < 0 {
+		switch re.NumSubexp() {
+		case 3:
+			p.typ, p.scope, p.breaking, p.subject = 1, 2, -1, 3
+		case 4:
+			p.typ, p.scope, p.breaking, p.subject = 1, 2, 3, 4
+		}
+	}
+	if p.typ < 0 || p.subject < 0 {
+		return nil, errors.New("commit pattern must contain groups for the type and subject (named type and subject or 3 to 4 positional groups)")
+	}
+	return p, nil
+}
+
+// MustCommitPattern is like NewCommitPattern but panics if the pattern is invalid
+func MustCommitPattern(pattern string) *CommitPattern {
+	p, err := NewCommitPattern(pattern)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+func group(match []string, i int) string {
+	if i < 0 {
+		return ""
+	}
+	return match[i]
+}
+
+type Change struct {
+	Major, Minor, Patch bool
+}
+
+// Verification is the result of the signature check of a commit, Reason explains it (e.g. unsigned or bad_email)
+type Verification struct {
+	Verified bool
+	Reason   string
+}
+
+// Commit is a parsed commit, Author is the git author and AuthorLogin its account if the provider links it.
+// CoAuthors are the names of the Co-authored-by trailers and Links the issues referenced in the body (e.g. fixes #42, closes JIRA-7).
+// Body is the description below the subject including its trailers (e.g. Signed-off-by).
+type Commit struct {
+	SHA         string
+	Raw         []string
+	Type        string
+	Scope       string
+	Message     string
+	Body        string
+	Deprecation string
+	Author      string
+	AuthorLogin string
+	Committer   string
+	CoAuthors   []string
+	Links       []string
+	// Files are the paths changed by the commit, only set if the repository lists them
+	Files []string
+	// Date is the committer date, zero if the repository does not provide it
+	Date time.Time
+	// Parents are the SHAs of the parent commits, more than one parent is a merge commit
+	Parents []string
+	// Verification is the signature verification of the provider, nil if the provider does not report it
+	Verification *Verification
+	Reverts      string
+	Change       Change
+}
+
+// sortCommits orders the commits newest first by their date, commits with the same date keep their order.
+// The first commit is the head the commits are listed from and stays first, even with a skewed or rebased date,
+// as it is the released commit. The commit of the release stays last as the commits are listed up to it.
+// Without a date on every commit the order of the provider is kept.
+func sortCommits(commits []*Commit, releaseSHA string) {
+	for _, commit := range commits {
+		if commit.Date.IsZero() {
+			return
+		}
+	}
+	if n := len(commits); n > 0 && releaseSHA != "" && commits[n-1].SHA == releaseSHA {
+		commits = commits[:n-1]
+	}
+	if len(commits) > 0 {
+		commits = commits[1:]
+	}
+	sort.SliceStable(commits, func(i, j int) bool {
+		return commits[i].Date.After(commits[j].Date)
+	})
+}
+
+// BumpRules map commit types to the level (major, minor, patch or none) they bump
+type BumpRules map[string]string
+
+// DefaultBumpRules are the conventional commit rules
+var DefaultBumpRules = BumpRules{
+	"feat": "minor",
+	"fix":  "patch",
+}
+
+// NewBumpRules returns the default rules overridden by the given rules
+func NewBumpRules(rules map[string]string) BumpRules {
+	ret := make(BumpRules, len(DefaultBumpRules)+len(rules))
+	for commitType, level := range DefaultBumpRules {
+		ret[commitType] = level
+	}
+	for commitType, level := range rules {
+		ret[commitType] = level
+	}
+	return ret
+}
+
+// Change returns the change of a commit type, breaking changes are always major
+func (rules BumpRules) Change(commitType string, breaking bool) Change {
+	level := rules[commitType]
+	return Change{
+		Major: breaking || level == "major",
+		Minor: level == "minor",
+		Patch: level == "patch",
+	}
+}
+
+// ParseCommit parses a conventional commit message with the DefaultBumpRules
+func ParseCommit(sha, message string) *Commit {
+	return ParseCommitWithRules(sha, message, DefaultBumpRules)
+}
+
+// ParseCommitWithRules parses a conventional commit message, breaking changes are either
+// marked with a ! after the type/scope or a BREAKING CHANGE footer
+func ParseCommitWithRules(sha, message string, rules BumpRules) *Commit {
+	return ParseCommitWithPattern(sha, message, commitPattern, rules)
+}
+
+// ParseCommitWithPattern parses a commit message whose first line is matched by the pattern
+func ParseCommitWithPattern(sha, message string, pattern *CommitPattern, rules BumpRules) *Commit {
+	c := new(Commit)
+	c.SHA = sha
+	c.Raw = strings.Split(message, "\n")
+	c.Body = strings.Trim(strings.Join(c.Raw[1:], "\n"), "\r\n")
+	for _, coAuthor := range coAuthorPattern.FindAllStringSubmatch(message, -1) {
+		if coAuthor[1] != "" {
+			c.CoAuthors = append(c.CoAuthors, coAuthor[1])
+		}
+	}
+	found := pattern.re.FindStringSubmatch(c.Raw[0])
+	if found == nil {
+		return c
+	}
+	body := strings.Join(c.Raw[1:], "\n")
+	c.Links = issueRefs(body)
+	c.Type = strings.ToLower(group(found, pattern.typ))
+	c.Scope = group(found, pattern.scope)
+	c.Message = group(found, pattern.subject)
+	if dep := deprecatedPattern.FindStringSubmatch(message); dep != nil {
+		c.Deprecation = dep[1]
+	}
+	if c.Type == "revert" {
+		if rev := revertPattern.FindStringSubmatch(message); rev != nil {
+			c.Reverts = strings.ToLower(rev[1])
+		}
+	}
+	// only a BREAKING CHANGE footer in the body is a breaking change, not a mention in the subject or prose
+	c.Change = rules.Change(c.Type, group(found, pattern.breaking) == "!" || breakingPattern.MatchString(body))
+	return c
+}
+
+// issueRefs returns the deduplicated issue references following a keyword like fixes, closes or refs
+func issueRefs(body string) []string {
+	var refs []string
+	seen := make(map[string]bool)
+	for _, list := range issueRefsPattern.FindAllStringSubmatch(body, -1) {
+		for _, ref := range issueRefPattern.FindAllString(list[1], -1) {
+			if !seen[ref] {
+				seen[ref] = true
+				refs = append(refs, ref)
+			}
+		}
+	}
+	return refs
+}
+
+// ApplyBumpRules recalculates the change of the commits with the given rules
+func ApplyBumpRules(commits []*Commit, rules BumpRules) {
+	for _, commit := range commits {
+		commit.Change = ParseCommitWithRules(commit.SHA, strings.Join(commit.Raw, "\n"), rules).Change
+	}
+}
+
+// ApplyCommitPattern parses the commits again with the pattern and rules, the fields set by the provider
+// (e.g. the author) are kept
+func ApplyCommitPattern(commits []*Commit, pattern *CommitPattern, rules BumpRules) {
+	for _, commit := range commits {
+		parsed := ParseCommitWithPattern(commit.SHA, strings.Join(commit.Raw, "\n"), pattern, rules)
+		commit.Type = parsed.Type
+		commit.Scope = parsed.Scope
+		commit.Message = parsed.Message
+		commit.Deprecation = parsed.Deprecation
+		commit.Links = parsed.Links
+		commit.Reverts = parsed.Reverts
+		commit.Change = parsed.Change
+	}
+}
+
+var listItemPattern = regexp.MustCompile(`^\s*[*-]\s+`)
+
+// ApplySquashBodies adds the changes of the commits listed in the body of squash merged commits
+// (e.g. "* feat: search") to the change of the commit, the lines are matched by the pattern or the default pattern if nil
+func ApplySquashBodies(commits []*Commit, pattern *CommitPattern, rules BumpRules) {
+	if pattern == nil {
+		pattern = commitPattern
+	}
+	for _, commit := range commits {
+		for _, line := range commit.Raw[1:] {
+			found := pattern.re.FindStringSubmatch(listItemPattern.ReplaceAllString(line, ""))
+			if found == nil {
+				continue
+			}
+			change := rules.Change(strings.ToLower(group(found, pattern.typ)), group(found, pattern.breaking) == "!")
+			commit.Change.Major = commit.Change.Major || change.Major
+			commit.Change.Minor = commit.Change.Minor || change.Minor
+			commit.Change.Patch = commit.Change.Patch || change.Patch
+		}
+	}
+}
+
+// revertedCommits returns the commits before the latest release that are reverted by a later commit in the same range
+func revertedCommits(commits []*Commit, latestRelease *Release) map[string]bool {
+	reverted := make(map[string]bool)
+	for i, commit := range commits {
+		if latestRelease.SHA == commit.SHA {
+			break
+		}
+		if commit.Reverts == "" {
+			continue
+		}
+		// commits are ordered from newest to oldest, the reverted commit must be older than the revert
+		for _, c := range commits[i+1:] {
+			if latestRelease.SHA == c.SHA {
+				break
+			}
+			if strings.HasPrefix(strings.ToLower(c.SHA), commit.Reverts) || strings.HasPrefix(commit.Reverts, strings.ToLower(c.SHA)) {
+				reverted[c.SHA] = true
+				break
+			}
+		}
+	}
+	return reverted
+}
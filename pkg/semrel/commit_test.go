@@ -0,0 +1,247 @@
+package semrel
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseCommitBreakingChange(t *testing.T) {
+	testCases := []struct {
+		message        string
+		expectedType   string
+		expectedScope  string
+		expectedChange Change
+	}{
+		{"feat: new feature", "feat", "", Change{false, true, false}},
+		{"feat!: new feature", "feat", "", Change{true, true, false}},
+		{"feat(api)!: new feature", "feat", "api", Change{true, true, false}},
+		{"fix(api): bug\n\nBREAKING CHANGE: removes endpoint", "fix", "api", Change{true, false, true}},
+		{"fix: bug\n\nBREAKING-CHANGE: removes endpoint", "fix", "", Change{true, false, true}},
+		{"chore!: drop support for node 6", "chore", "", Change{true, false, false}},
+		{"fix: mention BREAKING CHANGE in the changelog", "fix", "", Change{false, false, true}},
+		{"fix: bug\n\nthis is not a BREAKING CHANGE: just prose", "fix", "", Change{false, false, true}},
+		{"docs: explain breaking changes\n\nBREAKING CHANGES are listed first", "docs", "", Change{false, false, false}},
+		{"fix: bug\n\nBREAKING CHANGES: removes endpoints", "fix", "", Change{true, false, true}},
+		{"refactor(app): cleanup", "refactor", "app", Change{false, false, false}},
+		{"Initial commit", "", "", Change{false, false, false}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.message, func(t *testing.T) {
+			c := ParseCommit("abcd", tc.message)
+			if !compareCommit(c, tc.expectedType, tc.expectedScope, tc.expectedChange) {
+				t.Errorf("invalid commit: %+v", c)
+			}
+		})
+	}
+	if c := ParseCommit("abcd", "feat(api)!: new feature"); c.Message != "new feature" {
+		t.Errorf("invalid message: %s", c.Message)
+	}
+}
+
+func TestParseCommit(t *testing.T) {
+	c := ParseCommit("abcd", "feat(api): new endpoint\n\nadds /users\n\nDEPRECATED: use /v2/users instead")
+	if c.SHA != "abcd" || c.Type != "feat" || c.Scope != "api" || c.Message != "new endpoint" {
+		t.Errorf("invalid commit: %+v", c)
+	}
+	if c.Deprecation != "use /v2/users instead" {
+		t.Errorf("invalid deprecation: %s", c.Deprecation)
+	}
+	if len(c.Raw) != 5 || c.Raw[2] != "adds /users" {
+		t.Errorf("invalid raw message: %v", c.Raw)
+	}
+}
+
+func TestParseCommitRevert(t *testing.T) {
+	c := ParseCommit("abcd", "revert: new endpoint\n\nThis reverts commit DEADBEEF1234.")
+	if c.Type != "revert" || c.Reverts != "deadbeef1234" || c.Change != (Change{}) {
+		t.Errorf("invalid revert commit: %+v", c)
+	}
+	if c = ParseCommit("abcd", "fix: mention\n\nThis reverts commit deadbeef1234."); c.Reverts != "" {
+		t.Errorf("only revert commits may revert: %+v", c)
+	}
+}
+
+func TestApplyBumpRules(t *testing.T) {
+	commits := []*Commit{
+		ParseCommit("a", "perf: faster"),
+		ParseCommit("b", "security: fix cve"),
+		ParseCommit("c", "fix: typo"),
+		ParseCommit("d", "feat: new"),
+		ParseCommit("e", "perf!: drop cache"),
+	}
+	ApplyBumpRules(commits, NewBumpRules(map[string]string{"perf": "patch", "security": "minor", "fix": "none"}))
+	expected := []Change{
+		{Patch: true},
+		{Minor: true},
+		{},
+		{Minor: true},
+		{Major: true, Patch: true},
+	}
+	for i, commit := range commits {
+		if commit.Change != expected[i] {
+			t.Errorf("invalid change of %s: %+v (expected: %+v)", commit.SHA, commit.Change, expected[i])
+		}
+	}
+}
+
+func TestParseCommitCoAuthors(t *testing.T) {
+	c := ParseCommit("a", "feat: pairing\n\nbody\n\nCo-authored-by: Jane Doe <jane@example.com>\nco-authored-by:John Roe <john@example.com>\nCo-Authored-By: Max Mustermann")
+	if !reflect.DeepEqual(c.CoAuthors, []string{"Jane Doe", "John Roe", "Max Mustermann"}) {
+		t.Errorf("invalid co-authors: %v", c.CoAuthors)
+	}
+	if c = ParseCommit("b", "fix: alone\n\nCo-authored-by: <bot@example.com>"); len(c.CoAuthors) != 0 {
+		t.Errorf("co-authors without name must be ignored: %v", c.CoAuthors)
+	}
+}
+
+func TestParseCommitBody(t *testing.T) {
+	c := ParseCommit("a", "feat: search\n\nfirst paragraph\n\nsecond paragraph\n")
+	if c.Body != "first paragraph\n\nsecond paragraph" {
+		t.Errorf("invalid body: %q", c.Body)
+	}
+	if c = ParseCommit("b", "fix: typo"); c.Body != "" {
+		t.Errorf("commit without body must have an empty body: %q", c.Body)
+	}
+}
+
+func TestParseCommitLinks(t *testing.T) {
+	c := ParseCommit("a", "fix: login (#1)\n\nFixes #42, #43 and JIRA-7\nCloses: LIN-12\nrefs #42\nfixes foo-1, see #99")
+	if !reflect.DeepEqual(c.Links, []string{"#42", "#43", "JIRA-7", "LIN-12"}) {
+		t.Errorf("invalid links: %v", c.Links)
+	}
+	if c = ParseCommit("b", "feat: closes #12"); len(c.Links) != 0 {
+		t.Errorf("only the body may contain links: %v", c.Links)
+	}
+}
+
+func TestApplySquashBodies(t *testing.T) {
+	commits := []*Commit{
+		ParseCommit("a", "Add search (#12)\n\n* feat(search): add index\n* fix: typo\n\nCo-authored-by: Jane Doe <jane@example.com>"),
+		ParseCommit("b", "fix: login (#13)\n\n* fix: session\n- refactor!: drop old api\n"),
+		ParseCommit("c", "docs: readme (#14)\n\n* docs: typo\nsee feat: not a list"),
+		ParseCommit("d", "chore: deps (#15)\n\nfeat: plain line"),
+	}
+	ApplySquashBodies(commits, nil, DefaultBumpRules)
+	expected := []Change{
+		{Minor: true, Patch: true},
+		{Major: true, Patch: true},
+		{},
+		{Minor: true},
+	}
+	for i, commit := range commits {
+		if commit.Change != expected[i] {
+			t.Errorf("invalid change of %s: %+v (expected: %+v)", commit.SHA, commit.Change, expected[i])
+		}
+	}
+
+	// the listed commits are matched by a custom pattern too
+	pattern := MustCommitPattern(`^\[\w+-\d+\] (\w+)(?:\((.*)\))?(\!)?: (.*)$`)
+	commit := ParseCommitWithPattern("e", "[JIRA-1] chore: merge (#16)\n\n* [JIRA-2] feat: search\n* feat: not matched", pattern, DefaultBumpRules)
+	ApplySquashBodies([]*Commit{commit}, pattern, DefaultBumpRules)
+	if commit.Change != (Change{Minor: true}) {
+		t.Errorf("invalid change of %s: %+v", commit.SHA, commit.Change)
+	}
+}
+
+func TestNewCommitPattern(t *testing.T) {
+	for _, pattern := range []string{DefaultCommitPattern, `^\[\w+-\d+\] (\w+)(?:\((.*)\))?: (.*)$`, `^(?P<subject>.*) \((?P<type>\w+)\)$`} {
+		if _, err := NewCommitPattern(pattern); err != nil {
+			t.Errorf("%s: unexpected error: %v", pattern, err)
+		}
+	}
+	for _, pattern := range []string{`^(\w+): (.*)$`, `^(?P<type>\w+): .*$`, `^(\w*`} {
+		if _, err := NewCommitPattern(pattern); err == nil {
+			t.Errorf("%s: expected an error", pattern)
+		}
+	}
+}
+
+func TestParseCommitWithPattern(t *testing.T) {
+	testCases := []struct {
+		pattern       string
+		message       string
+		expectedType  string
+		expectedScope string
+		expectedMsg   string
+		expected      Change
+	}{
+		{`^\[[A-Z]+-\d+\] (\w+)(?:\((.*)\))?(!)?: (.*)$`, "[PROJ-123] feat(api): search", "feat", "api", "search", Change{Minor: true}},
+		{`^\[[A-Z]+-\d+\] (\w+)(?:\((.*)\))?(!)?: (.*)$`, "[PROJ-123] fix!: crash", "fix", "", "crash", Change{Major: true, Patch: true}},
+		{`^\[[A-Z]+-\d+\] (\w+)(?:\((.*)\))?: (.*)$`, "[PROJ-7] fix(ui): typo\n\nBREAKING CHANGE: removed", "fix", "ui", "typo", Change{Major: true, Patch: true}},
+		{`^\[[A-Z]+-\d+\] (\w+)(?:\((.*)\))?(!)?: (.*)$`, "feat: no ticket", "", "", "", Change{}},
+		{`^(?P<subject>.+) \[(?P<type>\w+)\]$`, "Add search [FEAT]", "feat", "", "Add search", Change{Minor: true}},
+	}
+	for _, tc := range testCases {
+		c := ParseCommitWithPattern("abcd", tc.message, MustCommitPattern(tc.pattern), DefaultBumpRules)
+		if c.Type != tc.expectedType || c.Scope != tc.expectedScope || c.Message != tc.expectedMsg || c.Change != tc.expected {
+			t.Errorf("%s: invalid commit: %+v", tc.message, c)
+		}
+	}
+}
+
+func TestApplyCommitPattern(t *testing.T) {
+	commits := []*Commit{ParseCommit("a", "[PROJ-1] feat: search\n\nFixes #12"), ParseCommit("b", "[PROJ-2] perf: faster")}
+	commits[0].Author = "Jane"
+	ApplyCommitPattern(commits, MustCommitPattern(`^\[[A-Z]+-\d+\] (\w+)(?:\((.*)\))?(!)?: (.*)$`), NewBumpRules(map[string]string{"perf": "patch"}))
+	if c := commits[0]; c.Type != "feat" || c.Message != "search" || c.Change != (Change{Minor: true}) || c.Author != "Jane" || !reflect.DeepEqual(c.Links, []string{"#12"}) {
+		t.Errorf("invalid commit: %+v", c)
+	}
+	if c := commits[1]; c.Type != "perf" || c.Change != (Change{Patch: true}) {
+		t.Errorf("invalid commit: %+v", c)
+	}
+}
+
+func commitSHAs(commits []*Commit) []string {
+	shas := make([]string, len(commits))
+	for i, commit := range commits {
+		shas[i] = commit.SHA
+	}
+	return shas
+}
+
+func TestSortCommits(t *testing.T) {
+	date := time.Date(2020, 5, 1, 12, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		commits    []*Commit
+		releaseSHA string
+		expected   []string
+	}{
+		{
+			[]*Commit{{SHA: "head", Date: date.Add(time.Hour)}, {SHA: "b", Date: date.Add(-time.Hour)}, {SHA: "a", Date: date}, {SHA: "c", Date: date.Add(-2 * time.Hour)}},
+			"",
+			[]string{"head", "a", "b", "c"},
+		},
+		// commits with the same date keep their order
+		{
+			[]*Commit{{SHA: "head", Date: date.Add(2 * time.Hour)}, {SHA: "b", Date: date}, {SHA: "a", Date: date}, {SHA: "c", Date: date.Add(time.Hour)}},
+			"",
+			[]string{"head", "c", "b", "a"},
+		},
+		// the head stays first even with an older date, e.g. after a rebase
+		{
+			[]*Commit{{SHA: "head", Date: date.Add(-3 * time.Hour)}, {SHA: "b", Date: date.Add(-time.Hour)}, {SHA: "a", Date: date}},
+			"",
+			[]string{"head", "a", "b"},
+		},
+		// the release commit stays last even if it is newer
+		{
+			[]*Commit{{SHA: "head", Date: date.Add(2 * time.Hour)}, {SHA: "b", Date: date.Add(-time.Hour)}, {SHA: "a", Date: date}, {SHA: "release", Date: date.Add(time.Hour)}},
+			"release",
+			[]string{"head", "a", "b", "release"},
+		},
+		// without dates the order is kept
+		{
+			[]*Commit{{SHA: "b", Date: date.Add(-time.Hour)}, {SHA: "a"}, {SHA: "c", Date: date}},
+			"",
+			[]string{"b", "a", "c"},
+		},
+		{[]*Commit{}, "release", []string{}},
+	}
+	for _, tc := range testCases {
+		sortCommits(tc.commits, tc.releaseSHA)
+		if shas := commitSHAs(tc.commits); !reflect.DeepEqual(shas, tc.expected) {
+			t.Errorf("expected: %v, got: %v", tc.expected, shas)
+		}
+	}
+}
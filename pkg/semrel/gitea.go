@@ -0,0 +1,215 @@
+package semrel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// giteaPageLimit is the requested page size, instances with a lower MAX_RESPONSE_ITEMS return shorter pages so
+// the pages are requested until an empty one
+const giteaPageLimit = 50
+
+type GiteaRepository struct {
+	owner   string
+	repo    string
+	token   string
+	baseURL string
+	Ctx     context.Context
+	client  *http.Client
+}
+
+type giteaCommit struct {
+	SHA    string `json:"sha"`
+	Commit struct {
+		Message string `json:"message"`
+		Author  struct {
+			Name string `json:"name"`
+		} `json:"author"`
+		Committer struct {
+			Name string `json:"name"`
+		} `json:"committer"`
+	} `json:"commit"`
+	Parents []struct {
+		SHA string `json:"sha"`
+	} `json:"parents"`
+}
+
+type giteaTag struct {
+	Name   string `json:"name"`
+	Commit struct {
+		SHA string `json:"sha"`
+	} `json:"commit"`
+}
+
+// NewGiteaRepository creates a Gitea or Forgejo repository, the baseURL is the root of the instance (e.g. https://gitea.com)
+func NewGiteaRepository(ctx context.Context, baseURL, slug, token string) (*GiteaRepository, error) {
+	if baseURL == "" {
+		return nil, errors.New("gitea base url is required")
+	}
+	owner, repoName, err := ParseSlug(slug)
+	if err != nil {
+		return nil, err
+	}
+	repo := new(GiteaRepository)
+	repo.owner = owner
+	repo.repo = repoName
+	repo.token = token
+	repo.baseURL = strings.TrimSuffix(baseURL, "/") + "/api/v1"
+	repo.Ctx = ctx
+	repo.client = httpClient(ctx)
+	return repo, nil
+}
+
+func (repo *GiteaRepository) repoURL(path string) string {
+	return fmt.Sprintf("%s/repos/%s/%s%s", repo.baseURL, url.PathEscape(repo.owner), url.PathEscape(repo.repo), path)
+}
+
+func (repo *GiteaRepository) do(method, u string, body, v interface{}) (*http.Response, error) {
+	return doJSONRequest(repo.Ctx, repo.client, method, u, body, v, func(req *http.Request) {
+		req.Header.Set("Authorization", "token "+repo.token)
+	})
+}
+
+func (repo *GiteaRepository) GetInfo() (string, bool, error) {
+	var info struct {
+		DefaultBranch string `json:"default_branch"`
+		Private       bool   `json:"private"`
+	}
+	resp, err := repo.do("GET", repo.repoURL(""), nil, &info)
+	if resp != nil && resp.StatusCode == 404 {
+		return "", false, fmt.Errorf("%w: %v", ErrRepoNotFound, err)
+	}
+	if err != nil {
+		return "", false, err
+	}
+	return info.DefaultBranch, info.Private, nil
+}
+
+// GetCommits returns all commits reachable from sha, newest first
+func (repo *GiteaRepository) GetCommits(sha string) ([]*Commit, error) {
+	return repo.GetCommitsUntil(sha, "")
+}
+
+// GetCommitsUntil returns the commits reachable from sha, newest first, stopping after the commit releaseSHA
+func (repo *GiteaRepository) GetCommitsUntil(sha, releaseSHA string) ([]*Commit, error) {
+	allCommits := make([]*Commit, 0)
+	for page := 1; ; page++ {
+		query := url.Values{}
+		query.Set("page", fmt.Sprint(page))
+		query.Set("limit", fmt.Sprint(giteaPageLimit))
+		if sha != "" {
+			query.Set("sha", sha)
+		}
+		var commits []*giteaCommit
+		if _, err := repo.do("GET", repo.repoURL("/commits")+"?"+query.Encode(), nil, &commits); err != nil {
+			return nil, err
+		}
+		if len(commits) == 0 {
+			break
+		}
+		for _, commit := range commits {
+			allCommits = append(allCommits, parseGiteaCommit(commit))
+			if releaseSHA != "" && commit.SHA == releaseSHA {
+				return allCommits, nil
+			}
+		}
+	}
+	return allCommits, nil
+}
+
+func (repo *GiteaRepository) GetLatestRelease(latest *GetLatestReleaseConfig) (*Release, error) {
+	allReleases := make(Releases, 0)
+	for page := 1; ; page++ {
+		var tags []*giteaTag
+		u := fmt.Sprintf("%s?page=%d&limit=%d", repo.repoURL("/tags"), page, giteaPageLimit)
+		resp, err := repo.do("GET", u, nil, &tags)
+		if resp != nil && resp.StatusCode == 404 {
+			return nil, ErrNoReleases
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(tags) == 0 && page == 1 {
+			return nil, ErrNoReleases
+		}
+		if len(tags) == 0 {
+			break
+		}
+		for _, tag := range tags {
+			if latest.Match != nil && !latest.Match.MatchString(tag.Name) {
+				continue
+			}
+			tagVersion, ok := latest.TagFormat.Version(tag.Name)
+			if !ok {
+				continue
+			}
+			version, err := parseTagVersion(tagVersion, latest.CoerceVersions)
+			if err != nil {
+				continue
+			}
+			allReleases = append(allReleases, &Release{tag.Commit.SHA, version})
+		}
+	}
+	return latest.selectRelease(allReleases)
+}
+
+// CreateRelease uses the release endpoint which creates the tag on the given sha as well
+func (repo *GiteaRepository) CreateRelease(release *CreateReleaseConfig) error {
+	tag := release.TagFormat.Format(release.NewVersion.String())
+	name := tag
+	if release.Title != "" {
+		name = release.Title
+	}
+	body := map[string]interface{}{
+		"tag_name":         tag,
+		"target_commitish": release.SHA,
+		"name":             name,
+		"body":             release.Changelog,
+		"prerelease":       release.Prerelease || release.NewVersion.Prerelease() != "",
+	}
+	if _, err := repo.do("POST", repo.repoURL("/releases"), body, nil); err != nil {
+		return err
+	}
+	for _, v := range GetMovingTagVersions(release.NewVersion, release.MovingTags) {
+		if err := repo.moveTag(release.TagFormat.Format(v), release.SHA); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// moveTag recreates the tag on the given sha as Gitea tags can not be updated
+func (repo *GiteaRepository) moveTag(tag, sha string) error {
+	resp, err := repo.do("DELETE", repo.repoURL("/tags/"+url.PathEscape(tag)), nil, nil)
+	if err != nil && (resp == nil || resp.StatusCode != 404) {
+		return err
+	}
+	_, err = repo.do("POST", repo.repoURL("/tags"), map[string]string{"tag_name": tag, "target": sha}, nil)
+	return err
+}
+
+func parseGiteaCommit(commit *giteaCommit) *Commit {
+	c := ParseCommit(commit.SHA, commit.Commit.Message)
+	c.Author = commit.Commit.Author.Name
+	c.Committer = commit.Commit.Committer.Name
+	for _, parent := range commit.Parents {
+		c.Parents = append(c.Parents, parent.SHA)
+	}
+	return c
+}
+
+func (repo *GiteaRepository) Owner() string {
+	return repo.owner
+}
+
+func (repo *GiteaRepository) Repo() string {
+	return repo.repo
+}
+
+func (repo *GiteaRepository) Provider() string {
+	return "Gitea"
+}
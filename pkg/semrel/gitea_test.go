@@ -0,0 +1,208 @@
+package semrel
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"testing"
+
+	"github.com/Masterminds/semver"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewGiteaRepository(t *testing.T) {
+	require := require.New(t)
+
+	repo, err := NewGiteaRepository(context.TODO(), "", "owner/test-repo", "token")
+	require.Nil(repo)
+	require.EqualError(err, "gitea base url is required")
+
+	repo, err = NewGiteaRepository(context.TODO(), "https://gitea.example.com", "", "token")
+	require.Nil(repo)
+	require.EqualError(err, `invalid slug "": must be owner/repo`)
+
+	repo, err = NewGiteaRepository(context.TODO(), "https://gitea.example.com/", "owner/test-repo", "token")
+	require.NotNil(repo)
+	require.NoError(err)
+	require.Equal("owner", repo.Owner())
+	require.Equal("test-repo", repo.Repo())
+	require.Equal("Gitea", repo.Provider())
+	require.Equal("https://gitea.example.com/api/v1", repo.baseURL)
+}
+
+func createGiteaCommit(sha, message string) map[string]interface{} {
+	return map[string]interface{}{"sha": sha, "commit": map[string]interface{}{"message": message}}
+}
+
+func createGiteaTag(name, sha string) map[string]interface{} {
+	return map[string]interface{}{"name": name, "commit": map[string]string{"sha": sha}}
+}
+
+var (
+	GITEA_COMMITS = []map[string]interface{}{
+		createGiteaCommit("abcd", "feat(app): new feature"),
+		createGiteaCommit("dcba", "Fix: bug"),
+		createGiteaCommit("cdba", "Initial commit"),
+		createGiteaCommit("efcd", "chore: break\nBREAKING CHANGE: breaks everything"),
+	}
+	GITEA_TAGS = []map[string]interface{}{
+		createGiteaTag("test-tag", "deadbeef"),
+		createGiteaTag("v1.0.0", "deadbeef"),
+		createGiteaTag("v2.0.0", "deadbeef"),
+		createGiteaTag("v2.1.0-beta", "deadbeef"),
+		createGiteaTag("v3.0.0-beta.2", "deadbeef"),
+		createGiteaTag("v3.0.0-beta.1", "deadbeef"),
+		createGiteaTag("2020.04.19", "deadbeef"),
+	}
+)
+
+// giteaPage returns the requested page of the items, the page size is capped at maxItems like MAX_RESPONSE_ITEMS
+func giteaPage(r *http.Request, items []map[string]interface{}, maxItems int) []map[string]interface{} {
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > maxItems {
+		limit = maxItems
+	}
+	start := (page - 1) * limit
+	if start >= len(items) {
+		return []map[string]interface{}{}
+	}
+	end := start + limit
+	if end > len(items) {
+		end = len(items)
+	}
+	return items[start:end]
+}
+
+//nolint:errcheck
+func giteaHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != "token token" {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if r.Method == "GET" && r.URL.Path == "/api/v1/repos/owner/test-repo" {
+		fmt.Fprint(w, `{"default_branch": "master", "private": true}`)
+		return
+	}
+	if r.Method == "GET" && r.URL.Path == "/api/v1/repos/owner/test-repo/commits" {
+		json.NewEncoder(w).Encode(giteaPage(r, GITEA_COMMITS, giteaPageLimit))
+		return
+	}
+	if r.Method == "GET" && r.URL.Path == "/api/v1/repos/owner/test-repo/tags" {
+		json.NewEncoder(w).Encode(giteaPage(r, GITEA_TAGS, giteaPageLimit))
+		return
+	}
+	if r.Method == "POST" && r.URL.Path == "/api/v1/repos/owner/test-repo/releases" {
+		var data map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&data)
+		r.Body.Close()
+		if data["tag_name"] != "v2.0.0" || data["target_commitish"] != "deadbeef" {
+			http.Error(w, "invalid tag name or target", http.StatusBadRequest)
+			return
+		}
+		fmt.Fprint(w, "{}")
+		return
+	}
+	http.Error(w, "invalid route", http.StatusNotImplemented)
+}
+
+func getNewGiteaTestRepo(t *testing.T) (*GiteaRepository, *httptest.Server) {
+	ts := httptest.NewServer(http.HandlerFunc(giteaHandler))
+	repo, err := NewGiteaRepository(context.TODO(), ts.URL, "owner/test-repo", "token")
+	require.NoError(t, err)
+	return repo, ts
+}
+
+func TestGiteaGetInfo(t *testing.T) {
+	repo, ts := getNewGiteaTestRepo(t)
+	defer ts.Close()
+	defaultBranch, isPrivate, err := repo.GetInfo()
+	require.NoError(t, err)
+	require.Equal(t, "master", defaultBranch)
+	require.True(t, isPrivate)
+}
+
+func TestGiteaGetCommits(t *testing.T) {
+	repo, ts := getNewGiteaTestRepo(t)
+	defer ts.Close()
+	commits, err := repo.GetCommitsUntil("", "")
+	require.NoError(t, err)
+	require.Len(t, commits, 4)
+
+	if !compareCommit(commits[0], "feat", "app", Change{false, true, false}) ||
+		!compareCommit(commits[1], "fix", "", Change{false, false, true}) ||
+		!compareCommit(commits[2], "", "", Change{false, false, false}) ||
+		!compareCommit(commits[3], "chore", "", Change{true, false, false}) {
+		t.Fatal("invalid commits")
+	}
+}
+
+func TestGiteaGetLatestRelease(t *testing.T) {
+	repo, ts := getNewGiteaTestRepo(t)
+	defer ts.Close()
+
+	testCases := []struct {
+		vrange          string
+		re              *regexp.Regexp
+		expectedSHA     string
+		expectedVersion string
+	}{
+		{"", nil, "deadbeef", "2020.4.19"},
+		{"", regexp.MustCompile("^v[0-9]*"), "deadbeef", "2.0.0"},
+		{"2-beta", nil, "deadbeef", "2.1.0-beta"},
+		{"3-beta", nil, "deadbeef", "3.0.0-beta.2"},
+		{"4-beta", nil, "deadbeef", "4.0.0-beta"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("VersionRange: %s, RE: %s", tc.vrange, tc.re), func(t *testing.T) {
+			release, err := repo.GetLatestRelease(&GetLatestReleaseConfig{VersionRange: tc.vrange, Match: tc.re})
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedSHA, release.SHA)
+			require.Equal(t, tc.expectedVersion, release.Version.String())
+		})
+	}
+}
+
+func TestGiteaCreateRelease(t *testing.T) {
+	repo, ts := getNewGiteaTestRepo(t)
+	defer ts.Close()
+	newVersion := semver.MustParse("2.0.0")
+	err := repo.CreateRelease(&CreateReleaseConfig{NewVersion: newVersion, SHA: "deadbeef"})
+	require.NoError(t, err)
+}
+
+//nolint:errcheck
+func TestGiteaShortPages(t *testing.T) {
+	// an instance with a MAX_RESPONSE_ITEMS below the requested limit
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/repos/owner/test-repo/commits":
+			json.NewEncoder(w).Encode(giteaPage(r, GITEA_COMMITS, 3))
+		case "/api/v1/repos/owner/test-repo/tags":
+			json.NewEncoder(w).Encode(giteaPage(r, GITEA_TAGS, 3))
+		default:
+			http.Error(w, "invalid route", http.StatusNotImplemented)
+		}
+	}))
+	defer ts.Close()
+	repo, err := NewGiteaRepository(context.TODO(), ts.URL, "owner/test-repo", "token")
+	require.NoError(t, err)
+
+	commits, err := repo.GetCommitsUntil("", "")
+	require.NoError(t, err)
+	require.Len(t, commits, 4)
+	require.Equal(t, "efcd", commits[3].SHA)
+
+	// the latest version is the last tag
+	release, err := repo.GetLatestRelease(&GetLatestReleaseConfig{})
+	require.NoError(t, err)
+	require.Equal(t, "2020.4.19", release.Version.String())
+}
@@ -2,94 +2,244 @@ package semrel
 
 import (
 	"context"
-	"errors"
 	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
 	"os"
-	"regexp"
+	"path/filepath"
 	"strings"
+	"time"
 
-	"github.com/Masterminds/semver"
 	"github.com/google/go-github/v30/github"
 	"golang.org/x/oauth2"
 )
 
+const (
+	taggerName  = "semantic-release"
+	taggerEmail = "semantic-release@users.noreply.github.com"
+)
+
 type GitHubRepository struct {
 	owner  string
 	repo   string
 	Ctx    context.Context
 	Client *github.Client
+	// ListFiles fetches the changed files of every commit, this costs one request per commit
+	ListFiles bool
+	// WaitForRateLimit waits for the reset of the rate limit instead of failing read requests
+	WaitForRateLimit bool
+	// CacheDir stores the tag listing to revalidate it with conditional requests, empty disables the cache
+	CacheDir string
+	// Since only lists the commits after the time, the commit of the previous release must be within the window
+	Since time.Time
+}
+
+func NewGitHubRepository(ctx context.Context, gheHost, gheAPIURL, gheUploadURL, slug, token string) (*GitHubRepository, error) {
+	oauthClient := oauth2.NewClient(ctx, oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token}))
+	return newGitHubRepository(ctx, gheHost, gheAPIURL, gheUploadURL, slug, func(*GitHubRepository) http.RoundTripper {
+		return oauthClient.Transport
+	})
 }
 
-func NewGitHubRepository(ctx context.Context, gheHost, slug, token string) (*GitHubRepository, error) {
-	if !strings.Contains(slug, "/") {
-		return nil, errors.New("invalid slug")
+// NewGitHubAppRepository authenticates as the installation of a GitHub App instead of with a token
+func NewGitHubAppRepository(ctx context.Context, gheHost, gheAPIURL, gheUploadURL, slug string, app *GitHubApp) (*GitHubRepository, error) {
+	key, err := parseGitHubAppKey(app.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+	base := httpClient(ctx).Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return newGitHubRepository(ctx, gheHost, gheAPIURL, gheUploadURL, slug, func(repo *GitHubRepository) http.RoundTripper {
+		return &githubAppTransport{base: base, repo: repo, app: app, key: key}
+	})
+}
+
+func newGitHubRepository(ctx context.Context, gheHost, gheAPIURL, gheUploadURL, slug string, auth func(*GitHubRepository) http.RoundTripper) (*GitHubRepository, error) {
+	owner, repoName, err := ParseSlug(slug)
+	if err != nil {
+		return nil, err
 	}
 	repo := new(GitHubRepository)
-	split := strings.Split(slug, "/")
-	repo.owner = split[0]
-	repo.repo = split[1]
+	repo.owner = owner
+	repo.repo = repoName
 	repo.Ctx = ctx
-	oauthClient := oauth2.NewClient(ctx, oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token}))
-	if gheHost != "" {
+	oauthClient := &http.Client{Transport: &rateLimitTransport{base: &etagCacheTransport{base: auth(repo), repo: repo}, repo: repo}}
+	switch {
+	case gheAPIURL != "":
+		if gheUploadURL == "" {
+			gheUploadURL = gheAPIURL
+		}
+		// the URLs are set directly as NewEnterpriseClient would enforce the /api/v3/ path
+		repo.Client = github.NewClient(oauthClient)
+		if err := setBaseURLs(repo.Client, gheAPIURL, gheUploadURL); err != nil {
+			return nil, err
+		}
+	case gheHost != "":
 		gheUrl := fmt.Sprintf("https://%s/api/v3/", gheHost)
 		rClient, err := github.NewEnterpriseClient(gheUrl, gheUrl, oauthClient)
 		if err != nil {
 			return nil, err
 		}
 		repo.Client = rClient
-	} else {
+	default:
 		repo.Client = github.NewClient(oauthClient)
 	}
 	return repo, nil
 }
 
+func parseBaseURL(rawURL string) (*url.URL, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("invalid url: %s", rawURL)
+	}
+	if !strings.HasSuffix(u.Path, "/") {
+		u.Path += "/"
+	}
+	return u, nil
+}
+
+func setBaseURLs(client *github.Client, apiURL, uploadURL string) error {
+	baseURL, err := parseBaseURL(apiURL)
+	if err != nil {
+		return err
+	}
+	uploadBaseURL, err := parseBaseURL(uploadURL)
+	if err != nil {
+		return err
+	}
+	client.BaseURL = baseURL
+	client.UploadURL = uploadBaseURL
+	return nil
+}
+
 func (repo *GitHubRepository) GetInfo() (string, bool, error) {
-	r, _, err := repo.Client.Repositories.Get(repo.Ctx, repo.owner, repo.repo)
+	r, resp, err := repo.Client.Repositories.Get(repo.Ctx, repo.owner, repo.repo)
+	if resp != nil && resp.StatusCode == 404 {
+		return "", false, fmt.Errorf("%w: %v", ErrRepoNotFound, err)
+	}
 	if err != nil {
-		return "", false, err
+		return "", false, githubRateLimitError(err)
 	}
 	return r.GetDefaultBranch(), r.GetPrivate(), nil
 }
 
+// GetCommits returns all commits reachable from sha, newest first
 func (repo *GitHubRepository) GetCommits(sha string) ([]*Commit, error) {
+	return repo.GetCommitsUntil(sha, "")
+}
+
+// GetCommitsUntil returns the commits reachable from sha, newest first, stopping after the commit releaseSHA
+func (repo *GitHubRepository) GetCommitsUntil(sha, releaseSHA string) ([]*Commit, error) {
+	commits, err := repo.listCommits(sha, releaseSHA)
+	if err != nil {
+		return nil, githubRateLimitError(err)
+	}
+	sortCommits(commits, releaseSHA)
+	if !repo.ListFiles {
+		return commits, nil
+	}
+	for _, commit := range commits {
+		if commit.SHA == releaseSHA {
+			continue
+		}
+		if commit.Files, err = repo.commitFiles(commit.SHA); err != nil {
+			return nil, githubRateLimitError(err)
+		}
+	}
+	return commits, nil
+}
+
+func (repo *GitHubRepository) commitFiles(sha string) ([]string, error) {
+	commit, _, err := repo.Client.Repositories.GetCommit(repo.Ctx, repo.owner, repo.repo, sha)
+	if err != nil {
+		return nil, err
+	}
+	files := make([]string, 0, len(commit.Files))
+	for _, file := range commit.Files {
+		files = append(files, file.GetFilename())
+		if file.GetPreviousFilename() != "" {
+			files = append(files, file.GetPreviousFilename())
+		}
+	}
+	return files, nil
+}
+
+func (repo *GitHubRepository) listCommits(sha, releaseSHA string) ([]*Commit, error) {
 	opts := &github.CommitsListOptions{
 		SHA:         sha,
+		Since:       repo.Since,
 		ListOptions: github.ListOptions{PerPage: 100},
 	}
-	commits, _, err := repo.Client.Repositories.ListCommits(repo.Ctx, repo.owner, repo.repo, opts)
-	if err != nil {
-		return nil, err
+	allCommits := make([]*Commit, 0)
+	for {
+		commits, resp, err := repo.Client.Repositories.ListCommits(repo.Ctx, repo.owner, repo.repo, opts)
+		if err != nil {
+			return nil, err
+		}
+		for _, commit := range commits {
+			allCommits = append(allCommits, parseGithubCommit(commit))
+			if releaseSHA != "" && commit.GetSHA() == releaseSHA {
+				return allCommits, nil
+			}
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
 	}
-	ret := make([]*Commit, len(commits))
-	for i, commit := range commits {
-		ret[i] = parseGithubCommit(commit)
+	if releaseSHA != "" && !repo.Since.IsZero() {
+		return nil, errReleaseOutsideWindow(releaseSHA, repo.Since)
 	}
-	return ret, nil
+	return allCommits, nil
+}
+
+func (repo *GitHubRepository) GetLatestRelease(latest *GetLatestReleaseConfig) (*Release, error) {
+	release, err := repo.getLatestRelease(latest)
+	return release, githubRateLimitError(err)
 }
 
-func (repo *GitHubRepository) GetLatestRelease(vrange string, re *regexp.Regexp) (*Release, error) {
+func (repo *GitHubRepository) getLatestRelease(latest *GetLatestReleaseConfig) (*Release, error) {
 	allReleases := make(Releases, 0)
+	annotated := make(map[string]bool)
 	opts := &github.ReferenceListOptions{Type: "tags", ListOptions: github.ListOptions{PerPage: 100}}
 	for {
 		refs, resp, err := repo.Client.Git.ListRefs(repo.Ctx, repo.owner, repo.repo, opts)
+		// the tags can not be listed if there are none
 		if resp != nil && resp.StatusCode == 404 {
-			return &Release{"", &semver.Version{}}, nil
+			return nil, ErrNoReleases
 		}
 		if err != nil {
 			return nil, err
 		}
+		if len(refs) == 0 && opts.Page <= 1 {
+			return nil, ErrNoReleases
+		}
 		for _, r := range refs {
 			tag := strings.TrimPrefix(r.GetRef(), "refs/tags/")
-			if re != nil && !re.MatchString(tag) {
+			if latest.Match != nil && !latest.Match.MatchString(tag) {
+				continue
+			}
+			objectType := r.Object.GetType()
+			if objectType != "commit" && objectType != "tag" {
 				continue
 			}
-			if r.Object.GetType() != "commit" {
+			tagVersion, ok := latest.TagFormat.Version(tag)
+			if !ok {
 				continue
 			}
-			version, err := semver.NewVersion(tag)
+			version, err := parseTagVersion(tagVersion, latest.CoerceVersions)
 			if err != nil {
 				continue
 			}
+			if objectType == "tag" {
+				annotated[r.Object.GetSHA()] = true
+			}
 			allReleases = append(allReleases, &Release{r.Object.GetSHA(), version})
 		}
 		if resp.NextPage == 0 {
@@ -98,54 +248,307 @@ func (repo *GitHubRepository) GetLatestRelease(vrange string, re *regexp.Regexp)
 		opts.Page = resp.NextPage
 	}
 
-	return allReleases.GetLatestRelease(vrange)
+	release, err := latest.selectRelease(allReleases)
+	if err != nil || !annotated[release.SHA] {
+		return release, err
+	}
+	// annotated tags point to a tag object which references the commit, only the selected one is resolved
+	annotatedTag, _, err := repo.Client.Git.GetTag(repo.Ctx, repo.owner, repo.repo, release.SHA)
+	if err != nil {
+		return nil, err
+	}
+	return &Release{annotatedTag.GetObject().GetSHA(), release.Version}, nil
+}
+
+func (repo *GitHubRepository) CreateRelease(release *CreateReleaseConfig) error {
+	return githubRateLimitError(repo.createRelease(release))
 }
 
-func (repo *GitHubRepository) CreateRelease(changelog string, newVersion *semver.Version, prerelease bool, branch, sha string) error {
-	tag := fmt.Sprintf("%s-v%s", os.Getenv("pkg_name"), newVersion.String())
-	isPrerelease := prerelease || newVersion.Prerelease() != ""
+func (repo *GitHubRepository) createRelease(release *CreateReleaseConfig) error {
+	tag := release.TagFormat.Format(release.NewVersion.String())
+	isPrerelease := release.Prerelease || release.NewVersion.Prerelease() != ""
+
+	// the category is validated before anything is created
+	if release.DiscussionCategory != "" {
+		found, err := repo.hasDiscussionCategory(release.DiscussionCategory)
+		if err != nil {
+			return err
+		}
+		if !found {
+			return fmt.Errorf("discussion category %q does not exist in %s/%s", release.DiscussionCategory, repo.owner, repo.repo)
+		}
+	}
 
-	if branch != sha {
+	if release.Branch != release.SHA {
 		ref := "refs/tags/" + tag
+		tagSHA := release.SHA
+		if !release.Timestamp.IsZero() || release.Signer != nil {
+			annotatedTag, err := repo.createAnnotatedTag(tag, release.SHA, release.Timestamp, release.Signer)
+			if err != nil {
+				return err
+			}
+			tagSHA = annotatedTag.GetSHA()
+		}
 		tagOpts := &github.Reference{
 			Ref:    &ref,
-			Object: &github.GitObject{SHA: &sha},
+			Object: &github.GitObject{SHA: &tagSHA},
 		}
-		_, _, err := repo.Client.Git.CreateRef(repo.Ctx, repo.owner, repo.repo, tagOpts)
+		_, resp, err := repo.Client.Git.CreateRef(repo.Ctx, repo.owner, repo.repo, tagOpts)
 		if err != nil {
-			return err
+			// the tag may exist from a previous attempt which failed afterwards
+			if resp == nil || resp.StatusCode != 422 {
+				return err
+			}
+			if !repo.tagPointsTo(tag, release.SHA) {
+				return fmt.Errorf("tag %s already exists and does not point to %s", tag, release.SHA)
+			}
+			release.logf("tag %s already exists, continuing with the release", tag)
+		}
+		for _, v := range GetMovingTagVersions(release.NewVersion, release.MovingTags) {
+			if err := repo.moveTag(release.TagFormat.Format(v), release.SHA); err != nil {
+				return err
+			}
 		}
 	}
 
-	opts := &github.RepositoryRelease{
-		TagName:         &tag,
-		Name:            &tag,
-		TargetCommitish: &branch,
-		Body:            &changelog,
-		Prerelease:      &isPrerelease,
+	name := tag
+	if release.Title != "" {
+		name = release.Title
+	}
+	opts := &githubRelease{
+		RepositoryRelease: &github.RepositoryRelease{
+			TagName:         &tag,
+			Name:            &name,
+			TargetCommitish: &release.Branch,
+			Body:            &release.Changelog,
+			Prerelease:      &isPrerelease,
+		},
+		DiscussionCategoryName: release.DiscussionCategory,
+		MakeLatest:             release.MakeLatest,
+		GenerateReleaseNotes:   release.GenerateReleaseNotes,
 	}
-	_, _, err := repo.Client.Repositories.CreateRelease(repo.Ctx, repo.owner, repo.repo, opts)
+	// the release is created manually as the client does not support all fields of the API
+	req, err := repo.Client.NewRequest("POST", fmt.Sprintf("repos/%s/%s/releases", repo.owner, repo.repo), opts)
 	if err != nil {
 		return err
 	}
+	resp, err := repo.Client.Do(repo.Ctx, req, nil)
+	if err != nil && resp != nil && resp.StatusCode == 422 {
+		// the release may exist from a previous attempt, it is updated instead
+		existing, _, getErr := repo.Client.Repositories.GetReleaseByTag(repo.Ctx, repo.owner, repo.repo, tag)
+		if getErr != nil {
+			return err
+		}
+		release.logf("release %s already exists, updating it", tag)
+		_, _, err = repo.Client.Repositories.EditRelease(repo.Ctx, repo.owner, repo.repo, existing.GetID(), &github.RepositoryRelease{
+			Name:       &name,
+			Body:       &release.Changelog,
+			Prerelease: &isPrerelease,
+		})
+	}
+	return err
+}
+
+// UploadReleaseAssets uploads the files to the release of the tag, assets with the same name are replaced.
+// The SHA256SUMS of the files are uploaded as well if checksums is set.
+func (repo *GitHubRepository) UploadReleaseAssets(tag string, files []string, checksums bool) error {
+	return githubRateLimitError(repo.uploadReleaseAssets(tag, files, checksums))
+}
+
+func (repo *GitHubRepository) uploadReleaseAssets(tag string, files []string, checksums bool) error {
+	release, _, err := repo.Client.Repositories.GetReleaseByTag(repo.Ctx, repo.owner, repo.repo, tag)
+	if err != nil {
+		return err
+	}
+	existing := make(map[string]int64)
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		assets, resp, err := repo.Client.Repositories.ListReleaseAssets(repo.Ctx, repo.owner, repo.repo, release.GetID(), opts)
+		if err != nil {
+			return err
+		}
+		for _, asset := range assets {
+			existing[asset.GetName()] = asset.GetID()
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	if checksums {
+		sums, err := Checksums(files)
+		if err != nil {
+			return err
+		}
+		dir, err := ioutil.TempDir("", "semantic-release")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(dir)
+		checksumsFile := filepath.Join(dir, ChecksumsFile)
+		if err := ioutil.WriteFile(checksumsFile, []byte(sums), 0644); err != nil {
+			return err
+		}
+		files = append(files, checksumsFile)
+	}
+
+	for _, file := range files {
+		name := filepath.Base(file)
+		if id, ok := existing[name]; ok {
+			if _, err := repo.Client.Repositories.DeleteReleaseAsset(repo.Ctx, repo.owner, repo.repo, id); err != nil {
+				return fmt.Errorf("could not replace asset %s: %w", name, err)
+			}
+		}
+		if err := repo.uploadReleaseAsset(release.GetID(), file); err != nil {
+			return fmt.Errorf("could not upload %s: %w", file, err)
+		}
+	}
 	return nil
 }
 
+func (repo *GitHubRepository) uploadReleaseAsset(releaseID int64, file string) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, _, err = repo.Client.Repositories.UploadReleaseAsset(repo.Ctx, repo.owner, repo.repo, releaseID, &github.UploadOptions{Name: filepath.Base(file)}, f)
+	return err
+}
+
+// tagPointsTo checks whether the tag exists and references the commit, annotated tags are resolved
+func (repo *GitHubRepository) tagPointsTo(tag, sha string) bool {
+	ref, _, err := repo.Client.Git.GetRef(repo.Ctx, repo.owner, repo.repo, "tags/"+tag)
+	if err != nil {
+		return false
+	}
+	refSHA := ref.GetObject().GetSHA()
+	if ref.GetObject().GetType() == "tag" {
+		annotatedTag, _, err := repo.Client.Git.GetTag(repo.Ctx, repo.owner, repo.repo, refSHA)
+		if err != nil {
+			return false
+		}
+		refSHA = annotatedTag.GetObject().GetSHA()
+	}
+	return refSHA == sha
+}
+
+// githubRelease extends the release of the client with the fields it does not support yet
+type githubRelease struct {
+	*github.RepositoryRelease
+	DiscussionCategoryName string `json:"discussion_category_name,omitempty"`
+	MakeLatest             string `json:"make_latest,omitempty"`
+	GenerateReleaseNotes   bool   `json:"generate_release_notes,omitempty"`
+}
+
+// graphQLURL returns the GraphQL endpoint, GitHub Enterprise serves it at /api/graphql instead of /api/v3/graphql
+func (repo *GitHubRepository) graphQLURL() string {
+	baseURL := *repo.Client.BaseURL
+	if strings.HasSuffix(baseURL.Path, "/api/v3/") {
+		baseURL.Path = strings.TrimSuffix(baseURL.Path, "v3/") + "graphql"
+		return baseURL.String()
+	}
+	return "graphql"
+}
+
+// hasDiscussionCategory checks whether the discussion category exists, categories are only available via GraphQL
+func (repo *GitHubRepository) hasDiscussionCategory(category string) (bool, error) {
+	query := map[string]interface{}{
+		"query": `query($owner: String!, $name: String!) {
+  repository(owner: $owner, name: $name) { discussionCategories(first: 100) { nodes { name } } }
+}`,
+		"variables": map[string]string{"owner": repo.owner, "name": repo.repo},
+	}
+	req, err := repo.Client.NewRequest("POST", repo.graphQLURL(), query)
+	if err != nil {
+		return false, err
+	}
+	var resp struct {
+		Data struct {
+			Repository struct {
+				DiscussionCategories struct {
+					Nodes []struct {
+						Name string `json:"name"`
+					} `json:"nodes"`
+				} `json:"discussionCategories"`
+			} `json:"repository"`
+		} `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if _, err := repo.Client.Do(repo.Ctx, req, &resp); err != nil {
+		return false, err
+	}
+	if len(resp.Errors) > 0 {
+		return false, fmt.Errorf("could not list discussion categories: %s", resp.Errors[0].Message)
+	}
+	for _, node := range resp.Data.Repository.DiscussionCategories.Nodes {
+		if node.Name == category {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// moveTag force-updates the tag to the given sha or creates it if it does not exist yet
+func (repo *GitHubRepository) moveTag(tag, sha string) error {
+	ref := "refs/tags/" + tag
+	tagOpts := &github.Reference{
+		Ref:    &ref,
+		Object: &github.GitObject{SHA: &sha},
+	}
+	_, resp, err := repo.Client.Git.UpdateRef(repo.Ctx, repo.owner, repo.repo, tagOpts, true)
+	if resp != nil && (resp.StatusCode == 404 || resp.StatusCode == 422) {
+		_, _, err = repo.Client.Git.CreateRef(repo.Ctx, repo.owner, repo.repo, tagOpts)
+	}
+	return err
+}
+
+// createAnnotatedTag creates a tag object dated at the given timestamp, lightweight refs do not carry a date
+func (repo *GitHubRepository) createAnnotatedTag(tag, sha string, timestamp time.Time, signer TagSigner) (*github.Tag, error) {
+	if timestamp.IsZero() {
+		timestamp = time.Now()
+	}
+	// the signed payload only has a precision of seconds
+	timestamp = timestamp.UTC().Truncate(time.Second)
+	message := tag + "\n"
+	if signer != nil {
+		// git stores the signature at the end of the message
+		signature, err := signer(tagPayload(tag, sha, message, timestamp))
+		if err != nil {
+			return nil, err
+		}
+		message += string(signature)
+	}
+	objectType := "commit"
+	tagOpts := &github.Tag{
+		Tag:     &tag,
+		Message: &message,
+		Object:  &github.GitObject{SHA: &sha, Type: &objectType},
+		Tagger: &github.CommitAuthor{
+			Name:  github.String(taggerName),
+			Email: github.String(taggerEmail),
+			Date:  &timestamp,
+		},
+	}
+	annotatedTag, _, err := repo.Client.Git.CreateTag(repo.Ctx, repo.owner, repo.repo, tagOpts)
+	return annotatedTag, err
+}
+
 func parseGithubCommit(commit *github.RepositoryCommit) *Commit {
-	c := new(Commit)
-	c.SHA = commit.GetSHA()
-	c.Raw = strings.Split(commit.Commit.GetMessage(), "\n")
-	found := commitPattern.FindAllStringSubmatch(c.Raw[0], -1)
-	if len(found) < 1 {
-		return c
-	}
-	c.Type = strings.ToLower(found[0][1])
-	c.Scope = found[0][2]
-	c.Message = found[0][3]
-	c.Change = Change{
-		Major: breakingPattern.MatchString(commit.Commit.GetMessage()),
-		Minor: c.Type == "feat",
-		Patch: c.Type == "fix",
+	c := ParseCommit(commit.GetSHA(), commit.Commit.GetMessage())
+	// the git author is credited, the committer of merges in the web interface is GitHub itself
+	c.Author = commit.Commit.GetAuthor().GetName()
+	c.AuthorLogin = commit.GetAuthor().GetLogin()
+	c.Committer = commit.Commit.GetCommitter().GetName()
+	c.Date = commit.Commit.GetCommitter().GetDate()
+	for _, parent := range commit.Parents {
+		c.Parents = append(c.Parents, parent.GetSHA())
+	}
+	if verification := commit.Commit.GetVerification(); verification != nil {
+		c.Verification = &Verification{Verified: verification.GetVerified(), Reason: verification.GetReason()}
 	}
 	return c
 }
@@ -2,13 +2,27 @@ package semrel
 
 import (
 	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
 	"encoding/json"
+	"encoding/pem"
+	"errors"
 	"fmt"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"os"
+	"path/filepath"
 	"regexp"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/Masterminds/semver"
 	"github.com/google/go-github/v30/github"
@@ -18,20 +32,33 @@ import (
 func TestNewGithubRepository(t *testing.T) {
 	require := require.New(t)
 
-	repo, err := NewGitHubRepository(context.TODO(), "", "", "")
+	repo, err := NewGitHubRepository(context.TODO(), "", "", "", "", "")
 	require.Nil(repo)
-	require.EqualError(err, "invalid slug")
+	require.EqualError(err, `invalid slug "": must be owner/repo`)
 
-	repo, err = NewGitHubRepository(context.TODO(), "", "owner/test-repo", "token")
+	repo, err = NewGitHubRepository(context.TODO(), "", "", "", "owner/test-repo", "token")
 	require.NotNil(repo)
 	require.NoError(err)
 	require.Equal("owner", repo.Owner())
 	require.Equal("test-repo", repo.Repo())
 
-	repo, err = NewGitHubRepository(context.TODO(), "github.enterprise", "owner/test-repo", "token")
+	repo, err = NewGitHubRepository(context.TODO(), "github.enterprise", "", "", "owner/test-repo", "token")
 	require.NotNil(repo)
 	require.NoError(err)
 	require.Equal("github.enterprise", repo.Client.BaseURL.Host)
+
+	repo, err = NewGitHubRepository(context.TODO(), "github.enterprise", "https://git.example.com/github/api", "https://git.example.com/github/uploads/", "owner/test-repo", "token")
+	require.NoError(err)
+	require.Equal("https://git.example.com/github/api/", repo.Client.BaseURL.String())
+	require.Equal("https://git.example.com/github/uploads/", repo.Client.UploadURL.String())
+
+	repo, err = NewGitHubRepository(context.TODO(), "", "http://git.example.com:8080/api", "", "owner/test-repo", "token")
+	require.NoError(err)
+	require.Equal("http://git.example.com:8080/api/", repo.Client.BaseURL.String())
+	require.Equal("http://git.example.com:8080/api/", repo.Client.UploadURL.String())
+
+	_, err = NewGitHubRepository(context.TODO(), "", "git.example.com/api", "", "owner/test-repo", "token")
+	require.EqualError(err, "invalid url: git.example.com/api")
 }
 
 func createGithubCommit(sha, message string) *github.RepositoryCommit {
@@ -39,11 +66,16 @@ func createGithubCommit(sha, message string) *github.RepositoryCommit {
 }
 
 var commitType = "commit"
+var tagType = "tag"
 
 func createGithubRef(ref, sha string) *github.Reference {
 	return &github.Reference{Ref: &ref, Object: &github.GitObject{SHA: &sha, Type: &commitType}}
 }
 
+func createGithubAnnotatedRef(ref, sha string) *github.Reference {
+	return &github.Reference{Ref: &ref, Object: &github.GitObject{SHA: &sha, Type: &tagType}}
+}
+
 var (
 	GITHUB_REPO_PRIVATE  = true
 	GITHUB_DEFAULTBRANCH = "master"
@@ -62,6 +94,9 @@ var (
 		createGithubRef("refs/tags/v3.0.0-beta.2", "deadbeef"),
 		createGithubRef("refs/tags/v3.0.0-beta.1", "deadbeef"),
 		createGithubRef("refs/tags/2020.04.19", "deadbeef"),
+		createGithubAnnotatedRef("refs/tags/v2.0.1-annotated", "cafebabe"),
+		createGithubRef("refs/tags/v4", "c0ffee"),
+		createGithubRef("refs/tags/1.2", "f00d"),
 	}
 )
 
@@ -83,6 +118,11 @@ func githubHandler(w http.ResponseWriter, r *http.Request) {
 		json.NewEncoder(w).Encode(GITHUB_TAGS)
 		return
 	}
+	if r.Method == "GET" && r.URL.Path == "/repos/owner/test-repo/git/tags/cafebabe" {
+		sha := "beefcafe"
+		json.NewEncoder(w).Encode(github.Tag{Object: &github.GitObject{SHA: &sha, Type: &commitType}})
+		return
+	}
 	if r.Method == "POST" && r.URL.Path == "/repos/owner/test-repo/git/refs" {
 		var data map[string]string
 		json.NewDecoder(r.Body).Decode(&data)
@@ -109,7 +149,7 @@ func githubHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 func getNewGithubTestRepo(t *testing.T) (*GitHubRepository, *httptest.Server) {
-	repo, err := NewGitHubRepository(context.TODO(), "", "owner/test-repo", "token")
+	repo, err := NewGitHubRepository(context.TODO(), "", "", "", "owner/test-repo", "token")
 	require.NoError(t, err)
 	ts := httptest.NewServer(http.HandlerFunc(githubHandler))
 	repo.Client.BaseURL, _ = url.Parse(ts.URL + "/")
@@ -128,7 +168,7 @@ func TestGithubGetInfo(t *testing.T) {
 func TestGithubGetCommits(t *testing.T) {
 	repo, ts := getNewGithubTestRepo(t)
 	defer ts.Close()
-	commits, err := repo.GetCommits("")
+	commits, err := repo.GetCommitsUntil("", "")
 	require.NoError(t, err)
 	require.Len(t, commits, 4)
 
@@ -140,6 +180,199 @@ func TestGithubGetCommits(t *testing.T) {
 	}
 }
 
+//nolint:errcheck
+func TestGithubGetCommitsPagination(t *testing.T) {
+	requestedPages := make([]string, 0)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page := r.URL.Query().Get("page")
+		requestedPages = append(requestedPages, page)
+		if page == "2" {
+			json.NewEncoder(w).Encode(GITHUB_COMMITS[2:])
+			return
+		}
+		w.Header().Set("Link", fmt.Sprintf(`<http://%s%s?page=2>; rel="next"`, r.Host, r.URL.Path))
+		json.NewEncoder(w).Encode(GITHUB_COMMITS[:2])
+	}))
+	defer ts.Close()
+	repo, err := NewGitHubRepository(context.TODO(), "", "", "", "owner/test-repo", "token")
+	require.NoError(t, err)
+	repo.Client.BaseURL, _ = url.Parse(ts.URL + "/")
+
+	commits, err := repo.GetCommitsUntil("", "")
+	require.NoError(t, err)
+	require.Len(t, commits, 4)
+	require.Equal(t, []string{"", "2"}, requestedPages)
+	require.True(t, compareCommit(commits[3], "chore", "", Change{true, false, false}), "commit of page 2 not parsed")
+
+	requestedPages = requestedPages[:0]
+	commits, err = repo.GetCommitsUntil("", "dcba")
+	require.NoError(t, err)
+	require.Len(t, commits, 2)
+	require.Equal(t, []string{""}, requestedPages)
+}
+
+//nolint:errcheck
+func TestGithubBaseURL(t *testing.T) {
+	var requestedPaths []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestedPaths = append(requestedPaths, r.URL.Path)
+		switch r.URL.Path {
+		case "/proxy/github/repos/owner/test-repo":
+			fmt.Fprint(w, `{"default_branch": "main", "private": true}`)
+		case "/proxy/github/repos/owner/test-repo/commits":
+			fmt.Fprint(w, `[{"sha": "abcd", "commit": {"message": "feat: search"}}]`)
+		default:
+			http.Error(w, "invalid route", http.StatusNotImplemented)
+		}
+	}))
+	defer ts.Close()
+
+	// the base url is used as is, without the /api/v3/ path of GitHub Enterprise
+	repo, err := NewGitHubRepository(context.TODO(), "", ts.URL+"/proxy/github", "", "owner/test-repo", "token")
+	require.NoError(t, err)
+	defaultBranch, isPrivate, err := repo.GetInfo()
+	require.NoError(t, err)
+	require.Equal(t, "main", defaultBranch)
+	require.True(t, isPrivate)
+	commits, err := repo.GetCommitsUntil("", "")
+	require.NoError(t, err)
+	require.Len(t, commits, 1)
+	require.Equal(t, []string{"/proxy/github/repos/owner/test-repo", "/proxy/github/repos/owner/test-repo/commits"}, requestedPaths)
+	require.Equal(t, ts.URL+"/proxy/github/", repo.Client.UploadURL.String())
+}
+
+func TestGithubGetCommitsOrder(t *testing.T) {
+	date := time.Date(2020, 5, 1, 12, 0, 0, 0, time.UTC)
+	createDatedCommit := func(sha string, date time.Time) *github.RepositoryCommit {
+		commit := createGithubCommit(sha, "fix: "+sha)
+		commit.Commit.Committer = &github.CommitAuthor{Date: &date}
+		return commit
+	}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("page") == "2" {
+			json.NewEncoder(w).Encode([]*github.RepositoryCommit{createDatedCommit("c", date.Add(-time.Hour)), createDatedCommit("release", date.Add(-3*time.Hour))})
+			return
+		}
+		w.Header().Set("Link", fmt.Sprintf(`<http://%s%s?page=2>; rel="next"`, r.Host, r.URL.Path))
+		json.NewEncoder(w).Encode([]*github.RepositoryCommit{createDatedCommit("head", date.Add(-4*time.Hour)), createDatedCommit("b", date.Add(-2*time.Hour)), createDatedCommit("a", date)})
+	}))
+	defer ts.Close()
+	repo, err := NewGitHubRepository(context.TODO(), "", "", "", "owner/test-repo", "token")
+	require.NoError(t, err)
+	repo.Client.BaseURL, _ = url.Parse(ts.URL + "/")
+
+	commits, err := repo.GetCommitsUntil("", "release")
+	require.NoError(t, err)
+	// the head stays first even with a skewed date as it is the released commit
+	require.Equal(t, []string{"head", "a", "c", "b", "release"}, commitSHAs(commits))
+	require.True(t, date.Equal(commits[1].Date))
+}
+
+func TestGithubGetCommitsUnknownSHA(t *testing.T) {
+	// GitHub answers 422 for a sha that does not exist in the repository
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("sha") == "unknown" {
+			http.Error(w, `{"message": "No commit found for SHA: unknown"}`, http.StatusUnprocessableEntity)
+			return
+		}
+		http.Error(w, "invalid route", http.StatusNotImplemented)
+	}))
+	defer ts.Close()
+	repo, err := NewGitHubRepository(context.TODO(), "", "", "", "owner/test-repo", "token")
+	require.NoError(t, err)
+	repo.Client.BaseURL, _ = url.Parse(ts.URL + "/")
+
+	commits, err := repo.GetCommitsUntil("unknown", "")
+	require.Error(t, err)
+	require.Nil(t, commits)
+}
+
+//nolint:errcheck
+func TestGithubGetCommitsVerification(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[
+			{"sha": "verified1234", "commit": {"message": "feat: search", "verification": {"verified": true, "reason": "valid"}}},
+			{"sha": "unsigned1234", "commit": {"message": "fix: typo", "verification": {"verified": false, "reason": "unsigned"}}},
+			{"sha": "bademail1234", "commit": {"message": "docs: readme", "verification": {"verified": false, "reason": "bad_email"}}},
+			{"sha": "release", "commit": {"message": "chore: release", "verification": {"verified": false, "reason": "unsigned"}}}
+		]`)
+	}))
+	defer ts.Close()
+	repo, err := NewGitHubRepository(context.TODO(), "", "", "", "owner/test-repo", "token")
+	require.NoError(t, err)
+	repo.Client.BaseURL, _ = url.Parse(ts.URL + "/")
+
+	commits, err := repo.GetCommitsUntil("", "release")
+	require.NoError(t, err)
+	require.Equal(t, &Verification{Verified: true, Reason: "valid"}, commits[0].Verification)
+	// the commit of the latest release is not checked
+	err = CheckCommitSignatures(commits, &Release{SHA: "release"})
+	require.EqualError(t, err, "2 commit(s) without a verified signature: unsigned (unsigned), bademail (bad_email)")
+	require.NoError(t, CheckCommitSignatures(commits[:1], &Release{SHA: "release"}))
+}
+
+func TestGithubGetCommitsSince(t *testing.T) {
+	date := time.Date(2020, 5, 1, 12, 0, 0, 0, time.UTC)
+	createDatedCommit := func(sha string, date time.Time) *github.RepositoryCommit {
+		commit := createGithubCommit(sha, "fix: "+sha)
+		commit.Commit.Committer = &github.CommitAuthor{Date: &date}
+		return commit
+	}
+	allCommits := []*github.RepositoryCommit{
+		createDatedCommit("a", date),
+		createDatedCommit("b", date.Add(-24*time.Hour)),
+		createDatedCommit("release", date.Add(-48*time.Hour)),
+		createDatedCommit("c", date.Add(-72*time.Hour)),
+	}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		commits := allCommits
+		if since := r.URL.Query().Get("since"); since != "" {
+			sinceTime, err := time.Parse(time.RFC3339, since)
+			require.NoError(t, err)
+			commits = make([]*github.RepositoryCommit, 0)
+			for _, commit := range allCommits {
+				if !commit.Commit.Committer.GetDate().Before(sinceTime) {
+					commits = append(commits, commit)
+				}
+			}
+		}
+		json.NewEncoder(w).Encode(commits)
+	}))
+	defer ts.Close()
+	repo, err := NewGitHubRepository(context.TODO(), "", "", "", "owner/test-repo", "token")
+	require.NoError(t, err)
+	repo.Client.BaseURL, _ = url.Parse(ts.URL + "/")
+
+	repo.Since = date.Add(-30 * time.Hour)
+	commits, err := repo.GetCommitsUntil("", "")
+	require.NoError(t, err)
+	require.Equal(t, []string{"a", "b"}, commitSHAs(commits))
+
+	// the previous release must not be cut off by the window
+	_, err = repo.GetCommitsUntil("", "release")
+	require.EqualError(t, err, "the commit release of the previous release is older than 2020-04-30T06:00:00Z, increase --since-duration")
+
+	repo.Since = date.Add(-50 * time.Hour)
+	commits, err = repo.GetCommitsUntil("", "release")
+	require.NoError(t, err)
+	require.Equal(t, []string{"a", "b", "release"}, commitSHAs(commits))
+}
+
+func TestGithubParseDeprecation(t *testing.T) {
+	commit := parseGithubCommit(createGithubCommit("abcd", "feat(api): new endpoint\n\nDEPRECATED: use /v2/users instead"))
+	require.Equal(t, "use /v2/users instead", commit.Deprecation)
+	require.True(t, compareCommit(commit, "feat", "api", Change{false, true, false}))
+}
+
+func TestGithubParseAuthorCommitter(t *testing.T) {
+	commit := createGithubCommit("abcd", "fix: bug")
+	commit.Commit.Author = &github.CommitAuthor{Name: github.String("Jane")}
+	commit.Commit.Committer = &github.CommitAuthor{Name: github.String("release-bot")}
+	c := parseGithubCommit(commit)
+	require.Equal(t, "Jane", c.Author)
+	require.Equal(t, "release-bot", c.Committer)
+}
+
 func TestGithubGetLatestRelease(t *testing.T) {
 	repo, ts := getNewGithubTestRepo(t)
 	defer ts.Close()
@@ -147,19 +380,23 @@ func TestGithubGetLatestRelease(t *testing.T) {
 	testCases := []struct {
 		vrange          string
 		re              *regexp.Regexp
+		coerce          bool
 		expectedSHA     string
 		expectedVersion string
 	}{
-		{"", nil, "deadbeef", "2020.4.19"},
-		{"", regexp.MustCompile("^v[0-9]*"), "deadbeef", "2.0.0"},
-		{"2-beta", nil, "deadbeef", "2.1.0-beta"},
-		{"3-beta", nil, "deadbeef", "3.0.0-beta.2"},
-		{"4-beta", nil, "deadbeef", "4.0.0-beta"},
+		{"", nil, false, "deadbeef", "2020.4.19"},
+		{"", regexp.MustCompile("^v[0-9]*"), false, "deadbeef", "2.0.0"},
+		{"2-beta", nil, false, "deadbeef", "2.1.0-beta"},
+		{"3-beta", nil, false, "deadbeef", "3.0.0-beta.2"},
+		{"4-beta", nil, false, "deadbeef", "4.0.0-beta"},
+		{"2.0.1-annotated", nil, false, "beefcafe", "2.0.1-annotated"},
+		{"", regexp.MustCompile("^v[0-9]*"), true, "c0ffee", "4.0.0"},
+		{"~1.2", nil, true, "f00d", "1.2.0"},
 	}
 
 	for _, tc := range testCases {
-		t.Run(fmt.Sprintf("VersionRange: %s, RE: %s", tc.vrange, tc.re), func(t *testing.T) {
-			release, err := repo.GetLatestRelease(tc.vrange, tc.re)
+		t.Run(fmt.Sprintf("VersionRange: %s, RE: %s, Coerce: %t", tc.vrange, tc.re, tc.coerce), func(t *testing.T) {
+			release, err := repo.GetLatestRelease(&GetLatestReleaseConfig{VersionRange: tc.vrange, Match: tc.re, CoerceVersions: tc.coerce})
 			require.NoError(t, err)
 			require.Equal(t, tc.expectedSHA, release.SHA)
 			require.Equal(t, tc.expectedVersion, release.Version.String())
@@ -167,10 +404,571 @@ func TestGithubGetLatestRelease(t *testing.T) {
 	}
 }
 
+//nolint:errcheck
+func TestGithubGetLatestReleaseResolvesSelectedTag(t *testing.T) {
+	tags := []*github.Reference{
+		createGithubAnnotatedRef("refs/tags/v1.0.0", "0ldtag"),
+		createGithubAnnotatedRef("refs/tags/v1.1.0", "newtag"),
+	}
+	resolved := make([]string, 0)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/owner/test-repo/git/refs/tags":
+			json.NewEncoder(w).Encode(tags)
+		case "/repos/owner/test-repo/git/tags/newtag":
+			resolved = append(resolved, "newtag")
+			sha := "beefcafe"
+			json.NewEncoder(w).Encode(github.Tag{Object: &github.GitObject{SHA: &sha, Type: &commitType}})
+		default:
+			// the tags of older releases are not resolved
+			http.Error(w, "invalid route", http.StatusNotImplemented)
+		}
+	}))
+	defer ts.Close()
+	repo, err := NewGitHubRepository(context.TODO(), "", "", "", "owner/test-repo", "token")
+	require.NoError(t, err)
+	repo.Client.BaseURL, _ = url.Parse(ts.URL + "/")
+
+	release, err := repo.GetLatestRelease(&GetLatestReleaseConfig{})
+	require.NoError(t, err)
+	require.Equal(t, "beefcafe", release.SHA)
+	require.Equal(t, "1.1.0", release.Version.String())
+	require.Equal(t, []string{"newtag"}, resolved)
+}
+
 func TestGithubCreateRelease(t *testing.T) {
 	repo, ts := getNewGithubTestRepo(t)
 	defer ts.Close()
 	newVersion := semver.MustParse("2.0.0")
-	err := repo.CreateRelease("", newVersion, false, "", "deadbeef")
+	err := repo.CreateRelease(&CreateReleaseConfig{NewVersion: newVersion, SHA: "deadbeef"})
+	require.NoError(t, err)
+}
+
+//nolint:errcheck
+func TestGithubCreateReleaseWithTimestamp(t *testing.T) {
+	var tagDate, refSHA string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "POST" && r.URL.Path == "/repos/owner/test-repo/git/tags":
+			var data struct {
+				Tagger struct {
+					Date string `json:"date"`
+				} `json:"tagger"`
+			}
+			json.NewDecoder(r.Body).Decode(&data)
+			tagDate = data.Tagger.Date
+			fmt.Fprint(w, `{"sha": "cafebabe"}`)
+		case r.Method == "POST" && r.URL.Path == "/repos/owner/test-repo/git/refs":
+			var data map[string]string
+			json.NewDecoder(r.Body).Decode(&data)
+			refSHA = data["sha"]
+			fmt.Fprint(w, "{}")
+		case r.Method == "POST" && r.URL.Path == "/repos/owner/test-repo/releases":
+			fmt.Fprint(w, "{}")
+		default:
+			http.Error(w, "invalid route", http.StatusNotImplemented)
+		}
+	}))
+	defer ts.Close()
+	repo, err := NewGitHubRepository(context.TODO(), "", "", "", "owner/test-repo", "token")
+	require.NoError(t, err)
+	repo.Client.BaseURL, _ = url.Parse(ts.URL + "/")
+
+	timestamp := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	err = repo.CreateRelease(&CreateReleaseConfig{NewVersion: semver.MustParse("2.0.0"), SHA: "deadbeef", Timestamp: timestamp})
+	require.NoError(t, err)
+	require.Equal(t, "2020-01-02T03:04:05Z", tagDate)
+	require.Equal(t, "cafebabe", refSHA)
+}
+
+//nolint:errcheck
+func TestGithubCreateReleaseMovingTags(t *testing.T) {
+	updatedRefs := make(map[string]string)
+	createdRefs := make(map[string]string)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var data map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&data)
+		switch {
+		case r.Method == "PATCH" && r.URL.Path == "/repos/owner/test-repo/git/refs/tags/app-v1":
+			updatedRefs["refs/tags/app-v1"] = data["sha"].(string)
+			require.Equal(t, true, data["force"])
+			fmt.Fprint(w, "{}")
+		case r.Method == "PATCH" && r.URL.Path == "/repos/owner/test-repo/git/refs/tags/app-v1.2":
+			http.Error(w, `{"message": "Reference does not exist"}`, http.StatusUnprocessableEntity)
+		case r.Method == "POST" && r.URL.Path == "/repos/owner/test-repo/git/refs":
+			createdRefs[data["ref"].(string)] = data["sha"].(string)
+			fmt.Fprint(w, "{}")
+		case r.Method == "POST" && r.URL.Path == "/repos/owner/test-repo/releases":
+			fmt.Fprint(w, "{}")
+		default:
+			http.Error(w, "invalid route", http.StatusNotImplemented)
+		}
+	}))
+	defer ts.Close()
+	repo, err := NewGitHubRepository(context.TODO(), "", "", "", "owner/test-repo", "token")
+	require.NoError(t, err)
+	repo.Client.BaseURL, _ = url.Parse(ts.URL + "/")
+
+	tagFormat, err := NewTagFormat(DefaultGitHubTagFormat, "app")
+	require.NoError(t, err)
+	err = repo.CreateRelease(&CreateReleaseConfig{NewVersion: semver.MustParse("1.2.3"), SHA: "deadbeef", MovingTags: []string{"major", "minor"}, TagFormat: tagFormat})
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"refs/tags/app-v1": "deadbeef"}, updatedRefs)
+	require.Equal(t, map[string]string{"refs/tags/app-v1.2.3": "deadbeef", "refs/tags/app-v1.2": "deadbeef"}, createdRefs)
+}
+
+//nolint:errcheck
+func TestGithubCreateReleaseDiscussionCategory(t *testing.T) {
+	var releaseData map[string]interface{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "POST" && r.URL.Path == "/graphql":
+			var data struct {
+				Variables map[string]string `json:"variables"`
+			}
+			json.NewDecoder(r.Body).Decode(&data)
+			require.Equal(t, map[string]string{"owner": "owner", "name": "test-repo"}, data.Variables)
+			fmt.Fprint(w, `{"data": {"repository": {"discussionCategories": {"nodes": [{"name": "General"}, {"name": "Announcements"}]}}}}`)
+		case r.Method == "POST" && r.URL.Path == "/repos/owner/test-repo/git/refs":
+			fmt.Fprint(w, "{}")
+		case r.Method == "POST" && r.URL.Path == "/repos/owner/test-repo/releases":
+			json.NewDecoder(r.Body).Decode(&releaseData)
+			fmt.Fprint(w, "{}")
+		default:
+			http.Error(w, "invalid route", http.StatusNotImplemented)
+		}
+	}))
+	defer ts.Close()
+	repo, err := NewGitHubRepository(context.TODO(), "", "", "", "owner/test-repo", "token")
+	require.NoError(t, err)
+	repo.Client.BaseURL, _ = url.Parse(ts.URL + "/")
+
+	err = repo.CreateRelease(&CreateReleaseConfig{NewVersion: semver.MustParse("2.0.0"), SHA: "deadbeef", DiscussionCategory: "Announcements"})
+	require.NoError(t, err)
+	require.Equal(t, "v2.0.0", releaseData["tag_name"])
+	require.Equal(t, "Announcements", releaseData["discussion_category_name"])
+
+	releaseData = nil
+	err = repo.CreateRelease(&CreateReleaseConfig{NewVersion: semver.MustParse("2.0.0"), SHA: "deadbeef", DiscussionCategory: "Releases"})
+	require.EqualError(t, err, `discussion category "Releases" does not exist in owner/test-repo`)
+	require.Nil(t, releaseData)
+
+	err = repo.CreateRelease(&CreateReleaseConfig{NewVersion: semver.MustParse("2.0.0"), SHA: "deadbeef"})
+	require.NoError(t, err)
+	require.NotContains(t, releaseData, "discussion_category_name")
+}
+
+func TestGithubGraphQLURL(t *testing.T) {
+	repo, err := NewGitHubRepository(context.TODO(), "", "", "", "owner/test-repo", "token")
+	require.NoError(t, err)
+	require.Equal(t, "graphql", repo.graphQLURL())
+
+	repo, err = NewGitHubRepository(context.TODO(), "github.enterprise", "", "", "owner/test-repo", "token")
+	require.NoError(t, err)
+	require.Equal(t, "https://github.enterprise/api/graphql", repo.graphQLURL())
+}
+
+//nolint:errcheck
+func TestGithubCreateReleaseMakeLatest(t *testing.T) {
+	var releaseData map[string]interface{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "POST" && r.URL.Path == "/repos/owner/test-repo/git/refs":
+			fmt.Fprint(w, "{}")
+		case r.Method == "POST" && r.URL.Path == "/repos/owner/test-repo/releases":
+			json.NewDecoder(r.Body).Decode(&releaseData)
+			fmt.Fprint(w, "{}")
+		default:
+			http.Error(w, "invalid route", http.StatusNotImplemented)
+		}
+	}))
+	defer ts.Close()
+	repo, err := NewGitHubRepository(context.TODO(), "", "", "", "owner/test-repo", "token")
+	require.NoError(t, err)
+	repo.Client.BaseURL, _ = url.Parse(ts.URL + "/")
+
+	// maintenance release of an older major
+	makeLatest := ResolveMakeLatest("auto", semver.MustParse("1.5.1"), &Release{SHA: "a", Version: semver.MustParse("2.0.0")})
+	err = repo.CreateRelease(&CreateReleaseConfig{NewVersion: semver.MustParse("1.5.1"), SHA: "deadbeef", MakeLatest: makeLatest})
+	require.NoError(t, err)
+	require.Equal(t, "false", releaseData["make_latest"])
+}
+
+//nolint:errcheck
+func TestGithubCreateReleaseGeneratedNotes(t *testing.T) {
+	var releaseData map[string]interface{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "POST" && r.URL.Path == "/repos/owner/test-repo/git/refs":
+			fmt.Fprint(w, "{}")
+		case r.Method == "POST" && r.URL.Path == "/repos/owner/test-repo/releases":
+			releaseData = nil
+			json.NewDecoder(r.Body).Decode(&releaseData)
+			fmt.Fprint(w, "{}")
+		default:
+			http.Error(w, "invalid route", http.StatusNotImplemented)
+		}
+	}))
+	defer ts.Close()
+	repo, err := NewGitHubRepository(context.TODO(), "", "", "", "owner/test-repo", "token")
+	require.NoError(t, err)
+	repo.Client.BaseURL, _ = url.Parse(ts.URL + "/")
+
+	err = repo.CreateRelease(&CreateReleaseConfig{NewVersion: semver.MustParse("1.1.0"), SHA: "deadbeef", Changelog: "changelog", GenerateReleaseNotes: true})
+	require.NoError(t, err)
+	require.Equal(t, true, releaseData["generate_release_notes"])
+	require.Equal(t, "changelog", releaseData["body"])
+
+	err = repo.CreateRelease(&CreateReleaseConfig{NewVersion: semver.MustParse("1.1.0"), SHA: "deadbeef", Changelog: "changelog"})
+	require.NoError(t, err)
+	_, found := releaseData["generate_release_notes"]
+	require.False(t, found)
+}
+
+//nolint:errcheck
+func TestGithubCreateReleaseExistingTag(t *testing.T) {
+	tagSHA := "deadbeef"
+	var releaseCreated, releaseEdited bool
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "POST" && r.URL.Path == "/repos/owner/test-repo/git/refs":
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			fmt.Fprint(w, `{"message": "Reference already exists"}`)
+		case r.Method == "GET" && (r.URL.Path == "/repos/owner/test-repo/git/ref/tags/v2.0.0" || r.URL.Path == "/repos/owner/test-repo/git/refs/tags/v2.0.0"):
+			json.NewEncoder(w).Encode(createGithubRef("refs/tags/v2.0.0", tagSHA))
+		case r.Method == "POST" && r.URL.Path == "/repos/owner/test-repo/releases":
+			if releaseCreated {
+				w.WriteHeader(http.StatusUnprocessableEntity)
+				fmt.Fprint(w, `{"message": "Validation Failed", "errors": [{"code": "already_exists"}]}`)
+				return
+			}
+			releaseCreated = true
+			fmt.Fprint(w, "{}")
+		case r.Method == "GET" && r.URL.Path == "/repos/owner/test-repo/releases/tags/v2.0.0":
+			fmt.Fprint(w, `{"id": 1, "tag_name": "v2.0.0"}`)
+		case r.Method == "PATCH" && r.URL.Path == "/repos/owner/test-repo/releases/1":
+			releaseEdited = true
+			fmt.Fprint(w, `{"id": 1}`)
+		default:
+			http.Error(w, "invalid route", http.StatusNotImplemented)
+		}
+	}))
+	defer ts.Close()
+	repo, err := NewGitHubRepository(context.TODO(), "", "", "", "owner/test-repo", "token")
+	require.NoError(t, err)
+	repo.Client.BaseURL, _ = url.Parse(ts.URL + "/")
+
+	// the tag exists from a previous run which failed before creating the release
+	err = repo.CreateRelease(&CreateReleaseConfig{NewVersion: semver.MustParse("2.0.0"), SHA: "deadbeef"})
+	require.NoError(t, err)
+	require.True(t, releaseCreated)
+	require.False(t, releaseEdited)
+
+	// re-run of a completed release
+	err = repo.CreateRelease(&CreateReleaseConfig{NewVersion: semver.MustParse("2.0.0"), SHA: "deadbeef"})
+	require.NoError(t, err)
+	require.True(t, releaseEdited)
+
+	tagSHA = "cafebabe"
+	err = repo.CreateRelease(&CreateReleaseConfig{NewVersion: semver.MustParse("2.0.0"), SHA: "deadbeef"})
+	require.EqualError(t, err, "tag v2.0.0 already exists and does not point to deadbeef")
+}
+
+//nolint:errcheck
+func TestGithubCreateReleaseSigned(t *testing.T) {
+	var tagMessage, refSHA string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "POST" && r.URL.Path == "/repos/owner/test-repo/git/tags":
+			var data map[string]interface{}
+			json.NewDecoder(r.Body).Decode(&data)
+			tagMessage = data["message"].(string)
+			fmt.Fprint(w, `{"sha": "cafebabe"}`)
+		case r.Method == "POST" && r.URL.Path == "/repos/owner/test-repo/git/refs":
+			var data map[string]string
+			json.NewDecoder(r.Body).Decode(&data)
+			refSHA = data["sha"]
+			fmt.Fprint(w, "{}")
+		case r.Method == "POST" && r.URL.Path == "/repos/owner/test-repo/releases":
+			fmt.Fprint(w, "{}")
+		default:
+			http.Error(w, "invalid route", http.StatusNotImplemented)
+		}
+	}))
+	defer ts.Close()
+	repo, err := NewGitHubRepository(context.TODO(), "", "", "", "owner/test-repo", "token")
+	require.NoError(t, err)
+	repo.Client.BaseURL, _ = url.Parse(ts.URL + "/")
+
+	var signedPayload string
+	signer := func(payload []byte) ([]byte, error) {
+		signedPayload = string(payload)
+		return []byte("-----BEGIN PGP SIGNATURE-----\nsignature\n-----END PGP SIGNATURE-----\n"), nil
+	}
+	timestamp := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	err = repo.CreateRelease(&CreateReleaseConfig{NewVersion: semver.MustParse("2.0.0"), SHA: "deadbeef", Timestamp: timestamp, Signer: signer})
+	require.NoError(t, err)
+	require.Equal(t, string(tagPayload("v2.0.0", "deadbeef", "v2.0.0\n", timestamp)), signedPayload)
+	require.Equal(t, "v2.0.0\n-----BEGIN PGP SIGNATURE-----\nsignature\n-----END PGP SIGNATURE-----\n", tagMessage)
+	require.Equal(t, "cafebabe", refSHA)
+
+	signer = func([]byte) ([]byte, error) {
+		return nil, errors.New("no secret key")
+	}
+	err = repo.CreateRelease(&CreateReleaseConfig{NewVersion: semver.MustParse("2.0.0"), SHA: "deadbeef", Signer: signer})
+	require.EqualError(t, err, "no secret key")
+}
+
+func TestGithubUploadReleaseAssets(t *testing.T) {
+	dir := writeUploadFiles(t, map[string]string{"app.tar.gz": "app"})
+	defer os.RemoveAll(dir)
+
+	uploaded := make(map[string]string)
+	var deleted []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/repos/owner/test-repo/releases/tags/v2.0.0":
+			fmt.Fprint(w, `{"id": 1, "tag_name": "v2.0.0"}`)
+		case r.Method == "GET" && r.URL.Path == "/repos/owner/test-repo/releases/1/assets":
+			fmt.Fprint(w, `[{"id": 7, "name": "app.tar.gz"}, {"id": 8, "name": "other.zip"}]`)
+		case r.Method == "DELETE" && strings.HasPrefix(r.URL.Path, "/repos/owner/test-repo/releases/assets/"):
+			deleted = append(deleted, strings.TrimPrefix(r.URL.Path, "/repos/owner/test-repo/releases/assets/"))
+			w.WriteHeader(http.StatusNoContent)
+		case r.Method == "POST" && r.URL.Path == "/repos/owner/test-repo/releases/1/assets":
+			body, _ := ioutil.ReadAll(r.Body)
+			uploaded[r.URL.Query().Get("name")] = string(body)
+			fmt.Fprint(w, `{"id": 9}`)
+		default:
+			http.Error(w, "invalid route", http.StatusNotImplemented)
+		}
+	}))
+	defer ts.Close()
+	repo, err := NewGitHubRepository(context.TODO(), "", "", "", "owner/test-repo", "token")
+	require.NoError(t, err)
+	repo.Client.BaseURL, _ = url.Parse(ts.URL + "/")
+	repo.Client.UploadURL, _ = url.Parse(ts.URL + "/")
+
+	err = repo.UploadReleaseAssets("v2.0.0", []string{filepath.Join(dir, "app.tar.gz")}, true)
+	require.NoError(t, err)
+	require.Equal(t, []string{"7"}, deleted)
+	require.Equal(t, map[string]string{
+		"app.tar.gz": "app",
+		"SHA256SUMS": "a172cedcae47474b615c54d510a5d84a8dea3032e958587430b413538be3f333  app.tar.gz\n",
+	}, uploaded)
+}
+
+func TestGithubGetCommitsListFiles(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/owner/test-repo/commits":
+			json.NewEncoder(w).Encode([]*github.RepositoryCommit{
+				createGithubCommit("abcd", "feat(api): search"),
+				createGithubCommit("release", "chore: release"),
+			})
+		case "/repos/owner/test-repo/commits/abcd":
+			fmt.Fprint(w, `{"sha": "abcd", "files": [{"filename": "services/api/search.go"}, {"filename": "services/api/b.go", "previous_filename": "services/web/a.go"}]}`)
+		default:
+			http.Error(w, "invalid route", http.StatusNotImplemented)
+		}
+	}))
+	defer ts.Close()
+	repo, err := NewGitHubRepository(context.TODO(), "", "", "", "owner/test-repo", "token")
+	require.NoError(t, err)
+	repo.Client.BaseURL, _ = url.Parse(ts.URL + "/")
+
+	commits, err := repo.GetCommitsUntil("", "release")
+	require.NoError(t, err)
+	require.Nil(t, commits[0].Files)
+
+	repo.ListFiles = true
+	commits, err = repo.GetCommitsUntil("", "release")
+	require.NoError(t, err)
+	require.Len(t, commits, 2)
+	require.Equal(t, []string{"services/api/search.go", "services/api/b.go", "services/web/a.go"}, commits[0].Files)
+	require.Nil(t, commits[1].Files, "the files of the release commit are not needed")
+}
+
+func rateLimitedGithubHandler(reset time.Time, requests *int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		*requests++
+		w.Header().Set("X-RateLimit-Limit", "60")
+		w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(reset.Unix(), 10))
+		if *requests == 1 {
+			w.Header().Set("X-RateLimit-Remaining", "0")
+			w.WriteHeader(http.StatusForbidden)
+			fmt.Fprint(w, `{"message": "API rate limit exceeded for 127.0.0.1."}`)
+			return
+		}
+		w.Header().Set("X-RateLimit-Remaining", "59")
+		json.NewEncoder(w).Encode(GITHUB_REPO)
+	}
+}
+
+func TestGithubRateLimit(t *testing.T) {
+	requests := 0
+	reset := time.Now().Add(30 * time.Minute)
+	ts := httptest.NewServer(rateLimitedGithubHandler(reset, &requests))
+	defer ts.Close()
+	repo, err := NewGitHubRepository(context.TODO(), "", "", "", "owner/test-repo", "token")
+	require.NoError(t, err)
+	repo.Client.BaseURL, _ = url.Parse(ts.URL + "/")
+
+	_, _, err = repo.GetInfo()
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "GitHub API rate limit of 60 requests exceeded, it resets at "+reset.UTC().Format(time.RFC3339))
+	require.Contains(t, err.Error(), "--wait-for-rate-limit")
+	var rateErr *github.RateLimitError
+	require.True(t, errors.As(err, &rateErr))
+	require.Equal(t, 1, requests)
+}
+
+func TestGithubWaitForRateLimit(t *testing.T) {
+	var waited time.Duration
+	defer func(sleep func(*http.Request, time.Duration) error) { sleepRequest = sleep }(sleepRequest)
+	sleepRequest = func(req *http.Request, d time.Duration) error {
+		waited += d
+		return nil
+	}
+
+	requests := 0
+	ts := httptest.NewServer(rateLimitedGithubHandler(time.Now().Add(time.Minute), &requests))
+	defer ts.Close()
+	repo, err := NewGitHubRepository(context.TODO(), "", "", "", "owner/test-repo", "token")
+	require.NoError(t, err)
+	repo.Client.BaseURL, _ = url.Parse(ts.URL + "/")
+	repo.WaitForRateLimit = true
+
+	defaultBranch, _, err := repo.GetInfo()
+	require.NoError(t, err)
+	require.Equal(t, "master", defaultBranch)
+	require.Equal(t, 2, requests)
+	require.True(t, waited > 55*time.Second && waited <= 61*time.Second, "waited %s", waited)
+}
+
+func TestGithubTagCache(t *testing.T) {
+	dir, err := ioutil.TempDir("", "semrel-cache")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	etag := `"v1"`
+	tags := []*github.Reference{createGithubRef("refs/tags/v1.0.0", "deadbeef")}
+	var listed, notModified int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/repos/owner/test-repo/git/refs/tags" {
+			http.Error(w, "invalid route", http.StatusNotImplemented)
+			return
+		}
+		if r.Header.Get("If-None-Match") == etag {
+			notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		listed++
+		w.Header().Set("ETag", etag)
+		json.NewEncoder(w).Encode(tags)
+	}))
+	defer ts.Close()
+	repo, err := NewGitHubRepository(context.TODO(), "", "", "", "owner/test-repo", "token")
+	require.NoError(t, err)
+	repo.Client.BaseURL, _ = url.Parse(ts.URL + "/")
+	repo.CacheDir = dir
+
+	release, err := repo.GetLatestRelease(&GetLatestReleaseConfig{})
+	require.NoError(t, err)
+	require.Equal(t, "1.0.0", release.Version.String())
+	require.Equal(t, 1, listed)
+
+	// unchanged tags are answered from the cache
+	release, err = repo.GetLatestRelease(&GetLatestReleaseConfig{})
+	require.NoError(t, err)
+	require.Equal(t, "1.0.0", release.Version.String())
+	require.Equal(t, 1, listed)
+	require.Equal(t, 1, notModified)
+
+	// a new tag changes the ETag and replaces the cached listing
+	etag = `"v2"`
+	tags = append(tags, createGithubRef("refs/tags/v1.1.0", "cafebabe"))
+	release, err = repo.GetLatestRelease(&GetLatestReleaseConfig{})
+	require.NoError(t, err)
+	require.Equal(t, "1.1.0", release.Version.String())
+	require.Equal(t, 2, listed)
+
+	release, err = repo.GetLatestRelease(&GetLatestReleaseConfig{})
+	require.NoError(t, err)
+	require.Equal(t, "1.1.0", release.Version.String())
+	require.Equal(t, 2, listed)
+	require.Equal(t, 2, notModified)
+
+	// without a cache directory every listing is a full request
+	repo.CacheDir = ""
+	_, err = repo.GetLatestRelease(&GetLatestReleaseConfig{})
+	require.NoError(t, err)
+	require.Equal(t, 3, listed)
+}
+
+func TestGithubAppAuth(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	privateKey := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+
+	tokens := 0
+	tokenLifetime := time.Hour
+	revoked := make(map[string]bool)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "POST" && r.URL.Path == "/app/installations/42/access_tokens" {
+			jwt := strings.Split(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "), ".")
+			require.Len(t, jwt, 3)
+			hash := sha256.Sum256([]byte(jwt[0] + "." + jwt[1]))
+			signature, _ := base64.RawURLEncoding.DecodeString(jwt[2])
+			require.NoError(t, rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, hash[:], signature))
+			claims, _ := base64.RawURLEncoding.DecodeString(jwt[1])
+			var jwtClaims struct{ Iss, Iat, Exp int64 }
+			require.NoError(t, json.Unmarshal(claims, &jwtClaims))
+			require.Equal(t, int64(7), jwtClaims.Iss)
+			require.LessOrEqual(t, jwtClaims.Exp-jwtClaims.Iat, int64(600))
+
+			tokens++
+			w.WriteHeader(http.StatusCreated)
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"token":      fmt.Sprintf("ghs_%d", tokens),
+				"expires_at": time.Now().Add(tokenLifetime),
+			})
+			return
+		}
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "token ")
+		if token == "" || revoked[token] {
+			http.Error(w, `{"message": "Bad credentials"}`, http.StatusUnauthorized)
+			return
+		}
+		r = r.Clone(r.Context())
+		r.Header.Set("Authorization", "Bearer token")
+		githubHandler(w, r)
+	}))
+	defer ts.Close()
+
+	_, err = NewGitHubAppRepository(context.TODO(), "", ts.URL, "", "owner/test-repo", &GitHubApp{ID: 7, InstallationID: 42, PrivateKey: []byte("key")})
+	require.EqualError(t, err, "invalid GitHub App private key: no PEM data found")
+
+	repo, err := NewGitHubAppRepository(context.TODO(), "", ts.URL, "", "owner/test-repo", &GitHubApp{ID: 7, InstallationID: 42, PrivateKey: privateKey})
+	require.NoError(t, err)
+	for i := 0; i < 2; i++ {
+		_, _, err = repo.GetInfo()
+		require.NoError(t, err)
+	}
+	require.Equal(t, 1, tokens, "token not reused")
+
+	// a revoked token is replaced once
+	revoked["ghs_1"] = true
+	_, _, err = repo.GetInfo()
+	require.NoError(t, err)
+	require.Equal(t, 2, tokens)
+
+	// tokens expiring within a minute are renewed
+	tokenLifetime = 30 * time.Second
+	revoked["ghs_2"] = true
+	_, _, err = repo.GetInfo()
+	require.NoError(t, err)
+	_, _, err = repo.GetInfo()
 	require.NoError(t, err)
+	require.Equal(t, 4, tokens)
 }
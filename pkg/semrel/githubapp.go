@@ -0,0 +1,154 @@
+package semrel
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+)
+
+// GitHubApp identifies the installation of a GitHub App, PrivateKey is the PEM encoded private key of the app
+type GitHubApp struct {
+	ID             int64
+	InstallationID int64
+	PrivateKey     []byte
+}
+
+// githubAppTransport authenticates the requests with an installation token of a GitHub App. The token is
+// created with a JWT signed by the private key of the app, it is renewed shortly before it expires and
+// once if a request is rejected with a 401.
+type githubAppTransport struct {
+	base    http.RoundTripper
+	repo    *GitHubRepository
+	app     *GitHubApp
+	key     *rsa.PrivateKey
+	token   string
+	expires time.Time
+}
+
+func parseGitHubAppKey(data []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("invalid GitHub App private key: no PEM data found")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid GitHub App private key: %w", err)
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("invalid GitHub App private key: not an RSA key")
+	}
+	return rsaKey, nil
+}
+
+// appJWT returns the JWT authenticating as the app, GitHub accepts a lifetime of at most 10 minutes and
+// the issue time is backdated to allow for clock drift
+func (t *githubAppTransport) appJWT(now time.Time) (string, error) {
+	claims, err := json.Marshal(struct {
+		IssuedAt  int64 `json:"iat"`
+		ExpiresAt int64 `json:"exp"`
+		Issuer    int64 `json:"iss"`
+	}{now.Add(-time.Minute).Unix(), now.Add(9 * time.Minute).Unix(), t.app.ID})
+	if err != nil {
+		return "", err
+	}
+	signed := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"RS256","typ":"JWT"}`)) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	hash := sha256.Sum256([]byte(signed))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, t.key, crypto.SHA256, hash[:])
+	if err != nil {
+		return "", err
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+func (t *githubAppTransport) createInstallationToken(ctx context.Context) error {
+	jwt, err := t.appJWT(time.Now())
+	if err != nil {
+		return err
+	}
+	// the base URL is read at request time as it is set after the transport is created
+	u, err := t.repo.Client.BaseURL.Parse(fmt.Sprintf("app/installations/%d/access_tokens", t.app.InstallationID))
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+jwt)
+	req.Header.Set("Accept", "application/vnd.github.machine-man-preview+json")
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("could not create GitHub App installation token: %s: %s", resp.Status, bytes.TrimSpace(body))
+	}
+	var token struct {
+		Token     string    `json:"token"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return fmt.Errorf("could not read GitHub App installation token: %w", err)
+	}
+	t.token, t.expires = token.Token, token.ExpiresAt
+	return nil
+}
+
+// installationToken returns the current token, a new one is created if it expires within a minute or if it
+// is the rejected token
+func (t *githubAppTransport) installationToken(ctx context.Context, rejected string) (string, error) {
+	if t.token == "" || t.token == rejected || time.Until(t.expires) < time.Minute {
+		if err := t.createInstallationToken(ctx); err != nil {
+			return "", err
+		}
+	}
+	return t.token, nil
+}
+
+func authorizeRequest(req *http.Request, token string) *http.Request {
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "token "+token)
+	return req
+}
+
+func (t *githubAppTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	token, err := t.installationToken(req.Context(), "")
+	if err != nil {
+		return nil, err
+	}
+	resp, err := t.base.RoundTrip(authorizeRequest(req, token))
+	// requests with a body that can not be read again (e.g. uploads of files) are not retried
+	if err != nil || resp.StatusCode != http.StatusUnauthorized || (req.Body != nil && req.GetBody == nil) {
+		return resp, err
+	}
+	retry := req.Clone(req.Context())
+	if req.GetBody != nil {
+		if retry.Body, err = req.GetBody(); err != nil {
+			return resp, nil
+		}
+	}
+	if token, err = t.installationToken(req.Context(), token); err != nil {
+		return resp, nil
+	}
+	resp.Body.Close()
+	return t.base.RoundTrip(authorizeRequest(retry, token))
+}
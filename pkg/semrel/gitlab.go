@@ -3,10 +3,9 @@ package semrel
 import (
 	"context"
 	"fmt"
-	"regexp"
 	"strings"
+	"time"
 
-	"github.com/Masterminds/semver"
 	gitlab "github.com/xanzy/go-gitlab"
 )
 
@@ -17,8 +16,16 @@ type GitLabRepository struct {
 	branch    string
 	Ctx       context.Context
 	client    *gitlab.Client
+	// UseMergeRequestCommits replaces the squash commits of merge requests with the commits of the merge request
+	UseMergeRequestCommits bool
+	// ListFiles fetches the changed files of every commit, this costs one request per commit
+	ListFiles bool
+	// Since only lists the commits after the time, the commit of the previous release must be within the window
+	Since time.Time
 }
 
+// NewGitLabRepository creates a GitLab client, the base url may contain the path of instances installed
+// in a sub path (e.g. https://host/gitlab), /api/v4 is appended if it is missing
 func NewGitLabRepository(ctx context.Context, gitlabBaseUrl, slug, token, branch string, projectID string) (*GitLabRepository, error) {
 	if projectID == "" {
 		return nil, fmt.Errorf("project id is required")
@@ -29,10 +36,14 @@ func NewGitLabRepository(ctx context.Context, gitlabBaseUrl, slug, token, branch
 	repo.Ctx = ctx
 	repo.branch = branch
 
+	// the project id identifies the project, the slug is optional and may contain subgroups (e.g. group/subgroup/project)
 	if strings.Contains(slug, "/") {
-		split := strings.Split(slug, "/")
-		repo.owner = split[0]
-		repo.repo = split[1]
+		idx := strings.LastIndex(slug, "/")
+		if idx == 0 || idx == len(slug)-1 || strings.Contains(slug, "//") {
+			return nil, fmt.Errorf("invalid slug %q: must be group/project", slug)
+		}
+		repo.owner = slug[:idx]
+		repo.repo = slug[idx+1:]
 	}
 
 	var (
@@ -41,9 +52,9 @@ func NewGitLabRepository(ctx context.Context, gitlabBaseUrl, slug, token, branch
 	)
 
 	if gitlabBaseUrl != "" {
-		client, err = gitlab.NewClient(token, gitlab.WithBaseURL(gitlabBaseUrl))
+		client, err = gitlab.NewClient(token, gitlab.WithBaseURL(gitlabBaseUrl), gitlab.WithHTTPClient(httpClient(ctx)))
 	} else {
-		client, err = gitlab.NewClient(token)
+		client, err = gitlab.NewClient(token, gitlab.WithHTTPClient(httpClient(ctx)))
 	}
 
 	if err != nil {
@@ -56,16 +67,67 @@ func NewGitLabRepository(ctx context.Context, gitlabBaseUrl, slug, token, branch
 }
 
 func (repo *GitLabRepository) GetInfo() (string, bool, error) {
-	project, _, err := repo.client.Projects.GetProject(repo.projectID, nil)
-
+	project, resp, err := repo.client.Projects.GetProject(repo.projectID, nil)
+	if resp != nil && resp.StatusCode == 404 {
+		return "", false, fmt.Errorf("%w: %v", ErrRepoNotFound, err)
+	}
 	if err != nil {
 		return "", false, err
 	}
 
-	return project.DefaultBranch, project.Visibility == gitlab.PrivateVisibility, nil
+	// internal projects are only visible to signed in users and are handled like private ones
+	return project.DefaultBranch, project.Visibility != gitlab.PublicVisibility, nil
 }
 
+// GetCommits returns all commits reachable from sha, newest first
 func (repo *GitLabRepository) GetCommits(sha string) ([]*Commit, error) {
+	return repo.GetCommitsUntil(sha, "")
+}
+
+// GetCommitsUntil returns the commits reachable from sha, newest first, stopping after the commit releaseSHA
+func (repo *GitLabRepository) GetCommitsUntil(sha, releaseSHA string) ([]*Commit, error) {
+	commits, err := repo.listCommits(sha, releaseSHA)
+	if err != nil {
+		return nil, err
+	}
+	sortCommits(commits, releaseSHA)
+	if !repo.ListFiles {
+		return commits, nil
+	}
+	for _, commit := range commits {
+		if commit.SHA == releaseSHA {
+			continue
+		}
+		if commit.Files, err = repo.commitFiles(commit.SHA); err != nil {
+			return nil, err
+		}
+	}
+	return commits, nil
+}
+
+func (repo *GitLabRepository) commitFiles(sha string) ([]string, error) {
+	files := make([]string, 0)
+	opts := &gitlab.GetCommitDiffOptions{Page: 1, PerPage: 100}
+	for {
+		diffs, resp, err := repo.client.Commits.GetCommitDiff(repo.projectID, sha, opts)
+		if err != nil {
+			return nil, err
+		}
+		for _, diff := range diffs {
+			files = append(files, diff.NewPath)
+			if diff.RenamedFile {
+				files = append(files, diff.OldPath)
+			}
+		}
+		if resp.CurrentPage >= resp.TotalPages {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	return files, nil
+}
+
+func (repo *GitLabRepository) listCommits(sha, releaseSHA string) ([]*Commit, error) {
 	opts := &gitlab.ListCommitsOptions{
 		ListOptions: gitlab.ListOptions{
 			Page:    1,
@@ -74,8 +136,12 @@ func (repo *GitLabRepository) GetCommits(sha string) ([]*Commit, error) {
 		RefName: gitlab.String(fmt.Sprintf("%s...%s", repo.branch, sha)),
 		All:     gitlab.Bool(true),
 	}
+	if !repo.Since.IsZero() {
+		opts.Since = &repo.Since
+	}
 
 	allCommits := make([]*Commit, 0)
+	seenMergeRequests := make(map[int]bool)
 
 	for {
 		commits, resp, err := repo.client.Commits.ListCommits(repo.projectID, opts)
@@ -85,6 +151,19 @@ func (repo *GitLabRepository) GetCommits(sha string) ([]*Commit, error) {
 		}
 
 		for _, commit := range commits {
+			if releaseSHA != "" && commit.ID == releaseSHA {
+				return append(allCommits, parseGitlabCommit(commit)), nil
+			}
+			if repo.UseMergeRequestCommits {
+				mrCommits, err := repo.mergeRequestCommits(commit.ID, seenMergeRequests)
+				if err != nil {
+					return nil, err
+				}
+				if mrCommits != nil {
+					allCommits = append(allCommits, mrCommits...)
+					continue
+				}
+			}
 			allCommits = append(allCommits, parseGitlabCommit(commit))
 		}
 
@@ -95,10 +174,13 @@ func (repo *GitLabRepository) GetCommits(sha string) ([]*Commit, error) {
 		opts.Page = resp.NextPage
 	}
 
+	if releaseSHA != "" && !repo.Since.IsZero() {
+		return nil, errReleaseOutsideWindow(releaseSHA, repo.Since)
+	}
 	return allCommits, nil
 }
 
-func (repo *GitLabRepository) GetLatestRelease(vrange string, re *regexp.Regexp) (*Release, error) {
+func (repo *GitLabRepository) GetLatestRelease(latest *GetLatestReleaseConfig) (*Release, error) {
 	allReleases := make(Releases, 0)
 
 	opts := &gitlab.ListTagsOptions{
@@ -113,13 +195,20 @@ func (repo *GitLabRepository) GetLatestRelease(vrange string, re *regexp.Regexp)
 		if err != nil {
 			return nil, err
 		}
+		if len(tags) == 0 && opts.Page == 1 {
+			return nil, ErrNoReleases
+		}
 
 		for _, tag := range tags {
-			if re != nil && !re.MatchString(tag.Name) {
+			if latest.Match != nil && !latest.Match.MatchString(tag.Name) {
 				continue
 			}
 
-			version, err := semver.NewVersion(tag.Name)
+			tagVersion, ok := latest.TagFormat.Version(tag.Name)
+			if !ok {
+				continue
+			}
+			version, err := parseTagVersion(tagVersion, latest.CoerceVersions)
 			if err != nil {
 				continue
 			}
@@ -137,39 +226,117 @@ func (repo *GitLabRepository) GetLatestRelease(vrange string, re *regexp.Regexp)
 		opts.Page = resp.NextPage
 	}
 
-	return allReleases.GetLatestRelease(vrange)
+	return latest.selectRelease(allReleases)
 }
 
-func (repo *GitLabRepository) CreateRelease(changelog string, newVersion *semver.Version, prerelease bool, branch, sha string) error {
-	tag := fmt.Sprintf("v%s", newVersion.String())
+func (repo *GitLabRepository) CreateRelease(release *CreateReleaseConfig) error {
+	tag := release.TagFormat.Format(release.NewVersion.String())
+	name := tag
+	if release.Title != "" {
+		name = release.Title
+	}
+
+	opts := &gitlab.CreateReleaseOptions{
+		Name:    &name,
+		TagName: &tag,
+		Ref:     &release.SHA,
+		// TODO: this may been to be wrapped in ```
+		Description: &release.Changelog,
+	}
+	if len(release.Assets) > 0 {
+		links := make([]*gitlab.ReleaseAssetLink, 0, len(release.Assets))
+		for _, asset := range release.Assets {
+			links = append(links, &gitlab.ReleaseAssetLink{Name: asset.Name, URL: asset.URL})
+		}
+		opts.Assets = &gitlab.ReleaseAssets{Links: links}
+	}
 
 	// Gitlab does not have any notion of pre-releases
-	_, _, err := repo.client.Releases.CreateRelease(repo.projectID, &gitlab.CreateReleaseOptions{
+	_, resp, err := repo.client.Releases.CreateRelease(repo.projectID, opts)
+	if err != nil {
+		// the release may exist from a previous attempt, it is updated instead
+		if resp == nil || resp.StatusCode != 409 {
+			return err
+		}
+		if _, _, getErr := repo.client.Releases.GetRelease(repo.projectID, tag); getErr != nil {
+			return err
+		}
+		release.logf("release %s already exists, updating it", tag)
+		_, _, err = repo.client.Releases.UpdateRelease(repo.projectID, tag, &gitlab.UpdateReleaseOptions{
+			Name:        &name,
+			Description: &release.Changelog,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, v := range GetMovingTagVersions(release.NewVersion, release.MovingTags) {
+		if err := repo.moveTag(release.TagFormat.Format(v), release.SHA); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// moveTag recreates the tag on the given sha as GitLab tags can not be updated
+func (repo *GitLabRepository) moveTag(tag, sha string) error {
+	resp, err := repo.client.Tags.DeleteTag(repo.projectID, tag)
+	if err != nil && (resp == nil || resp.StatusCode != 404) {
+		return err
+	}
+	_, _, err = repo.client.Tags.CreateTag(repo.projectID, &gitlab.CreateTagOptions{
 		TagName: &tag,
 		Ref:     &sha,
-		// TODO: this may been to be wrapped in ```
-		Description: &changelog,
 	})
-
 	return err
 }
 
+// mergeRequestCommits returns the commits of the squash merged merge request of the commit, nil if the commit
+// is no squash commit. A merge request is only expanded once, the commits are empty if it was seen before.
+func (repo *GitLabRepository) mergeRequestCommits(sha string, seen map[int]bool) ([]*Commit, error) {
+	mergeRequests, _, err := repo.client.Commits.GetMergeRequestsByCommit(repo.projectID, sha)
+	if err != nil {
+		return nil, err
+	}
+	for _, mr := range mergeRequests {
+		if mr.State != "merged" || !mr.Squash {
+			continue
+		}
+		if seen[mr.IID] {
+			return []*Commit{}, nil
+		}
+		seen[mr.IID] = true
+		ret := make([]*Commit, 0)
+		opts := &gitlab.GetMergeRequestCommitsOptions{Page: 1, PerPage: 100}
+		for {
+			commits, resp, err := repo.client.MergeRequests.GetMergeRequestCommits(repo.projectID, mr.IID, opts)
+			if err != nil {
+				return nil, err
+			}
+			for _, commit := range commits {
+				ret = append(ret, parseGitlabCommit(commit))
+			}
+			if resp.CurrentPage >= resp.TotalPages {
+				break
+			}
+			opts.Page = resp.NextPage
+		}
+		if len(ret) > 0 {
+			return ret, nil
+		}
+	}
+	return nil, nil
+}
+
 func parseGitlabCommit(commit *gitlab.Commit) *Commit {
-	c := new(Commit)
-	c.SHA = commit.ID
-	c.Raw = strings.Split(commit.Message, "\n")
-	found := commitPattern.FindAllStringSubmatch(c.Raw[0], -1)
-	if len(found) < 1 {
-		return c
-	}
-	c.Type = strings.ToLower(found[0][1])
-	c.Scope = found[0][2]
-	c.Message = found[0][3]
-	c.Change = Change{
-		Major: breakingPattern.MatchString(commit.Message),
-		Minor: c.Type == "feat",
-		Patch: c.Type == "fix",
+	c := ParseCommit(commit.ID, commit.Message)
+	c.Author = commit.AuthorName
+	c.Committer = commit.CommitterName
+	if commit.CommittedDate != nil {
+		c.Date = *commit.CommittedDate
 	}
+	c.Parents = commit.ParentIDs
 	return c
 }
 
@@ -9,6 +9,7 @@ import (
 	"regexp"
 	"strconv"
 	"testing"
+	"time"
 
 	"github.com/Masterminds/semver"
 	"github.com/stretchr/testify/require"
@@ -34,6 +35,51 @@ func TestNewGitlabRepository(t *testing.T) {
 	require.Equal("https://mygitlab.com/api/v4/", repo.client.BaseURL().String(), "invalid custom instance initialization")
 }
 
+//nolint:errcheck
+func TestNewGitlabRepositorySubPath(t *testing.T) {
+	var requestedPaths []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// the client requests the base url to configure the rate limit
+		if r.URL.Path == "/gitlab/api/v4/" {
+			json.NewEncoder(w).Encode(struct{}{})
+			return
+		}
+		requestedPaths = append(requestedPaths, r.URL.Path)
+		if r.Method == "GET" && r.URL.Path == fmt.Sprintf("/gitlab/api/v4/projects/%d", GITLAB_PROJECT_ID) {
+			json.NewEncoder(w).Encode(GITLAB_PROJECT)
+			return
+		}
+		http.Error(w, "invalid route", http.StatusNotImplemented)
+	}))
+	defer ts.Close()
+
+	for _, baseURL := range []string{ts.URL + "/gitlab", ts.URL + "/gitlab/", ts.URL + "/gitlab/api/v4", ts.URL + "/gitlab/api/v4/"} {
+		requestedPaths = nil
+		repo, err := NewGitLabRepository(context.TODO(), baseURL, "owner/test-repo", "token", "", strconv.Itoa(GITLAB_PROJECT_ID))
+		require.NoError(t, err)
+		require.Equal(t, ts.URL+"/gitlab/api/v4/", repo.client.BaseURL().String(), baseURL)
+		defaultBranch, _, err := repo.GetInfo()
+		require.NoError(t, err, baseURL)
+		require.Equal(t, GITLAB_DEFAULTBRANCH, defaultBranch)
+		require.Equal(t, []string{fmt.Sprintf("/gitlab/api/v4/projects/%d", GITLAB_PROJECT_ID)}, requestedPaths)
+	}
+}
+
+func TestNewGitlabRepositorySlug(t *testing.T) {
+	for _, slug := range []string{"owner/", "/repo", "a//b"} {
+		_, err := NewGitLabRepository(context.TODO(), "", slug, "token", "", "1")
+		require.EqualError(t, err, fmt.Sprintf("invalid slug %q: must be group/project", slug))
+	}
+	repo, err := NewGitLabRepository(context.TODO(), "", "group/subgroup/project", "token", "", "1")
+	require.NoError(t, err)
+	require.Equal(t, "group/subgroup", repo.Owner())
+	require.Equal(t, "project", repo.Repo())
+
+	// the slug is optional as the project id identifies the project
+	_, err = NewGitLabRepository(context.TODO(), "", "group-project", "token", "", "1")
+	require.NoError(t, err)
+}
+
 func createGitlabCommit(sha, message string) *gitlab.Commit {
 	return &gitlab.Commit{ID: sha, Message: message}
 }
@@ -62,6 +108,8 @@ var (
 		createGitlabTag("v3.0.0-beta.2", "deadbeef"),
 		createGitlabTag("v3.0.0-beta.1", "deadbeef"),
 		createGitlabTag("2020.04.19", "deadbeef"),
+		createGitlabTag("v4", "c0ffee"),
+		createGitlabTag("1.2", "f00d"),
 	}
 )
 
@@ -125,10 +173,42 @@ func TestGitlabGetInfo(t *testing.T) {
 	require.True(t, isPrivate)
 }
 
+//nolint:errcheck
+func TestGitlabGetInfoVisibility(t *testing.T) {
+	testCases := []struct {
+		visibility string
+		private    bool
+	}{
+		{"public", false},
+		{"internal", true},
+		{"private", true},
+	}
+	for _, tc := range testCases {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/api/v4/" {
+				json.NewEncoder(w).Encode(struct{}{})
+				return
+			}
+			if r.Method == "GET" && r.URL.Path == fmt.Sprintf("/api/v4/projects/%d", GITLAB_PROJECT_ID) {
+				fmt.Fprintf(w, `{"id": %d, "default_branch": "develop", "visibility": "%s"}`, GITLAB_PROJECT_ID, tc.visibility)
+				return
+			}
+			http.Error(w, "invalid route", http.StatusNotImplemented)
+		}))
+		repo, err := NewGitLabRepository(context.TODO(), ts.URL, "owner/test-repo", "token", "", strconv.Itoa(GITLAB_PROJECT_ID))
+		require.NoError(t, err)
+		defaultBranch, isPrivate, err := repo.GetInfo()
+		ts.Close()
+		require.NoError(t, err)
+		require.Equal(t, "develop", defaultBranch)
+		require.Equal(t, tc.private, isPrivate, tc.visibility)
+	}
+}
+
 func TestGitlabGetCommits(t *testing.T) {
 	repo, ts := getNewGitlabTestRepo(t)
 	defer ts.Close()
-	commits, err := repo.GetCommits("")
+	commits, err := repo.GetCommitsUntil("", "")
 	require.NoError(t, err)
 	require.Len(t, commits, 4)
 
@@ -140,6 +220,15 @@ func TestGitlabGetCommits(t *testing.T) {
 	}
 }
 
+func TestGitlabGetCommitsStopsAtRelease(t *testing.T) {
+	repo, ts := getNewGitlabTestRepo(t)
+	defer ts.Close()
+	commits, err := repo.GetCommitsUntil("", "dcba")
+	require.NoError(t, err)
+	require.Len(t, commits, 2)
+	require.Equal(t, "dcba", commits[1].SHA)
+}
+
 func TestGitlabGetLatestRelease(t *testing.T) {
 	repo, ts := getNewGitlabTestRepo(t)
 	defer ts.Close()
@@ -147,19 +236,22 @@ func TestGitlabGetLatestRelease(t *testing.T) {
 	testCases := []struct {
 		vrange          string
 		re              *regexp.Regexp
+		coerce          bool
 		expectedSHA     string
 		expectedVersion string
 	}{
-		{"", nil, "deadbeef", "2020.4.19"},
-		{"", regexp.MustCompile("^v[0-9]*"), "deadbeef", "2.0.0"},
-		{"2-beta", nil, "deadbeef", "2.1.0-beta"},
-		{"3-beta", nil, "deadbeef", "3.0.0-beta.2"},
-		{"4-beta", nil, "deadbeef", "4.0.0-beta"},
+		{"", nil, false, "deadbeef", "2020.4.19"},
+		{"", regexp.MustCompile("^v[0-9]*"), false, "deadbeef", "2.0.0"},
+		{"2-beta", nil, false, "deadbeef", "2.1.0-beta"},
+		{"3-beta", nil, false, "deadbeef", "3.0.0-beta.2"},
+		{"4-beta", nil, false, "deadbeef", "4.0.0-beta"},
+		{"", regexp.MustCompile("^v[0-9]*"), true, "c0ffee", "4.0.0"},
+		{"~1.2", nil, true, "f00d", "1.2.0"},
 	}
 
 	for _, tc := range testCases {
-		t.Run(fmt.Sprintf("VersionRange: %s, RE: %s", tc.vrange, tc.re), func(t *testing.T) {
-			release, err := repo.GetLatestRelease(tc.vrange, tc.re)
+		t.Run(fmt.Sprintf("VersionRange: %s, RE: %s, Coerce: %t", tc.vrange, tc.re, tc.coerce), func(t *testing.T) {
+			release, err := repo.GetLatestRelease(&GetLatestReleaseConfig{VersionRange: tc.vrange, Match: tc.re, CoerceVersions: tc.coerce})
 			require.NoError(t, err)
 			require.Equal(t, tc.expectedSHA, release.SHA)
 			require.Equal(t, tc.expectedVersion, release.Version.String())
@@ -171,6 +263,218 @@ func TestGitlabCreateRelease(t *testing.T) {
 	repo, ts := getNewGitlabTestRepo(t)
 	defer ts.Close()
 	newVersion := semver.MustParse("2.0.0")
-	err := repo.CreateRelease("", newVersion, false, "", "deadbeef")
+	err := repo.CreateRelease(&CreateReleaseConfig{NewVersion: newVersion, SHA: "deadbeef"})
+	require.NoError(t, err)
+}
+
+//nolint:errcheck
+func TestGitlabCreateReleaseAssets(t *testing.T) {
+	var data struct {
+		TagName string `json:"tag_name"`
+		Assets  struct {
+			Links []struct {
+				Name string `json:"name"`
+				URL  string `json:"url"`
+			} `json:"links"`
+		} `json:"assets"`
+	}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "POST" && r.URL.Path == fmt.Sprintf("/api/v4/projects/%d/releases", GITLAB_PROJECT_ID) {
+			json.NewDecoder(r.Body).Decode(&data)
+			fmt.Fprint(w, "{}")
+			return
+		}
+		http.Error(w, "invalid route", http.StatusNotImplemented)
+	}))
+	defer ts.Close()
+	repo, err := NewGitLabRepository(context.TODO(), ts.URL, "gitlab-examples-ci", "token", "", strconv.Itoa(GITLAB_PROJECT_ID))
+	require.NoError(t, err)
+
+	assets := []*Asset{{Name: "linux", URL: "https://example.com/app-linux"}, {Name: "darwin", URL: "https://example.com/app-darwin"}}
+	err = repo.CreateRelease(&CreateReleaseConfig{NewVersion: semver.MustParse("2.0.0"), SHA: "deadbeef", Assets: assets})
+	require.NoError(t, err)
+	require.Equal(t, "v2.0.0", data.TagName)
+	require.Len(t, data.Assets.Links, 2)
+	require.Equal(t, "linux", data.Assets.Links[0].Name)
+	require.Equal(t, "https://example.com/app-darwin", data.Assets.Links[1].URL)
+}
+
+//nolint:errcheck
+func TestGitlabCreateReleaseExisting(t *testing.T) {
+	releasePath := fmt.Sprintf("/api/v4/projects/%d/releases", GITLAB_PROJECT_ID)
+	var updated map[string]string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "POST" && r.URL.Path == releasePath:
+			http.Error(w, `{"message": "Release already exists"}`, http.StatusConflict)
+		case r.Method == "GET" && r.URL.Path == releasePath+"/v2.0.0":
+			fmt.Fprint(w, `{"tag_name": "v2.0.0"}`)
+		case r.Method == "PUT" && r.URL.Path == releasePath+"/v2.0.0":
+			json.NewDecoder(r.Body).Decode(&updated)
+			fmt.Fprint(w, `{"tag_name": "v2.0.0"}`)
+		default:
+			http.Error(w, "invalid route", http.StatusNotImplemented)
+		}
+	}))
+	defer ts.Close()
+	repo, err := NewGitLabRepository(context.TODO(), ts.URL, "gitlab-examples-ci", "token", "", strconv.Itoa(GITLAB_PROJECT_ID))
+	require.NoError(t, err)
+
+	err = repo.CreateRelease(&CreateReleaseConfig{NewVersion: semver.MustParse("2.0.0"), SHA: "deadbeef", Changelog: "changelog"})
+	require.NoError(t, err)
+	require.Equal(t, "v2.0.0", updated["name"])
+	require.Equal(t, "changelog", updated["description"])
+}
+
+//nolint:errcheck
+func TestGitlabGetCommitsMergeRequests(t *testing.T) {
+	projectPath := fmt.Sprintf("/api/v4/projects/%d", GITLAB_PROJECT_ID)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case projectPath + "/repository/commits":
+			json.NewEncoder(w).Encode([]*gitlab.Commit{
+				createGitlabCommit("merge", "Merge branch 'search' into 'master'"),
+				createGitlabCommit("squash", "Add search"),
+				createGitlabCommit("direct", "fix: typo"),
+				createGitlabCommit("release", "chore: release"),
+			})
+		case projectPath + "/repository/commits/merge/merge_requests", projectPath + "/repository/commits/squash/merge_requests":
+			// go-gitlab encodes the labels of a merge request as a string but only decodes them from an array
+			fmt.Fprint(w, `[{"iid": 7, "state": "merged", "squash": true}]`)
+		case projectPath + "/repository/commits/direct/merge_requests":
+			fmt.Fprint(w, "[]")
+		case projectPath + "/merge_requests/7/commits":
+			json.NewEncoder(w).Encode([]*gitlab.Commit{
+				createGitlabCommit("mr2", "feat(search): add index"),
+				createGitlabCommit("mr1", "fix: empty query\n\nBREAKING CHANGE: query is required"),
+			})
+		case "/api/v4/":
+			fmt.Fprint(w, "{}")
+		default:
+			http.Error(w, "invalid route", http.StatusNotImplemented)
+		}
+	}))
+	defer ts.Close()
+	repo, err := NewGitLabRepository(context.TODO(), ts.URL, "owner/test-repo", "token", "master", strconv.Itoa(GITLAB_PROJECT_ID))
+	require.NoError(t, err)
+
+	commits, err := repo.GetCommitsUntil("", "release")
+	require.NoError(t, err)
+	require.Len(t, commits, 4)
+
+	repo.UseMergeRequestCommits = true
+	commits, err = repo.GetCommitsUntil("", "release")
+	require.NoError(t, err)
+	shas := make([]string, len(commits))
+	for i, commit := range commits {
+		shas[i] = commit.SHA
+	}
+	require.Equal(t, []string{"mr2", "mr1", "direct", "release"}, shas)
+	require.Equal(t, Change{Minor: true}, commits[0].Change)
+	require.True(t, commits[1].Change.Major)
+}
+
+func TestGitlabGetCommitsOrder(t *testing.T) {
+	date := time.Date(2020, 5, 1, 12, 0, 0, 0, time.UTC)
+	createDatedCommit := func(sha string, date time.Time) *gitlab.Commit {
+		commit := createGitlabCommit(sha, "fix: "+sha)
+		commit.CommittedDate = &date
+		return commit
+	}
+	projectPath := fmt.Sprintf("/api/v4/projects/%d", GITLAB_PROJECT_ID)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case projectPath + "/repository/commits":
+			w.Header().Set("X-Total-Pages", "2")
+			if r.URL.Query().Get("page") == "2" {
+				w.Header().Set("X-Page", "2")
+				json.NewEncoder(w).Encode([]*gitlab.Commit{createDatedCommit("c", date.Add(-time.Hour)), createDatedCommit("release", date.Add(-3*time.Hour))})
+				return
+			}
+			w.Header().Set("X-Page", "1")
+			w.Header().Set("X-Next-Page", "2")
+			json.NewEncoder(w).Encode([]*gitlab.Commit{createDatedCommit("head", date.Add(-4*time.Hour)), createDatedCommit("b", date.Add(-2*time.Hour)), createDatedCommit("a", date)})
+		case "/api/v4/":
+			fmt.Fprint(w, "{}")
+		default:
+			http.Error(w, "invalid route", http.StatusNotImplemented)
+		}
+	}))
+	defer ts.Close()
+	repo, err := NewGitLabRepository(context.TODO(), ts.URL, "owner/test-repo", "token", "master", strconv.Itoa(GITLAB_PROJECT_ID))
+	require.NoError(t, err)
+
+	commits, err := repo.GetCommitsUntil("", "release")
+	require.NoError(t, err)
+	// the head stays first even with a skewed date as it is the released commit
+	require.Equal(t, []string{"head", "a", "c", "b", "release"}, commitSHAs(commits))
+	require.True(t, date.Equal(commits[1].Date))
+}
+
+func TestGitlabGetCommitsSince(t *testing.T) {
+	date := time.Date(2020, 5, 1, 12, 0, 0, 0, time.UTC)
+	projectPath := fmt.Sprintf("/api/v4/projects/%d", GITLAB_PROJECT_ID)
+	var requestedSince string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case projectPath + "/repository/commits":
+			requestedSince = r.URL.Query().Get("since")
+			a, b := createGitlabCommit("a", "fix: a"), createGitlabCommit("b", "fix: b")
+			a.CommittedDate, b.CommittedDate = &date, &date
+			json.NewEncoder(w).Encode([]*gitlab.Commit{a, b})
+		case "/api/v4/":
+			fmt.Fprint(w, "{}")
+		default:
+			http.Error(w, "invalid route", http.StatusNotImplemented)
+		}
+	}))
+	defer ts.Close()
+	repo, err := NewGitLabRepository(context.TODO(), ts.URL, "owner/test-repo", "token", "master", strconv.Itoa(GITLAB_PROJECT_ID))
+	require.NoError(t, err)
+
+	commits, err := repo.GetCommitsUntil("", "")
+	require.NoError(t, err)
+	require.Len(t, commits, 2)
+	require.Empty(t, requestedSince)
+
+	repo.Since = date.Add(-time.Hour)
+	commits, err = repo.GetCommitsUntil("", "")
+	require.NoError(t, err)
+	require.Len(t, commits, 2)
+	require.Equal(t, "2020-05-01T11:00:00Z", requestedSince)
+
+	_, err = repo.GetCommitsUntil("", "release")
+	require.EqualError(t, err, "the commit release of the previous release is older than 2020-05-01T11:00:00Z, increase --since-duration")
+}
+
+func TestGitlabGetCommitsListFiles(t *testing.T) {
+	projectPath := fmt.Sprintf("/api/v4/projects/%d", GITLAB_PROJECT_ID)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case projectPath + "/repository/commits":
+			json.NewEncoder(w).Encode([]*gitlab.Commit{
+				createGitlabCommit("abcd", "feat(api): search"),
+				createGitlabCommit("release", "chore: release"),
+			})
+		case projectPath + "/repository/commits/abcd/diff":
+			json.NewEncoder(w).Encode([]*gitlab.Diff{
+				{NewPath: "services/api/search.go", OldPath: "services/api/search.go"},
+				{NewPath: "services/api/b.go", OldPath: "services/web/a.go", RenamedFile: true},
+			})
+		case "/api/v4/":
+			fmt.Fprint(w, "{}")
+		default:
+			http.Error(w, "invalid route", http.StatusNotImplemented)
+		}
+	}))
+	defer ts.Close()
+	repo, err := NewGitLabRepository(context.TODO(), ts.URL, "owner/test-repo", "token", "master", strconv.Itoa(GITLAB_PROJECT_ID))
+	require.NoError(t, err)
+	repo.ListFiles = true
+
+	commits, err := repo.GetCommitsUntil("", "release")
 	require.NoError(t, err)
+	require.Len(t, commits, 2)
+	require.Equal(t, []string{"services/api/search.go", "services/api/b.go", "services/web/a.go"}, commits[0].Files)
+	require.Nil(t, commits[1].Files)
 }
@@ -0,0 +1,194 @@
+package semrel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// GogsRepository uses the API of Gogs which is close to the early Gitea API. Older versions of Gogs can not list
+// commits or tags, and the API can neither create releases nor tags: with Local the tag is pushed from the local checkout instead.
+type GogsRepository struct {
+	owner   string
+	repo    string
+	token   string
+	baseURL string
+	Ctx     context.Context
+	client  *http.Client
+	// Local allows pushing the tag from Dir if the release can not be created, it is set by --local
+	Local bool
+	// Dir is the local checkout the tag is pushed from if the release can not be created, defaults to the working directory
+	Dir string
+	// Remote is the git remote of the repository in Dir, defaults to origin
+	Remote string
+}
+
+// errGogsRouteMissing is returned if the Gogs version does not serve a route the repository needs
+func errGogsRouteMissing(what string) error {
+	return fmt.Errorf("listing %s is not supported by this Gogs version, use --local to read them from the local repository", what)
+}
+
+// NewGogsRepository creates a Gogs repository, the baseURL is the root of the instance (e.g. https://try.gogs.io)
+func NewGogsRepository(ctx context.Context, baseURL, slug, token string) (*GogsRepository, error) {
+	if baseURL == "" {
+		return nil, errors.New("gogs base url is required")
+	}
+	owner, repoName, err := ParseSlug(slug)
+	if err != nil {
+		return nil, err
+	}
+	repo := new(GogsRepository)
+	repo.owner = owner
+	repo.repo = repoName
+	repo.token = token
+	repo.baseURL = strings.TrimSuffix(baseURL, "/") + "/api/v1"
+	repo.Ctx = ctx
+	repo.client = httpClient(ctx)
+	repo.Dir = "."
+	repo.Remote = "origin"
+	return repo, nil
+}
+
+func (repo *GogsRepository) repoURL(path string) string {
+	return fmt.Sprintf("%s/repos/%s/%s%s", repo.baseURL, url.PathEscape(repo.owner), url.PathEscape(repo.repo), path)
+}
+
+func (repo *GogsRepository) do(method, u string, body, v interface{}) (*http.Response, error) {
+	return doJSONRequest(repo.Ctx, repo.client, method, u, body, v, func(req *http.Request) {
+		req.Header.Set("Authorization", "token "+repo.token)
+	})
+}
+
+func (repo *GogsRepository) GetInfo() (string, bool, error) {
+	var info struct {
+		DefaultBranch string `json:"default_branch"`
+		Private       bool   `json:"private"`
+	}
+	resp, err := repo.do("GET", repo.repoURL(""), nil, &info)
+	if resp != nil && resp.StatusCode == 404 {
+		return "", false, fmt.Errorf("%w: %v", ErrRepoNotFound, err)
+	}
+	if err != nil {
+		return "", false, err
+	}
+	return info.DefaultBranch, info.Private, nil
+}
+
+// GetCommits returns all commits reachable from sha, newest first
+func (repo *GogsRepository) GetCommits(sha string) ([]*Commit, error) {
+	return repo.GetCommitsUntil(sha, "")
+}
+
+// GetCommitsUntil pages through the commits until an empty page as Gogs does not take a page limit, a page repeating
+// the first commit of the previous one stops as well since some versions ignore the page
+func (repo *GogsRepository) GetCommitsUntil(sha, releaseSHA string) ([]*Commit, error) {
+	allCommits := make([]*Commit, 0)
+	previousSHA := ""
+	for page := 1; ; page++ {
+		query := url.Values{}
+		query.Set("page", fmt.Sprint(page))
+		if sha != "" {
+			query.Set("sha", sha)
+		}
+		var commits []*giteaCommit
+		resp, err := repo.do("GET", repo.repoURL("/commits")+"?"+query.Encode(), nil, &commits)
+		// the repository exists, only the route is missing
+		if resp != nil && resp.StatusCode == 404 && page == 1 {
+			return nil, errGogsRouteMissing("commits")
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(commits) == 0 || commits[0].SHA == previousSHA {
+			break
+		}
+		previousSHA = commits[0].SHA
+		for _, commit := range commits {
+			allCommits = append(allCommits, parseGiteaCommit(commit))
+			if releaseSHA != "" && commit.SHA == releaseSHA {
+				return allCommits, nil
+			}
+		}
+	}
+	return allCommits, nil
+}
+
+// GetLatestRelease reads all tags with one request, the tags endpoint of Gogs is not paged. Unlike Gitea a missing
+// route is not the same as no tags, Gogs lists an empty array for a repository without tags.
+func (repo *GogsRepository) GetLatestRelease(latest *GetLatestReleaseConfig) (*Release, error) {
+	var tags []*giteaTag
+	resp, err := repo.do("GET", repo.repoURL("/tags"), nil, &tags)
+	if resp != nil && resp.StatusCode == 404 {
+		return nil, errGogsRouteMissing("tags")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(tags) == 0 {
+		return nil, ErrNoReleases
+	}
+	allReleases := make(Releases, 0, len(tags))
+	for _, tag := range tags {
+		if latest.Match != nil && !latest.Match.MatchString(tag.Name) {
+			continue
+		}
+		tagVersion, ok := latest.TagFormat.Version(tag.Name)
+		if !ok {
+			continue
+		}
+		version, err := parseTagVersion(tagVersion, latest.CoerceVersions)
+		if err != nil {
+			continue
+		}
+		allReleases = append(allReleases, &Release{tag.Commit.SHA, version})
+	}
+	return latest.selectRelease(allReleases)
+}
+
+// CreateRelease uses the release endpoint which creates the tag on the given sha as well. Gogs versions without the
+// endpoint answer 404 or 405, with Local the tag is pushed from the local checkout then and the changelog is not stored.
+// Moving tags are not supported as the Gogs API can not delete tags.
+func (repo *GogsRepository) CreateRelease(release *CreateReleaseConfig) error {
+	if len(release.MovingTags) > 0 {
+		return errors.New("moving tags are not supported on Gogs")
+	}
+	tag := release.TagFormat.Format(release.NewVersion.String())
+	name := tag
+	if release.Title != "" {
+		name = release.Title
+	}
+	body := map[string]interface{}{
+		"tag_name":         tag,
+		"target_commitish": release.SHA,
+		"name":             name,
+		"body":             release.Changelog,
+		"draft":            false,
+		"prerelease":       release.Prerelease || release.NewVersion.Prerelease() != "",
+	}
+	resp, err := repo.do("POST", repo.repoURL("/releases"), body, nil)
+	if resp == nil || (resp.StatusCode != 404 && resp.StatusCode != 405) {
+		return err
+	}
+	if !repo.Local {
+		return fmt.Errorf("releases can not be created on this Gogs version, use --local to push the tag %s from the local repository", tag)
+	}
+	release.warnf("releases can not be created on this Gogs version, pushing the tag %s from the local repository without the changelog", tag)
+	local := &LocalRepository{dir: repo.Dir}
+	_, err = local.git("push", "--quiet", repo.Remote, release.SHA+":refs/tags/"+tag)
+	return err
+}
+
+func (repo *GogsRepository) Owner() string {
+	return repo.owner
+}
+
+func (repo *GogsRepository) Repo() string {
+	return repo.repo
+}
+
+func (repo *GogsRepository) Provider() string {
+	return "Gogs"
+}
@@ -0,0 +1,167 @@
+package semrel
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/Masterminds/semver"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewGogsRepository(t *testing.T) {
+	require := require.New(t)
+
+	repo, err := NewGogsRepository(context.TODO(), "", "owner/test-repo", "token")
+	require.Nil(repo)
+	require.EqualError(err, "gogs base url is required")
+
+	repo, err = NewGogsRepository(context.TODO(), "https://gogs.example.com/", "owner/test-repo", "token")
+	require.NoError(err)
+	require.Equal("owner", repo.Owner())
+	require.Equal("test-repo", repo.Repo())
+	require.Equal("Gogs", repo.Provider())
+	require.Equal("https://gogs.example.com/api/v1", repo.baseURL)
+}
+
+//nolint:errcheck
+func gogsHandler(w http.ResponseWriter, r *http.Request) {
+	// the Gitea fixtures served the way Gogs does: the commits on the first page only and the tags at once
+	if r.Header.Get("Authorization") != "token token" {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if r.Method == "GET" && r.URL.Path == "/api/v1/repos/owner/test-repo" {
+		fmt.Fprint(w, `{"default_branch": "master", "private": true}`)
+		return
+	}
+	if r.Method == "GET" && r.URL.Path == "/api/v1/repos/owner/test-repo/commits" {
+		if r.URL.Query().Get("limit") != "" {
+			http.Error(w, "unexpected limit", http.StatusBadRequest)
+			return
+		}
+		if r.URL.Query().Get("page") != "1" {
+			fmt.Fprint(w, "[]")
+			return
+		}
+		json.NewEncoder(w).Encode(GITEA_COMMITS)
+		return
+	}
+	if r.Method == "GET" && r.URL.Path == "/api/v1/repos/owner/test-repo/tags" {
+		if r.URL.RawQuery != "" {
+			http.Error(w, "tags are not paged", http.StatusBadRequest)
+			return
+		}
+		json.NewEncoder(w).Encode(GITEA_TAGS)
+		return
+	}
+	if r.Method == "POST" && r.URL.Path == "/api/v1/repos/owner/test-repo/releases" {
+		var data map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&data)
+		r.Body.Close()
+		if data["tag_name"] != "v2.0.0" || data["target_commitish"] != "deadbeef" || data["draft"] != false {
+			http.Error(w, "invalid tag name or target", http.StatusBadRequest)
+			return
+		}
+		fmt.Fprint(w, "{}")
+		return
+	}
+	http.Error(w, "invalid route", http.StatusNotImplemented)
+}
+
+func getNewGogsTestRepo(t *testing.T) (*GogsRepository, *httptest.Server) {
+	ts := httptest.NewServer(http.HandlerFunc(gogsHandler))
+	repo, err := NewGogsRepository(context.TODO(), ts.URL, "owner/test-repo", "token")
+	require.NoError(t, err)
+	return repo, ts
+}
+
+//nolint:errcheck
+func TestGogsGetCommitsIgnoredPage(t *testing.T) {
+	// older versions of Gogs return the same page for every page number
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(GITEA_COMMITS)
+	}))
+	defer ts.Close()
+	repo, err := NewGogsRepository(context.TODO(), ts.URL, "owner/test-repo", "token")
+	require.NoError(t, err)
+	commits, err := repo.GetCommitsUntil("deadbeef", "")
+	require.NoError(t, err)
+	require.Len(t, commits, 4)
+}
+
+func TestGogsCreateRelease(t *testing.T) {
+	repo, ts := getNewGogsTestRepo(t)
+	defer ts.Close()
+	newVersion := semver.MustParse("2.0.0")
+	err := repo.CreateRelease(&CreateReleaseConfig{NewVersion: newVersion, SHA: "deadbeef"})
+	require.NoError(t, err)
+
+	err = repo.CreateRelease(&CreateReleaseConfig{NewVersion: newVersion, SHA: "deadbeef", MovingTags: []string{"major"}})
+	require.EqualError(t, err, "moving tags are not supported on Gogs")
+}
+
+func TestGogsMissingRoutes(t *testing.T) {
+	// older versions of Gogs do not serve the commits and tags of a repository
+	ts := httptest.NewServer(http.NotFoundHandler())
+	defer ts.Close()
+	repo, err := NewGogsRepository(context.TODO(), ts.URL, "owner/test-repo", "token")
+	require.NoError(t, err)
+	_, err = repo.GetCommitsUntil("deadbeef", "")
+	require.EqualError(t, err, "listing commits is not supported by this Gogs version, use --local to read them from the local repository")
+	_, err = repo.GetLatestRelease(&GetLatestReleaseConfig{})
+	require.EqualError(t, err, "listing tags is not supported by this Gogs version, use --local to read them from the local repository")
+}
+
+func TestGogsCreateReleasePushesTag(t *testing.T) {
+	dir, cleanup := newLocalTestRepo(t)
+	defer cleanup()
+	remote, err := ioutil.TempDir("", "semrel-gogs-remote")
+	require.NoError(t, err)
+	defer os.RemoveAll(remote)
+	git := func(dir string, args ...string) string {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+		return strings.TrimSpace(string(out))
+	}
+	git(remote, "init", "-q", "--bare")
+	git(dir, "remote", "add", "origin", remote)
+	sha := git(dir, "rev-parse", "HEAD")
+
+	// the release endpoint is missing, the tag is pushed from the checkout instead
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}))
+	defer ts.Close()
+	repo, err := NewGogsRepository(context.TODO(), ts.URL, "owner/test-repo", "token")
+	require.NoError(t, err)
+	repo.Dir = dir
+	err = repo.CreateRelease(&CreateReleaseConfig{NewVersion: semver.MustParse("2.0.0"), SHA: sha})
+	require.EqualError(t, err, "releases can not be created on this Gogs version, use --local to push the tag v2.0.0 from the local repository")
+	require.Empty(t, git(remote, "tag"))
+
+	repo.Local = true
+	var logged bytes.Buffer
+	err = repo.CreateRelease(&CreateReleaseConfig{NewVersion: semver.MustParse("2.0.0"), SHA: sha, WarnLogger: log.New(&logged, "", 0)})
+	require.NoError(t, err)
+	require.Equal(t, sha, git(remote, "rev-parse", "refs/tags/v2.0.0"))
+	require.Contains(t, logged.String(), "pushing the tag v2.0.0 from the local repository")
+
+	// other errors are returned
+	ts.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "forbidden", http.StatusForbidden)
+	})
+	err = repo.CreateRelease(&CreateReleaseConfig{NewVersion: semver.MustParse("2.1.0"), SHA: sha})
+	require.Error(t, err)
+}
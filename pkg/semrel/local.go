@@ -0,0 +1,148 @@
+package semrel
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// LocalRepository reads the commits and tags from a local git checkout instead of the API of the provider,
+// everything else (e.g. creating the release) is done by the wrapped provider
+type LocalRepository struct {
+	Repository
+	dir string
+	// Since only lists the commits after the time, the commit of the previous release must be within the window
+	Since time.Time
+}
+
+// ErrShallowClone is returned by NewLocalRepository for a shallow clone, the commit of the previous release may be missing
+var ErrShallowClone = errors.New("the local repository is a shallow clone, fetch the full history (e.g. fetch-depth: 0 of actions/checkout, git fetch --unshallow --tags or --unshallow)")
+
+func NewLocalRepository(remote Repository, dir string) (*LocalRepository, error) {
+	repo := &LocalRepository{Repository: remote, dir: dir}
+	shallow, err := repo.isShallow()
+	if err != nil {
+		return nil, err
+	}
+	if shallow {
+		return nil, ErrShallowClone
+	}
+	return repo, nil
+}
+
+func (repo *LocalRepository) isShallow() (bool, error) {
+	shallow, err := repo.git("rev-parse", "--is-shallow-repository")
+	if err != nil {
+		return false, err
+	}
+	return strings.TrimSpace(shallow) == "true", nil
+}
+
+// Unshallow fetches the full history and the tags if the repository in dir is a shallow clone, it reports whether it was shallow
+func Unshallow(dir string) (bool, error) {
+	repo := &LocalRepository{dir: dir}
+	shallow, err := repo.isShallow()
+	if err != nil || !shallow {
+		return false, err
+	}
+	if _, err := repo.git("fetch", "--unshallow", "--tags", "--quiet"); err != nil {
+		return true, err
+	}
+	return true, nil
+}
+
+func (repo *LocalRepository) git(args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("git", args...)
+	cmd.Dir = repo.dir
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("git %s: %w: %s", args[0], err, bytes.TrimSpace(stderr.Bytes()))
+	}
+	return stdout.String(), nil
+}
+
+// GetCommits returns all commits reachable from sha, newest first
+func (repo *LocalRepository) GetCommits(sha string) ([]*Commit, error) {
+	return repo.GetCommitsUntil(sha, "")
+}
+
+// GetCommitsUntil returns the commits reachable from sha, newest first, stopping after the commit releaseSHA
+func (repo *LocalRepository) GetCommitsUntil(sha, releaseSHA string) ([]*Commit, error) {
+	if sha == "" {
+		sha = "HEAD"
+	}
+	// the fields are separated by the unit separator and the commits by the record separator,
+	// the changed files are listed after the last field
+	args := []string{"log", "--name-only", "--format=%x1e%H%x1f%an%x1f%cn%x1f%P%x1f%B%x1f"}
+	if !repo.Since.IsZero() {
+		args = append(args, "--since="+repo.Since.Format(time.RFC3339))
+	}
+	out, err := repo.git(append(args, sha)...)
+	if err != nil {
+		return nil, err
+	}
+	allCommits := make([]*Commit, 0)
+	for _, record := range strings.Split(out, "\x1e") {
+		fields := strings.SplitN(record, "\x1f", 6)
+		if len(fields) != 6 {
+			continue
+		}
+		c := ParseCommit(fields[0], strings.TrimRight(fields[4], "\n"))
+		c.Author = fields[1]
+		c.Committer = fields[2]
+		c.Parents = strings.Fields(fields[3])
+		for _, file := range strings.Split(fields[5], "\n") {
+			if file != "" {
+				c.Files = append(c.Files, file)
+			}
+		}
+		allCommits = append(allCommits, c)
+		if releaseSHA != "" && c.SHA == releaseSHA {
+			return allCommits, nil
+		}
+	}
+	if releaseSHA != "" && !repo.Since.IsZero() {
+		return nil, errReleaseOutsideWindow(releaseSHA, repo.Since)
+	}
+	return allCommits, nil
+}
+
+func (repo *LocalRepository) GetLatestRelease(latest *GetLatestReleaseConfig) (*Release, error) {
+	// annotated tags are resolved to their commit by *objectname
+	out, err := repo.git("for-each-ref", "--format=%(refname:short)%09%(objectname)%09%(*objectname)", "refs/tags")
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(out) == "" {
+		return nil, ErrNoReleases
+	}
+	allReleases := make(Releases, 0)
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		fields := strings.Split(line, "\t")
+		if len(fields) != 3 {
+			continue
+		}
+		tag, sha := fields[0], fields[1]
+		if fields[2] != "" {
+			sha = fields[2]
+		}
+		if latest.Match != nil && !latest.Match.MatchString(tag) {
+			continue
+		}
+		tagVersion, ok := latest.TagFormat.Version(tag)
+		if !ok {
+			continue
+		}
+		version, err := parseTagVersion(tagVersion, latest.CoerceVersions)
+		if err != nil {
+			continue
+		}
+		allReleases = append(allReleases, &Release{sha, version})
+	}
+	return latest.selectRelease(allReleases)
+}
@@ -0,0 +1,195 @@
+package semrel
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-semantic-release/semantic-release/pkg/config"
+	"github.com/stretchr/testify/require"
+)
+
+// newLocalTestRepo creates a repository with the releases v1.0.0 (lightweight tag) and v1.1.0 (annotated tag)
+func newLocalTestRepo(t *testing.T) (string, func()) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	dir, err := ioutil.TempDir("", "semrel-local")
+	require.NoError(t, err)
+	git := func(args ...string) string {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=Jane Doe", "GIT_AUTHOR_EMAIL=jane@example.com",
+			"GIT_COMMITTER_NAME=CI", "GIT_COMMITTER_EMAIL=ci@example.com")
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+		return strings.TrimSpace(string(out))
+	}
+	git("init", "-q")
+	git("commit", "-q", "--allow-empty", "-m", "feat: initial")
+	git("tag", "v1.0.0")
+	git("commit", "-q", "--allow-empty", "-m", "feat: search")
+	git("tag", "-a", "v1.1.0", "-m", "v1.1.0")
+	git("commit", "-q", "--allow-empty", "-m", "fix: crash\n\nFixes #12\n\nBREAKING CHANGE: config removed")
+	git("commit", "-q", "--allow-empty", "-m", "docs: readme")
+	git("tag", "not-a-version")
+	return dir, func() { os.RemoveAll(dir) }
+}
+
+func TestLocalRepository(t *testing.T) {
+	dir, cleanup := newLocalTestRepo(t)
+	defer cleanup()
+	repo, err := NewLocalRepository(&GiteaRepository{owner: "owner", repo: "test-repo"}, dir)
+	require.NoError(t, err)
+	require.Equal(t, "Gitea", repo.Provider())
+	require.Equal(t, "owner", repo.Owner())
+
+	release, err := repo.GetLatestRelease(&GetLatestReleaseConfig{})
+	require.NoError(t, err)
+	require.Equal(t, "1.1.0", release.Version.String())
+
+	commits, err := repo.GetCommitsUntil("", release.SHA)
+	require.NoError(t, err)
+	require.Len(t, commits, 3)
+	require.Equal(t, "docs", commits[0].Type)
+	require.Equal(t, "fix", commits[1].Type)
+	require.True(t, commits[1].Change.Major)
+	require.Equal(t, []string{"#12"}, commits[1].Links)
+	require.Equal(t, "Jane Doe", commits[1].Author)
+	require.Equal(t, "CI", commits[1].Committer)
+	// the annotated tag is resolved to its commit
+	require.Equal(t, release.SHA, commits[2].SHA)
+	require.Equal(t, "search", commits[2].Message)
+
+	release, err = repo.GetLatestRelease(&GetLatestReleaseConfig{VersionRange: "1.0.x"})
+	require.NoError(t, err)
+	require.Equal(t, "1.0.0", release.Version.String())
+	commits, err = repo.GetCommitsUntil("HEAD", "")
+	require.NoError(t, err)
+	require.Len(t, commits, 4)
+	require.Equal(t, release.SHA, commits[3].SHA)
+}
+
+func TestLocalRepositoryNoGit(t *testing.T) {
+	dir, err := ioutil.TempDir("", "semrel-local")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	_, err = NewLocalRepository(&GiteaRepository{}, dir)
+	require.Error(t, err)
+}
+
+func TestLocalRepositoryFiles(t *testing.T) {
+	dir, cleanup := newLocalTestRepo(t)
+	defer cleanup()
+	require.NoError(t, os.MkdirAll(dir+"/services/api", 0755))
+	require.NoError(t, os.MkdirAll(dir+"/services/web", 0755))
+	require.NoError(t, ioutil.WriteFile(dir+"/services/api/main.go", []byte("package main\n"), 0644))
+	require.NoError(t, ioutil.WriteFile(dir+"/services/web/index file.js", []byte("\n"), 0644))
+	for _, args := range [][]string{
+		{"add", "services/api"},
+		{"commit", "-q", "-m", "feat: api"},
+		{"add", "services/web"},
+		{"commit", "-q", "-m", "fix: web"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=a", "GIT_AUTHOR_EMAIL=a@example.com", "GIT_COMMITTER_NAME=a", "GIT_COMMITTER_EMAIL=a@example.com")
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+
+	repo, err := NewLocalRepository(&GiteaRepository{}, dir)
+	require.NoError(t, err)
+	commits, err := repo.GetCommitsUntil("", "")
+	require.NoError(t, err)
+	require.Equal(t, []string{"services/web/index file.js"}, commits[0].Files)
+	require.Equal(t, "web", commits[0].Message)
+	require.Equal(t, []string{"services/api/main.go"}, commits[1].Files)
+	require.Empty(t, commits[2].Files)
+}
+
+func TestLocalRepositoryMergeCommits(t *testing.T) {
+	dir, cleanup := newLocalTestRepo(t)
+	defer cleanup()
+	for _, args := range [][]string{
+		{"checkout", "-q", "-b", "search"},
+		{"commit", "-q", "--allow-empty", "-m", "feat: search filters"},
+		{"checkout", "-q", "-"},
+		{"commit", "-q", "--allow-empty", "-m", "fix: typo"},
+		{"merge", "-q", "--no-ff", "-m", "Merge branch 'search'", "search"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=a", "GIT_AUTHOR_EMAIL=a@example.com", "GIT_COMMITTER_NAME=a", "GIT_COMMITTER_EMAIL=a@example.com")
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+
+	repo, err := NewLocalRepository(&GiteaRepository{}, dir)
+	require.NoError(t, err)
+	release, err := repo.GetLatestRelease(&GetLatestReleaseConfig{})
+	require.NoError(t, err)
+	commits, err := repo.GetCommitsUntil("", release.SHA)
+	require.NoError(t, err)
+	require.Equal(t, "Merge branch 'search'", commits[0].Raw[0])
+	require.Len(t, commits[0].Parents, 2)
+	require.Len(t, commits[1].Parents, 1)
+
+	filtered := FilterCommits(&config.Config{IgnoreMergeCommits: true}, commits, release)
+	require.Len(t, filtered, len(commits)-1)
+	require.Equal(t, release.SHA, filtered[len(filtered)-1].SHA)
+	for _, commit := range filtered {
+		require.NotEqual(t, commits[0].SHA, commit.SHA)
+	}
+}
+
+func TestLocalRepositorySince(t *testing.T) {
+	dir, cleanup := newLocalTestRepo(t)
+	defer cleanup()
+	repo, err := NewLocalRepository(&GiteaRepository{owner: "owner", repo: "test-repo"}, dir)
+	require.NoError(t, err)
+	release, err := repo.GetLatestRelease(&GetLatestReleaseConfig{})
+	require.NoError(t, err)
+
+	repo.Since = time.Now().Add(-time.Hour)
+	commits, err := repo.GetCommitsUntil("", release.SHA)
+	require.NoError(t, err)
+	require.Len(t, commits, 3)
+
+	repo.Since = time.Now().Add(time.Hour)
+	_, err = repo.GetCommitsUntil("", release.SHA)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "increase --since-duration")
+}
+
+func TestLocalRepositoryShallowClone(t *testing.T) {
+	origin, cleanup := newLocalTestRepo(t)
+	defer cleanup()
+	dir, err := ioutil.TempDir("", "semrel-shallow")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	// a clone like the default checkout of most CIs
+	out, err := exec.Command("git", "clone", "-q", "--depth=1", "file://"+origin, dir).CombinedOutput()
+	require.NoError(t, err, string(out))
+
+	_, err = NewLocalRepository(&GiteaRepository{}, dir)
+	require.Equal(t, ErrShallowClone, err)
+
+	shallow, err := Unshallow(dir)
+	require.NoError(t, err)
+	require.True(t, shallow)
+	repo, err := NewLocalRepository(&GiteaRepository{}, dir)
+	require.NoError(t, err)
+	release, err := repo.GetLatestRelease(&GetLatestReleaseConfig{})
+	require.NoError(t, err)
+	require.Equal(t, "1.1.0", release.Version.String())
+
+	// the full clone is left as it is
+	shallow, err = Unshallow(dir)
+	require.NoError(t, err)
+	require.False(t, shallow)
+}
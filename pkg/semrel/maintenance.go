@@ -0,0 +1,30 @@
+package semrel
+
+import (
+	"regexp"
+	"strings"
+)
+
+// branchPatternToRegexp converts a maintenance branch pattern to a regexp, N matches a number and * any
+// characters, everything else is matched literally (e.g. N.N.x matches 1.2.x and release/* matches release/1.x)
+func branchPatternToRegexp(pattern string) *regexp.Regexp {
+	var expr strings.Builder
+	expr.WriteString("^")
+	for _, r := range pattern {
+		switch r {
+		case 'N':
+			expr.WriteString(`\d+`)
+		case '*':
+			expr.WriteString(".*")
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	expr.WriteString("$")
+	return regexp.MustCompile(expr.String())
+}
+
+// MatchesMaintenanceBranch reports whether the branch matches the maintenance branch pattern
+func MatchesMaintenanceBranch(pattern, branch string) bool {
+	return branchPatternToRegexp(pattern).MatchString(branch)
+}
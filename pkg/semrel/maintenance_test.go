@@ -0,0 +1,29 @@
+package semrel
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatchesMaintenanceBranch(t *testing.T) {
+	testCases := []struct {
+		pattern, branch string
+		matches         bool
+	}{
+		{"N.N.x", "1.2.x", true},
+		{"N.N.x", "10.20.x", true},
+		{"N.x", "1.x", true},
+		{"N.N.x", "1.x", false},
+		{"N.N.x", "1.2.3", false},
+		{"N.N.x", "feature/1.2.x", false},
+		{"N.N.x", "1.2.x-hotfix", false},
+		{"N.N.x", "1a2.x", false},
+		{"release/N.x", "release/2.x", true},
+		{"release/*", "release/anything", true},
+		{"release/*", "master", false},
+	}
+	for _, tc := range testCases {
+		require.Equal(t, tc.matches, MatchesMaintenanceBranch(tc.pattern, tc.branch), "%s %s", tc.pattern, tc.branch)
+	}
+}
@@ -0,0 +1,83 @@
+package semrel
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/go-github/v30/github"
+)
+
+// githubRateLimitError replaces the rate limit errors of the GitHub client with a message explaining how to resolve them
+func githubRateLimitError(err error) error {
+	var rateErr *github.RateLimitError
+	if errors.As(err, &rateErr) {
+		reset := rateErr.Rate.Reset.Time
+		return fmt.Errorf("GitHub API rate limit of %d requests exceeded, it resets at %s (in %s): wait until then, "+
+			"use --wait-for-rate-limit or a token with a higher rate limit: %w",
+			rateErr.Rate.Limit, reset.UTC().Format(time.RFC3339), time.Until(reset).Round(time.Second), err)
+	}
+	var abuseErr *github.AbuseRateLimitError
+	if errors.As(err, &abuseErr) {
+		return fmt.Errorf("GitHub secondary rate limit exceeded (retry after %s), reduce the number of concurrent releases "+
+			"or use --wait-for-rate-limit: %w", abuseErr.GetRetryAfter(), err)
+	}
+	return err
+}
+
+// rateLimitTransport waits until the rate limit of GitHub resets if a read request exhausted it,
+// waiting is enabled by the WaitForRateLimit field of the repository
+type rateLimitTransport struct {
+	base http.RoundTripper
+	repo *GitHubRepository
+}
+
+// rateLimitReset returns the reset time if the response used the last request of the rate limit
+func rateLimitReset(resp *http.Response) (time.Time, bool) {
+	if resp.Header.Get("X-RateLimit-Remaining") != "0" {
+		return time.Time{}, false
+	}
+	seconds, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(seconds, 0), true
+}
+
+// sleepRequest waits for the duration unless the request is canceled, it is replaced in the tests
+var sleepRequest = func(req *http.Request, d time.Duration) error {
+	select {
+	case <-req.Context().Done():
+		return req.Context().Err()
+	case <-time.After(d):
+		return nil
+	}
+}
+
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	for {
+		resp, err := t.base.RoundTrip(req)
+		if err != nil || !t.repo.WaitForRateLimit || (req.Method != http.MethodGet && req.Method != http.MethodHead) {
+			return resp, err
+		}
+		reset, ok := rateLimitReset(resp)
+		if !ok {
+			return resp, nil
+		}
+		// one second of leeway for clock differences
+		if err := sleepRequest(req, time.Until(reset)+time.Second); err != nil {
+			resp.Body.Close()
+			return nil, err
+		}
+		// a successful response is returned after waiting, otherwise the client would reject the next request itself
+		if resp.StatusCode != http.StatusForbidden && resp.StatusCode != http.StatusTooManyRequests {
+			return resp, nil
+		}
+		_, _ = io.Copy(ioutil.Discard, resp.Body)
+		resp.Body.Close()
+	}
+}
@@ -0,0 +1,89 @@
+package semrel
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Masterminds/semver"
+)
+
+var (
+	// ErrRepoNotFound is returned by GetInfo if the repository does not exist or the token can not access it
+	ErrRepoNotFound = errors.New("repository not found")
+	// ErrNoReleases is returned by GetLatestRelease if the repository has no tags at all
+	ErrNoReleases = errors.New("no releases found")
+)
+
+// errReleaseOutsideWindow is returned if the commits listed since the time do not reach the commit of the previous
+// release, the new version would be calculated from an incomplete history otherwise
+func errReleaseOutsideWindow(releaseSHA string, since time.Time) error {
+	return fmt.Errorf("the commit %s of the previous release is older than %s, increase --since-duration", releaseSHA, since.UTC().Format(time.RFC3339))
+}
+
+// InitialRelease is the release before the first release, a zero version without sha
+func InitialRelease() *Release {
+	return &Release{"", &semver.Version{}}
+}
+
+// Repository is implemented by every provider (GitHub, GitLab, Bitbucket, Gitea and Gogs) and by the LocalRepository
+// and SplitRepository wrapping them
+type Repository interface {
+	// GetInfo returns the default branch and whether the repository is private, ErrRepoNotFound if it does not exist
+	GetInfo() (string, bool, error)
+	// GetCommits returns the commits reachable from sha, newest first
+	GetCommits(sha string) ([]*Commit, error)
+	// GetLatestRelease returns the latest release, the InitialRelease if no tag matches and ErrNoReleases if there are no tags
+	GetLatestRelease(latest *GetLatestReleaseConfig) (*Release, error)
+	// CreateRelease creates the tag and, if the provider supports it, the release
+	CreateRelease(release *CreateReleaseConfig) error
+	Owner() string
+	Repo() string
+	// Provider is the name of the provider, e.g. GitHub
+	Provider() string
+}
+
+// CommitsUntilRepository is implemented by the repositories that stop listing the commits after the commit of the
+// previous release instead of walking the whole history
+type CommitsUntilRepository interface {
+	// GetCommitsUntil is like GetCommits but stops after the commit releaseSHA, an empty releaseSHA lists all commits
+	GetCommitsUntil(sha, releaseSHA string) ([]*Commit, error)
+}
+
+// GetCommitsUntil lists the commits reachable from sha up to the commit releaseSHA, repositories that do not implement
+// CommitsUntilRepository list all commits which are cut after releaseSHA
+func GetCommitsUntil(repo Repository, sha, releaseSHA string) ([]*Commit, error) {
+	if r, ok := repo.(CommitsUntilRepository); ok {
+		return r.GetCommitsUntil(sha, releaseSHA)
+	}
+	commits, err := repo.GetCommits(sha)
+	if err != nil || releaseSHA == "" {
+		return commits, err
+	}
+	for i, commit := range commits {
+		if commit.SHA == releaseSHA {
+			return commits[:i+1], nil
+		}
+	}
+	return commits, nil
+}
+
+var (
+	_ Repository = (*GitHubRepository)(nil)
+	_ Repository = (*GitLabRepository)(nil)
+	_ Repository = (*BitbucketRepository)(nil)
+	_ Repository = (*GiteaRepository)(nil)
+	_ Repository = (*GogsRepository)(nil)
+	_ Repository = (*LocalRepository)(nil)
+	_ Repository = (*SplitRepository)(nil)
+)
+
+var (
+	_ CommitsUntilRepository = (*GitHubRepository)(nil)
+	_ CommitsUntilRepository = (*GitLabRepository)(nil)
+	_ CommitsUntilRepository = (*BitbucketRepository)(nil)
+	_ CommitsUntilRepository = (*GiteaRepository)(nil)
+	_ CommitsUntilRepository = (*GogsRepository)(nil)
+	_ CommitsUntilRepository = (*LocalRepository)(nil)
+	_ CommitsUntilRepository = (*SplitRepository)(nil)
+)
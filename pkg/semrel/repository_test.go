@@ -0,0 +1,149 @@
+package semrel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"testing"
+
+	"github.com/Masterminds/semver"
+	"github.com/stretchr/testify/require"
+)
+
+// testRepository checks the behavior all providers share against their test server,
+// the fixtures of every provider contain the same commits and tags
+func testRepository(t *testing.T, repo Repository) {
+	require.NotEmpty(t, repo.Provider())
+
+	defaultBranch, isPrivate, err := repo.GetInfo()
+	require.NoError(t, err)
+	require.Equal(t, "master", defaultBranch)
+	require.True(t, isPrivate)
+
+	commits, err := repo.GetCommits("deadbeef")
+	require.NoError(t, err)
+	require.Len(t, commits, 4)
+	expectedChanges := []Change{{Minor: true}, {Patch: true}, {}, {Major: true}}
+	for i, sha := range []string{"abcd", "dcba", "cdba", "efcd"} {
+		require.Equal(t, sha, commits[i].SHA)
+		require.Equal(t, expectedChanges[i], commits[i].Change)
+	}
+
+	commits, err = GetCommitsUntil(repo, "deadbeef", "dcba")
+	require.NoError(t, err)
+	require.Len(t, commits, 2)
+	require.Equal(t, "dcba", commits[1].SHA)
+
+	release, err := repo.GetLatestRelease(&GetLatestReleaseConfig{})
+	require.NoError(t, err)
+	require.Equal(t, "2020.4.19", release.Version.String())
+	release, err = repo.GetLatestRelease(&GetLatestReleaseConfig{Match: regexp.MustCompile("^v[0-9]*")})
+	require.NoError(t, err)
+	require.Equal(t, "deadbeef", release.SHA)
+	require.Equal(t, "2.0.0", release.Version.String())
+
+	err = repo.CreateRelease(&CreateReleaseConfig{Changelog: "changelog", NewVersion: semver.MustParse("2.0.0"), SHA: "deadbeef"})
+	require.NoError(t, err)
+}
+
+func TestRepositories(t *testing.T) {
+	testCases := []struct {
+		name    string
+		newRepo func(t *testing.T) (Repository, *httptest.Server)
+	}{
+		{"GitHub", func(t *testing.T) (Repository, *httptest.Server) { return getNewGithubTestRepo(t) }},
+		{"GitLab", func(t *testing.T) (Repository, *httptest.Server) { return getNewGitlabTestRepo(t) }},
+		{"Bitbucket", func(t *testing.T) (Repository, *httptest.Server) { return getNewBitbucketTestRepo(t) }},
+		{"Gitea", func(t *testing.T) (Repository, *httptest.Server) { return getNewGiteaTestRepo(t) }},
+		{"Gogs", func(t *testing.T) (Repository, *httptest.Server) { return getNewGogsTestRepo(t) }},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo, ts := tc.newRepo(t)
+			defer ts.Close()
+			testRepository(t, repo)
+		})
+	}
+}
+
+// newEmptyTestRepos creates a repository of every provider against the test server
+func newEmptyTestRepos(t *testing.T, ts *httptest.Server) map[string]Repository {
+	github, err := NewGitHubRepository(context.TODO(), "", "", "", "owner/test-repo", "token")
+	require.NoError(t, err)
+	github.Client.BaseURL, _ = url.Parse(ts.URL + "/")
+	gitlab, err := NewGitLabRepository(context.TODO(), ts.URL, "owner/test-repo", "token", "", "1")
+	require.NoError(t, err)
+	bitbucket, err := NewBitbucketRepository(context.TODO(), "owner/test-repo", "token")
+	require.NoError(t, err)
+	bitbucket.baseURL = ts.URL
+	gitea, err := NewGiteaRepository(context.TODO(), ts.URL, "owner/test-repo", "token")
+	require.NoError(t, err)
+	gogs, err := NewGogsRepository(context.TODO(), ts.URL, "owner/test-repo", "token")
+	require.NoError(t, err)
+	return map[string]Repository{"GitHub": github, "GitLab": gitlab, "Bitbucket": bitbucket, "Gitea": gitea, "Gogs": gogs}
+}
+
+func TestRepositoriesNotFound(t *testing.T) {
+	ts := httptest.NewServer(http.NotFoundHandler())
+	defer ts.Close()
+	for name, repo := range newEmptyTestRepos(t, ts) {
+		_, _, err := repo.GetInfo()
+		require.True(t, errors.Is(err, ErrRepoNotFound), "%s: %v", name, err)
+	}
+}
+
+//nolint:errcheck
+func TestRepositoriesNoReleases(t *testing.T) {
+	// the tags of GitHub, Bitbucket and Gitea may not be found if there are none, Gogs lists an empty array
+	ts := httptest.NewServer(http.NotFoundHandler())
+	defer ts.Close()
+	for name, repo := range newEmptyTestRepos(t, ts) {
+		if name == "GitLab" || name == "Gogs" {
+			continue
+		}
+		_, err := repo.GetLatestRelease(&GetLatestReleaseConfig{})
+		require.True(t, errors.Is(err, ErrNoReleases), "%s: %v", name, err)
+	}
+
+	ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/owner/test-repo/git/refs/tags", "/api/v4/projects/1/repository/tags", "/api/v1/repos/owner/test-repo/tags":
+			fmt.Fprint(w, "[]")
+		case "/repositories/owner/test-repo/refs/tags":
+			fmt.Fprint(w, `{"values": []}`)
+		case "/api/v4/":
+			fmt.Fprint(w, "{}")
+		default:
+			http.Error(w, "invalid route", http.StatusNotImplemented)
+		}
+	}))
+	defer ts.Close()
+	for name, repo := range newEmptyTestRepos(t, ts) {
+		_, err := repo.GetLatestRelease(&GetLatestReleaseConfig{})
+		require.True(t, errors.Is(err, ErrNoReleases), "%s: %v", name, err)
+	}
+}
+
+// commitsOnlyRepository hides GetCommitsUntil like a repository implemented before CommitsUntilRepository
+type commitsOnlyRepository struct {
+	Repository
+}
+
+func TestGetCommitsUntil(t *testing.T) {
+	stub := &stubRepository{commits: []*Commit{{SHA: "cccc"}, {SHA: "bbbb"}, {SHA: "aaaa"}}}
+	for _, repo := range []Repository{stub, commitsOnlyRepository{stub}} {
+		commits, err := GetCommitsUntil(repo, "", "bbbb")
+		require.NoError(t, err)
+		require.Equal(t, []string{"cccc", "bbbb"}, commitSHAs(commits))
+
+		commits, err = GetCommitsUntil(repo, "", "")
+		require.NoError(t, err)
+		require.Len(t, commits, 3)
+	}
+	_, ok := Repository(commitsOnlyRepository{stub}).(CommitsUntilRepository)
+	require.False(t, ok)
+}
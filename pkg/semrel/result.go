@@ -0,0 +1,56 @@
+package semrel
+
+import (
+	"github.com/Masterminds/semver"
+)
+
+// ReleaseResult describes the computed release in a machine readable form
+type ReleaseResult struct {
+	Version         string `json:"version"`
+	PreviousVersion string `json:"previousVersion"`
+	Bump            string `json:"bump"`
+	CommitCount     int    `json:"commitCount"`
+	Prerelease      bool   `json:"prerelease"`
+	Dry             bool   `json:"dry"`
+	Tag             string `json:"tag,omitempty"`
+	Changelog       string `json:"changelog,omitempty"`
+	// NewVersion is the released version without the build metadata and SHA the released commit, both set by Run
+	NewVersion *semver.Version `json:"-"`
+	SHA        string          `json:"-"`
+}
+
+// GetBump returns the level (major, minor, patch, prerelease or none) between the two versions
+func GetBump(previousVersion, newVersion *semver.Version) string {
+	switch {
+	case newVersion == nil:
+		return "none"
+	case newVersion.Major() != previousVersion.Major():
+		return "major"
+	case newVersion.Minor() != previousVersion.Minor():
+		return "minor"
+	case newVersion.Patch() != previousVersion.Patch():
+		return "patch"
+	case newVersion.Prerelease() != previousVersion.Prerelease():
+		return "prerelease"
+	}
+	return "none"
+}
+
+// NewReleaseResult creates the result for the commits since the latest release, newVersion is nil if there is no release
+func NewReleaseResult(commits []*Commit, latestRelease *Release, newVersion *semver.Version, prerelease bool) *ReleaseResult {
+	result := &ReleaseResult{
+		PreviousVersion: latestRelease.Version.String(),
+		Bump:            GetBump(latestRelease.Version, newVersion),
+	}
+	for _, commit := range commits {
+		if latestRelease.SHA == commit.SHA {
+			break
+		}
+		result.CommitCount++
+	}
+	if newVersion != nil {
+		result.Version = newVersion.String()
+		result.Prerelease = prerelease || newVersion.Prerelease() != ""
+	}
+	return result
+}
@@ -0,0 +1,44 @@
+package semrel
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/Masterminds/semver"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetBump(t *testing.T) {
+	testCases := []struct {
+		previousVersion string
+		newVersion      string
+		expectedBump    string
+	}{
+		{"1.0.0", "2.0.0", "major"},
+		{"1.0.0", "1.1.0", "minor"},
+		{"1.0.0", "1.0.1", "patch"},
+		{"2.0.0-beta.1", "2.0.0-beta.2", "prerelease"},
+		{"1.0.0", "", "none"},
+	}
+	for _, tc := range testCases {
+		var newVersion *semver.Version
+		if tc.newVersion != "" {
+			newVersion = semver.MustParse(tc.newVersion)
+		}
+		require.Equal(t, tc.expectedBump, GetBump(semver.MustParse(tc.previousVersion), newVersion))
+	}
+}
+
+func TestNewReleaseResult(t *testing.T) {
+	commits := []*Commit{{SHA: "a"}, {SHA: "b"}, {SHA: "c"}}
+	release := &Release{SHA: "c", Version: semver.MustParse("1.2.3")}
+	result := NewReleaseResult(commits, release, semver.MustParse("1.3.0-beta.1"), false)
+	data, err := json.Marshal(result)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"version": "1.3.0-beta.1", "previousVersion": "1.2.3", "bump": "minor", "commitCount": 2, "prerelease": true, "dry": false}`, string(data))
+
+	result = NewReleaseResult(commits, release, nil, false)
+	require.Equal(t, "", result.Version)
+	require.Equal(t, "none", result.Bump)
+	require.False(t, result.Prerelease)
+}
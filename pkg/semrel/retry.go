@@ -0,0 +1,96 @@
+package semrel
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+var errBodyNotRewindable = errors.New("request can not be retried as its body can not be rewound")
+
+// retryTransport retries requests which failed with a network error, a 5xx, a 429 or a rate limited 403 response
+type retryTransport struct {
+	base    http.RoundTripper
+	retries int
+	wait    time.Duration
+}
+
+// WithRetries returns a context whose HTTP client retries failed API requests with an exponential backoff starting at wait,
+// the client is used by all providers (it is stored under the oauth2.HTTPClient key the GitHub client already uses)
+func WithRetries(ctx context.Context, retries int, wait time.Duration) context.Context {
+	if retries <= 0 {
+		return ctx
+	}
+	client := &http.Client{Transport: &retryTransport{base: http.DefaultTransport, retries: retries, wait: wait}}
+	return context.WithValue(ctx, oauth2.HTTPClient, client)
+}
+
+// httpClient returns the HTTP client of the context or the default client
+func httpClient(ctx context.Context) *http.Client {
+	if client, ok := ctx.Value(oauth2.HTTPClient).(*http.Client); ok && client != nil {
+		return client
+	}
+	return http.DefaultClient
+}
+
+func shouldRetry(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	switch {
+	case resp.StatusCode >= 500, resp.StatusCode == http.StatusTooManyRequests:
+		return true
+	case resp.StatusCode == http.StatusForbidden:
+		// secondary rate limits of GitHub
+		return resp.Header.Get("Retry-After") != "" || resp.Header.Get("X-RateLimit-Remaining") == "0"
+	}
+	return false
+}
+
+// retryWait respects the Retry-After header (in seconds), otherwise the wait is doubled for every attempt
+func retryWait(resp *http.Response, wait time.Duration, attempt int) time.Duration {
+	if resp != nil {
+		if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds >= 0 {
+			return time.Duration(seconds) * time.Second
+		}
+	}
+	return wait << uint(attempt)
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		attemptReq := req
+		if attempt > 0 && req.Body != nil {
+			// the body was consumed by the previous attempt
+			if req.GetBody == nil {
+				return nil, errBodyNotRewindable
+			}
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			attemptReq = req.Clone(req.Context())
+			attemptReq.Body = body
+		}
+		resp, err := t.base.RoundTrip(attemptReq)
+		if attempt >= t.retries || req.Context().Err() != nil || !shouldRetry(resp, err) {
+			return resp, err
+		}
+		wait := retryWait(resp, t.wait, attempt)
+		if resp != nil {
+			_, _ = io.Copy(ioutil.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		select {
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		case <-time.After(wait):
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package semrel
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Masterminds/semver"
+	"github.com/stretchr/testify/require"
+)
+
+// flakyHandler fails the first failures requests with the given status
+func flakyHandler(failures, status int, handler http.HandlerFunc) (http.HandlerFunc, *int) {
+	requests := 0
+	return func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests <= failures {
+			http.Error(w, "flaky", status)
+			return
+		}
+		handler(w, r)
+	}, &requests
+}
+
+func TestRetryTransport(t *testing.T) {
+	handler, requests := flakyHandler(2, http.StatusBadGateway, giteaHandler)
+	ts := httptest.NewServer(handler)
+	defer ts.Close()
+
+	repo, err := NewGiteaRepository(WithRetries(context.TODO(), 3, time.Millisecond), ts.URL, "owner/test-repo", "token")
+	require.NoError(t, err)
+	defaultBranch, _, err := repo.GetInfo()
+	require.NoError(t, err)
+	require.Equal(t, "master", defaultBranch)
+	require.Equal(t, 3, *requests)
+
+	// the body of the release is sent again on every attempt
+	*requests = 0
+	err = repo.CreateRelease(&CreateReleaseConfig{Changelog: "changelog", NewVersion: semver.MustParse("2.0.0"), SHA: "deadbeef"})
+	require.NoError(t, err)
+	require.Equal(t, 3, *requests)
+}
+
+func TestRetryTransportGivesUp(t *testing.T) {
+	handler, requests := flakyHandler(10, http.StatusServiceUnavailable, giteaHandler)
+	ts := httptest.NewServer(handler)
+	defer ts.Close()
+
+	repo, err := NewGiteaRepository(WithRetries(context.TODO(), 2, time.Millisecond), ts.URL, "owner/test-repo", "token")
+	require.NoError(t, err)
+	_, _, err = repo.GetInfo()
+	require.Error(t, err)
+	require.Equal(t, 3, *requests)
+
+	// client errors are not retried
+	handler, requests = flakyHandler(10, http.StatusNotFound, giteaHandler)
+	ts404 := httptest.NewServer(handler)
+	defer ts404.Close()
+	repo, err = NewGiteaRepository(WithRetries(context.TODO(), 2, time.Millisecond), ts404.URL, "owner/test-repo", "token")
+	require.NoError(t, err)
+	_, _, err = repo.GetInfo()
+	require.Error(t, err)
+	require.Equal(t, 1, *requests)
+}
+
+func TestRetryWait(t *testing.T) {
+	resp := &http.Response{Header: http.Header{}}
+	require.Equal(t, time.Second, retryWait(resp, time.Second, 0))
+	require.Equal(t, 4*time.Second, retryWait(resp, time.Second, 2))
+	resp.Header.Set("Retry-After", "7")
+	require.Equal(t, 7*time.Second, retryWait(resp, time.Second, 2))
+	require.Equal(t, 2*time.Second, retryWait(nil, time.Second, 1))
+
+	rateLimited := &http.Response{StatusCode: http.StatusForbidden, Header: http.Header{}}
+	require.False(t, shouldRetry(rateLimited, nil))
+	rateLimited.Header.Set("X-RateLimit-Remaining", "0")
+	require.True(t, shouldRetry(rateLimited, nil))
+	require.True(t, shouldRetry(nil, fmt.Errorf("connection reset")))
+}
@@ -0,0 +1,300 @@
+package semrel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/go-semantic-release/semantic-release/pkg/config"
+	"github.com/go-semantic-release/semantic-release/pkg/logging"
+)
+
+// ErrNoChange is returned by Run if no commit since the latest release bumps the version
+var ErrNoChange = errors.New("no change")
+
+// latestStableRelease ignores the prerelease channel of the latest release config
+func latestStableRelease(repo Repository, latest GetLatestReleaseConfig) (*Release, error) {
+	latest.PrereleaseChannel = ""
+	latest.IncludePrereleases = false
+	release, err := repo.GetLatestRelease(&latest)
+	if errors.Is(err, ErrNoReleases) {
+		return InitialRelease(), nil
+	}
+	return release, err
+}
+
+// NewLatestReleaseConfig returns the config to find the latest release with the match, tag format, prerelease
+// channel and maintained version of the configuration
+func NewLatestReleaseConfig(conf *config.Config) (*GetLatestReleaseConfig, error) {
+	var matchRegex *regexp.Regexp
+	if match := strings.TrimSpace(conf.Match); match != "" {
+		matchRegex = regexp.MustCompile("^" + match)
+	}
+	format := conf.TagFormat
+	if format == "" && !conf.GitLab && !conf.Bitbucket && !conf.Gitea && !conf.Gogs {
+		// only the tags on GitHub were prefixed with the package name before the tag format could be set
+		format = DefaultGitHubTagFormat
+	}
+	tagFormat, err := NewTagFormat(format, conf.PkgName)
+	if err != nil {
+		return nil, err
+	}
+	latest := &GetLatestReleaseConfig{
+		Match:              matchRegex,
+		CoerceVersions:     conf.CoerceVersions,
+		TagFormat:          tagFormat,
+		PrereleaseChannel:  conf.PrereleaseChannel,
+		IncludePrereleases: conf.IncludePrereleases,
+	}
+	if conf.BetaRelease != nil {
+		latest.VersionRange = conf.BetaRelease.MaintainedVersion
+	}
+	return latest, nil
+}
+
+func shortSHA(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
+
+// BeforeRelease is called by RunWithHook after the changelog file is written and right before the release is created.
+// It may return the sha of another commit to release, e.g. of a commit of the updated files, or an empty sha.
+type BeforeRelease func(result *ReleaseResult) (string, error)
+
+// Run releases the commit conf.CurrentSHA (the newest commit if it is empty) of the branch conf.CurrentBranch: it calculates the new version from the
+// commits since the latest release, generates the changelog and creates the release unless conf.Dry is set.
+// ErrNoChange is returned with the result if there is nothing to release.
+func Run(ctx context.Context, conf *config.Config, repo Repository, logger *logging.Logger) (*ReleaseResult, error) {
+	return RunWithHook(ctx, conf, repo, logger, nil)
+}
+
+// RunWithHook is like Run but calls beforeRelease before the release is created, it is not called on dry runs
+func RunWithHook(ctx context.Context, conf *config.Config, repo Repository, logger *logging.Logger, beforeRelease BeforeRelease) (*ReleaseResult, error) {
+	assets, err := ParseAssets(conf.Assets)
+	if err != nil {
+		return nil, err
+	}
+	latestConfig, err := NewLatestReleaseConfig(conf)
+	if err != nil {
+		return nil, err
+	}
+	var commitPattern *CommitPattern
+	if conf.CommitPattern != "" {
+		if commitPattern, err = NewCommitPattern(conf.CommitPattern); err != nil {
+			return nil, err
+		}
+	}
+	currentBranch, currentSha := conf.CurrentBranch, conf.CurrentSHA
+
+	logger.Info("getting latest release...")
+	if latestConfig.Match != nil {
+		logger.Infof("getting latest release matching %s...", strings.TrimSpace(conf.Match))
+	}
+	release, err := repo.GetLatestRelease(latestConfig)
+	if errors.Is(err, ErrNoReleases) {
+		logger.Info("no releases found, this is the first release")
+		release, err = InitialRelease(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	logger.Info("found version: " + release.Version.String())
+
+	if strings.Contains(latestConfig.VersionRange, "-") && release.Version.Prerelease() == "" {
+		return nil, errors.New("no pre-release for this version possible")
+	}
+
+	// a promotion releases the commit of the prerelease with the commits since the latest stable release
+	previousRelease := release
+	if conf.Promote {
+		if previousRelease, err = latestStableRelease(repo, *latestConfig); err != nil {
+			return nil, err
+		}
+		currentSha = release.SHA
+	}
+
+	// the commits since the latest stable release are fetched for the changelog, the version is still
+	// calculated from the commits since the latest prerelease as they stop at its commit
+	changelogRelease := release
+	if conf.ChangelogSince == ChangelogSinceLastStable && !conf.Promote && release.Version.Prerelease() != "" {
+		if previousRelease, err = latestStableRelease(repo, *latestConfig); err != nil {
+			return nil, err
+		}
+		changelogRelease = previousRelease
+		logger.Info("generating the changelog since the latest stable version: " + previousRelease.Version.String())
+	}
+
+	logger.Info("getting commits...")
+	commits, err := GetCommitsUntil(repo, currentSha, previousRelease.SHA)
+	if err != nil {
+		return nil, err
+	}
+	logger.Debugf("found %d commits since %s", len(commits), shortSHA(previousRelease.SHA))
+	// without a sha the commits start at the head of the default branch, its newest commit is released
+	if currentSha == "" && len(commits) > 0 {
+		currentSha = commits[0].SHA
+		logger.Info("no current sha set, using the newest commit: " + shortSHA(currentSha))
+	}
+	// the latest prerelease stays a boundary if the commits since the latest stable release are fetched
+	commits = FilterCommits(conf, commits, previousRelease, release)
+	logger.Debugf("%d commits left after filtering", len(commits))
+	if commitPattern != nil {
+		ApplyCommitPattern(commits, commitPattern, NewBumpRules(conf.BumpRules))
+	} else if len(conf.BumpRules) > 0 {
+		ApplyBumpRules(commits, NewBumpRules(conf.BumpRules))
+	}
+	if conf.ParseSquashBody {
+		ApplySquashBodies(commits, commitPattern, NewBumpRules(conf.BumpRules))
+	}
+
+	logger.Info("calculating new version...")
+	var decision *VersionDecision
+	if conf.Promote {
+		if decision, err = PromoteRelease(release, previousRelease, commits); err != nil {
+			return nil, err
+		}
+		release = previousRelease
+		changelogRelease = previousRelease
+	} else {
+		decision = GetNewVersionWithReason(conf, commits, release)
+	}
+	newVer := decision.Version
+	result := NewReleaseResult(commits, release, newVer, conf.Prerelease)
+	result.Dry = conf.Dry
+	if newVer == nil {
+		logger.Infof("no feat, fix or breaking change commits found since the latest release (seen: %s)", SummarizeCommitTypes(commits, release))
+		return result, ErrNoChange
+	}
+	logger.Infof("new version: %s (%s bump because of %s)", newVer.String(), decision.Bump, decision.Reason())
+
+	if conf.RequireSignedCommits {
+		err = CheckCommitSignatures(commits, release)
+	} else if conf.RequireSignedReleasingCommits {
+		err = CheckCommitSignatures(decision.Commits, release)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// the tag uses the version without the build metadata
+	metaVer, err := SetBuildMetadata(newVer, conf.BuildMetadata, &BuildMetadata{
+		SHA:      currentSha,
+		ShortSHA: shortSHA(currentSha),
+		Branch:   currentBranch,
+	})
+	if err != nil {
+		return nil, err
+	}
+	result.Version = metaVer.String()
+	result.NewVersion = newVer
+	result.SHA = currentSha
+
+	logger.Info("generating changelog...")
+	changelog, err := GenerateChangelog(conf, commits, changelogRelease, metaVer)
+	if err != nil {
+		return nil, err
+	}
+	result.Tag = latestConfig.TagFormat.Format(newVer.String())
+	result.Changelog = changelog
+
+	if conf.Dry {
+		return result, nil
+	}
+
+	if conf.Changelog != "" {
+		if err := WriteChangelogFile(conf.Changelog, changelog, conf.ChangelogPrepend, conf.ChangelogHeaderMarker); err != nil {
+			return nil, err
+		}
+	}
+
+	releaseTitle := ""
+	if conf.ReleaseTitleFromChangelog {
+		releaseTitle = GetReleaseTitle(changelog)
+	}
+
+	if len(assets) > 0 && repo.Provider() != "GitLab" {
+		logger.Warnf("release assets are not supported on %s yet, ignoring them", repo.Provider())
+	}
+
+	if conf.ReleaseDiscussionCategory != "" && repo.Provider() != "GitHub" {
+		logger.Warnf("release discussions are not supported on %s, ignoring the category", repo.Provider())
+	}
+
+	// the generated notes replace the changelog unless it is kept above them
+	releaseChangelog := changelog
+	if conf.UseGitHubGeneratedNotes {
+		if repo.Provider() != "GitHub" {
+			logger.Warnf("generated release notes are not supported on %s, using the changelog", repo.Provider())
+		} else if !conf.GitHubGeneratedNotesChangelog {
+			releaseChangelog = ""
+		}
+	}
+
+	var signer TagSigner
+	if conf.SignTags {
+		if repo.Provider() != "GitHub" {
+			return nil, fmt.Errorf("signed tags are not supported on %s", repo.Provider())
+		}
+		if signer, err = NewGPGSigner(conf.SigningKey); err != nil {
+			return nil, err
+		}
+	}
+
+	makeLatest := conf.MarkLatest
+	if makeLatest == "auto" {
+		// the latest release of the whole repository, the release above may be the latest of a maintained version
+		latestRelease, err := repo.GetLatestRelease(&GetLatestReleaseConfig{
+			Match:          latestConfig.Match,
+			CoerceVersions: latestConfig.CoerceVersions,
+			TagFormat:      latestConfig.TagFormat,
+		})
+		if errors.Is(err, ErrNoReleases) {
+			latestRelease, err = InitialRelease(), nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		makeLatest = ResolveMakeLatest(makeLatest, newVer, latestRelease)
+	}
+
+	// nothing has been created yet, a canceled run stops here
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if beforeRelease != nil {
+		sha, err := beforeRelease(result)
+		if err != nil {
+			return nil, err
+		}
+		if sha != "" {
+			currentSha, result.SHA = sha, sha
+		}
+	}
+	logger.Info("creating release...")
+	err = repo.CreateRelease(&CreateReleaseConfig{
+		Changelog:            releaseChangelog,
+		Title:                releaseTitle,
+		MovingTags:           conf.MovingTags,
+		NewVersion:           newVer,
+		Prerelease:           conf.Prerelease,
+		Branch:               currentBranch,
+		SHA:                  currentSha,
+		Timestamp:            conf.ReleaseTimestamp,
+		TagFormat:            latestConfig.TagFormat,
+		Assets:               assets,
+		DiscussionCategory:   conf.ReleaseDiscussionCategory,
+		MakeLatest:           makeLatest,
+		GenerateReleaseNotes: conf.UseGitHubGeneratedNotes && repo.Provider() == "GitHub",
+		Signer:               signer,
+		Logger:               logger.StdLogger(logging.Info),
+		WarnLogger:           logger.StdLogger(logging.Warn),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
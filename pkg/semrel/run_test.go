@@ -0,0 +1,197 @@
+package semrel
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"testing"
+
+	"github.com/Masterminds/semver"
+	"github.com/go-semantic-release/semantic-release/pkg/config"
+	"github.com/go-semantic-release/semantic-release/pkg/logging"
+	"github.com/stretchr/testify/require"
+)
+
+// stubRepository serves fixed commits and the latest release and records the created releases
+type stubRepository struct {
+	commits  []*Commit
+	latest   *Release
+	releases []*CreateReleaseConfig
+}
+
+func (r *stubRepository) GetInfo() (string, bool, error) { return "main", false, nil }
+
+func (r *stubRepository) GetCommits(sha string) ([]*Commit, error) {
+	return r.GetCommitsUntil(sha, "")
+}
+
+func (r *stubRepository) GetCommitsUntil(sha, releaseSHA string) ([]*Commit, error) {
+	commits := make([]*Commit, 0, len(r.commits))
+	found := sha == ""
+	for _, commit := range r.commits {
+		if found = found || commit.SHA == sha; !found {
+			continue
+		}
+		commits = append(commits, commit)
+		if commit.SHA == releaseSHA {
+			break
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("commit %s not found", sha)
+	}
+	return commits, nil
+}
+
+func (r *stubRepository) GetLatestRelease(latest *GetLatestReleaseConfig) (*Release, error) {
+	if r.latest == nil {
+		return nil, ErrNoReleases
+	}
+	return r.latest, nil
+}
+
+func (r *stubRepository) CreateRelease(release *CreateReleaseConfig) error {
+	r.releases = append(r.releases, release)
+	return nil
+}
+
+func (r *stubRepository) Owner() string    { return "owner" }
+func (r *stubRepository) Repo() string     { return "repo" }
+func (r *stubRepository) Provider() string { return "Stub" }
+
+func TestRun(t *testing.T) {
+	logger := logging.New(ioutil.Discard, "semantic-release", logging.Info, "text")
+	repo := &stubRepository{
+		commits: []*Commit{
+			ParseCommit("cccc", "feat(search): fuzzy matching"),
+			ParseCommit("bbbb", "fix: empty query"),
+			ParseCommit("aaaa", "chore: release 1.0.0"),
+		},
+		latest: &Release{SHA: "aaaa", Version: semver.MustParse("1.0.0")},
+	}
+	conf := &config.Config{CurrentBranch: "main", CurrentSHA: "cccc"}
+
+	result, err := Run(context.TODO(), conf, repo, logger)
+	require.NoError(t, err)
+	require.Equal(t, "1.1.0", result.Version)
+	require.Equal(t, "1.0.0", result.PreviousVersion)
+	require.Equal(t, "minor", result.Bump)
+	require.Equal(t, 2, result.CommitCount)
+	require.Equal(t, "v1.1.0", result.Tag)
+	require.Equal(t, "cccc", result.SHA)
+	require.Contains(t, result.Changelog, "* **search:** fuzzy matching (cccc)")
+	require.Contains(t, result.Changelog, "* empty query (bbbb)")
+
+	require.Len(t, repo.releases, 1)
+	release := repo.releases[0]
+	require.Equal(t, "1.1.0", release.NewVersion.String())
+	require.Equal(t, "v1.1.0", release.TagFormat.Format(release.NewVersion.String()))
+	require.Equal(t, "cccc", release.SHA)
+	require.Equal(t, "main", release.Branch)
+	require.Equal(t, result.Changelog, release.Changelog)
+
+	// a dry run computes the release without creating it
+	conf.Dry = true
+	result, err = Run(context.TODO(), conf, repo, logger)
+	require.NoError(t, err)
+	require.True(t, result.Dry)
+	require.Equal(t, "v1.1.0", result.Tag)
+	require.Len(t, repo.releases, 1)
+}
+
+func TestRunDefaultTagFormat(t *testing.T) {
+	logger := logging.New(ioutil.Discard, "semantic-release", logging.Info, "text")
+	newRepo := func() *stubRepository {
+		return &stubRepository{
+			commits: []*Commit{ParseCommit("bbbb", "fix: empty query"), ParseCommit("aaaa", "chore: release 1.0.0")},
+			latest:  &Release{SHA: "aaaa", Version: semver.MustParse("1.0.0")},
+		}
+	}
+	// only the tags on GitHub are prefixed with the package name by default
+	result, err := Run(context.TODO(), &config.Config{CurrentBranch: "main", PkgName: "app", Dry: true}, newRepo(), logger)
+	require.NoError(t, err)
+	require.Equal(t, "app-v1.0.1", result.Tag)
+
+	result, err = Run(context.TODO(), &config.Config{CurrentBranch: "main", PkgName: "app", GitLab: true, Dry: true}, newRepo(), logger)
+	require.NoError(t, err)
+	require.Equal(t, "v1.0.1", result.Tag)
+
+	result, err = Run(context.TODO(), &config.Config{CurrentBranch: "main", PkgName: "app", GitLab: true, TagFormat: "{{.Pkg}}@{{.Version}}", Dry: true}, newRepo(), logger)
+	require.NoError(t, err)
+	require.Equal(t, "app@1.0.1", result.Tag)
+}
+
+func TestRunNoChange(t *testing.T) {
+	logger := logging.New(ioutil.Discard, "semantic-release", logging.Info, "text")
+	repo := &stubRepository{
+		commits: []*Commit{ParseCommit("bbbb", "docs: typo"), ParseCommit("aaaa", "chore: release 1.0.0")},
+		latest:  &Release{SHA: "aaaa", Version: semver.MustParse("1.0.0")},
+	}
+	result, err := Run(context.TODO(), &config.Config{CurrentBranch: "main", CurrentSHA: "bbbb"}, repo, logger)
+	require.Equal(t, ErrNoChange, err)
+	require.Equal(t, "none", result.Bump)
+	require.Empty(t, repo.releases)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	repo.commits = append([]*Commit{ParseCommit("cccc", "fix: crash")}, repo.commits...)
+	_, err = Run(ctx, &config.Config{CurrentBranch: "main", CurrentSHA: "cccc"}, repo, logger)
+	require.Equal(t, context.Canceled, err)
+	require.Empty(t, repo.releases)
+}
+
+func TestRunCurrentSHA(t *testing.T) {
+	logger := logging.New(ioutil.Discard, "semantic-release", logging.Info, "text")
+	repo := &stubRepository{
+		commits: []*Commit{
+			ParseCommit("dddd", "fix: crash"),
+			ParseCommit("cccc", "feat: search"),
+			ParseCommit("aaaa", "chore: release 1.0.0"),
+		},
+		latest: &Release{SHA: "aaaa", Version: semver.MustParse("1.0.0")},
+	}
+
+	// the newest commit is released without a sha
+	result, err := Run(context.TODO(), &config.Config{CurrentBranch: "main", Dry: true}, repo, logger)
+	require.NoError(t, err)
+	require.Equal(t, "dddd", result.SHA)
+	require.Equal(t, 2, result.CommitCount)
+
+	result, err = Run(context.TODO(), &config.Config{CurrentBranch: "main", CurrentSHA: "cccc", Dry: true}, repo, logger)
+	require.NoError(t, err)
+	require.Equal(t, "cccc", result.SHA)
+	require.Equal(t, 1, result.CommitCount)
+
+	_, err = Run(context.TODO(), &config.Config{CurrentBranch: "main", CurrentSHA: "ffff", Dry: true}, repo, logger)
+	require.EqualError(t, err, "commit ffff not found")
+}
+
+func TestRunWithHook(t *testing.T) {
+	logger := logging.New(ioutil.Discard, "semantic-release", logging.Info, "text")
+	repo := &stubRepository{
+		commits: []*Commit{ParseCommit("bbbb", "fix: crash"), ParseCommit("aaaa", "chore: release 1.0.0")},
+		latest:  &Release{SHA: "aaaa", Version: semver.MustParse("1.0.0")},
+	}
+	conf := &config.Config{CurrentBranch: "main", CurrentSHA: "bbbb", Dry: true}
+	called := 0
+	hook := func(result *ReleaseResult) (string, error) {
+		called++
+		require.Equal(t, "v1.0.1", result.Tag)
+		require.Empty(t, repo.releases)
+		return "cccc", nil
+	}
+
+	// the hook is not called on dry runs
+	_, err := RunWithHook(context.TODO(), conf, repo, logger, hook)
+	require.NoError(t, err)
+	require.Equal(t, 0, called)
+
+	// the commit returned by the hook is released
+	conf.Dry = false
+	result, err := RunWithHook(context.TODO(), conf, repo, logger, hook)
+	require.NoError(t, err)
+	require.Equal(t, 1, called)
+	require.Equal(t, "cccc", result.SHA)
+	require.Len(t, repo.releases, 1)
+	require.Equal(t, "cccc", repo.releases[0].SHA)
+}
@@ -1,31 +1,32 @@
 package semrel
 
 import (
+	"bytes"
 	"fmt"
+	"log"
+	"os"
+	"path"
 	"regexp"
 	"sort"
 	"strconv"
 	"strings"
+	"text/template"
 	"time"
 
 	"github.com/Masterminds/semver"
 	"github.com/go-semantic-release/semantic-release/pkg/config"
+	"github.com/go-semantic-release/semantic-release/pkg/logging"
 )
 
-var commitPattern = regexp.MustCompile(`^(\w*)(?:\((.*)\))?\: (.*)$`)
-var breakingPattern = regexp.MustCompile("BREAKING CHANGES?")
+var strictVersionPattern = regexp.MustCompile(`^v?\d+\.\d+\.\d+(?:[-+].*)?$`)
 
-type Change struct {
-	Major, Minor, Patch bool
-}
-
-type Commit struct {
-	SHA     string
-	Raw     []string
-	Type    string
-	Scope   string
-	Message string
-	Change  Change
+// ParseSlug splits a slug into owner and repo, it must contain exactly one / and neither part may be empty
+func ParseSlug(slug string) (string, string, error) {
+	split := strings.Split(slug, "/")
+	if len(split) != 2 || split[0] == "" || split[1] == "" {
+		return "", "", fmt.Errorf("invalid slug %q: must be owner/repo", slug)
+	}
+	return split[0], split[1], nil
 }
 
 type Release struct {
@@ -35,6 +36,14 @@ type Release struct {
 
 type Releases []*Release
 
+// parseTagVersion parses a tag into a version, loose tags (e.g. 1.2, v1) are only accepted if coerce is set
+func parseTagVersion(tag string, coerce bool) (*semver.Version, error) {
+	if !coerce && !strictVersionPattern.MatchString(tag) {
+		return nil, fmt.Errorf("invalid version: %s", tag)
+	}
+	return semver.NewVersion(tag)
+}
+
 func (r Releases) Len() int {
 	return len(r)
 }
@@ -47,6 +56,28 @@ func (r Releases) Swap(i, j int) {
 	r[i], r[j] = r[j], r[i]
 }
 
+// GetLatestReleaseOnChannel is like GetLatestRelease but also considers the prereleases of the channel (e.g. beta)
+// which are newer than the latest release
+func (releases Releases) GetLatestReleaseOnChannel(vrange, channel string) (*Release, error) {
+	if channel == "" || vrange != "" {
+		return releases.GetLatestRelease(vrange)
+	}
+	sort.Sort(releases)
+	for _, r := range releases {
+		preRel := r.Version.Prerelease()
+		if preRel == "" || preRel == channel || strings.HasPrefix(preRel, channel+".") {
+			return r, nil
+		}
+	}
+	return &Release{"", &semver.Version{}}, nil
+}
+
+// rangeSeparatorPattern finds the whitespace between the comparisons of a range like >=1.2.0 <2.0.0
+var rangeSeparatorPattern = regexp.MustCompile(`([^\s,|])\s+([<>=!~^])`)
+
+// GetLatestRelease returns the latest stable release, or the latest release satisfying the version range
+// (e.g. ~1.2, ^1, 1.2.3 or >=1.2.0 <2.0.0). If no release satisfies a range which is a version itself
+// (e.g. 1.3 or 2-beta of a new maintenance line) the version is returned with the commit of the latest release.
 func (releases Releases) GetLatestRelease(vrange string) (*Release, error) {
 	sort.Sort(releases)
 
@@ -65,9 +96,10 @@ func (releases Releases) GetLatestRelease(vrange string) (*Release, error) {
 		return &Release{"", &semver.Version{}}, nil
 	}
 
-	constraint, err := semver.NewConstraint(vrange)
+	// the comparisons of a range are separated by commas in the constraint syntax of semver
+	constraint, err := semver.NewConstraint(rangeSeparatorPattern.ReplaceAllString(strings.TrimSpace(vrange), "$1,$2"))
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("invalid version range %s: %w", vrange, err)
 	}
 	for _, r := range releases {
 		if constraint.Check(r.Version) {
@@ -77,29 +109,146 @@ func (releases Releases) GetLatestRelease(vrange string) (*Release, error) {
 
 	nver, err := semver.NewVersion(vrange)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("no release satisfies the version range %s", vrange)
+	}
+	lastSHA := ""
+	if lastRelease != nil {
+		lastSHA = lastRelease.SHA
 	}
 
 	splitPre := strings.SplitN(vrange, "-", 2)
 	if len(splitPre) == 1 {
-		return &Release{lastRelease.SHA, nver}, nil
+		return &Release{lastSHA, nver}, nil
 	}
 
 	npver, err := nver.SetPrerelease(splitPre[1])
 	if err != nil {
 		return nil, err
 	}
-	return &Release{lastRelease.SHA, &npver}, nil
+	return &Release{lastSHA, &npver}, nil
+}
+
+// CreateReleaseConfig holds everything a Repository needs to publish a release
+type CreateReleaseConfig struct {
+	Changelog  string
+	NewVersion *semver.Version
+	Prerelease bool
+	Branch     string
+	SHA        string
+	// Title is used as release name if set, otherwise the tag is used
+	Title string
+	// MovingTags are the version levels (major, minor) whose tags are moved to the new release
+	MovingTags []string
+	// Timestamp overrides the date of the created tag, zero means the server time is used
+	Timestamp time.Time
+	// TagFormat renders the tags, nil uses the DefaultTagFormat
+	TagFormat *TagFormat
+	// Assets are attached as links to the release, only supported by GitLab
+	Assets []*Asset
+	// DiscussionCategory creates a discussion of the release in the category, only supported by GitHub
+	DiscussionCategory string
+	// MakeLatest is true or false to control whether the release is marked as latest, empty leaves it to the provider
+	MakeLatest string
+	// GenerateReleaseNotes lets GitHub generate the notes of the release, the changelog is put above them
+	GenerateReleaseNotes bool
+	// Signer creates a signed annotated tag if set, only supported by GitHub
+	Signer TagSigner
+	// Logger reports recoverable conditions like an already existing tag, nil discards them
+	Logger *log.Logger
+	// WarnLogger reports fallbacks that change what is released like a tag pushed from the local repository, nil discards them
+	WarnLogger *log.Logger
+}
+
+func (c *CreateReleaseConfig) logf(format string, v ...interface{}) {
+	if c.Logger != nil {
+		c.Logger.Printf(format, v...)
+	}
 }
 
-type Repository interface {
-	GetInfo() (string, bool, error)
-	GetCommits(sha string) ([]*Commit, error)
-	GetLatestRelease(vrange string, re *regexp.Regexp) (*Release, error)
-	CreateRelease(changelog string, newVersion *semver.Version, prerelease bool, branch, sha string) error
-	Owner() string
-	Repo() string
-	Provider() string
+func (c *CreateReleaseConfig) warnf(format string, v ...interface{}) {
+	if c.WarnLogger != nil {
+		c.WarnLogger.Printf(format, v...)
+	}
+}
+
+// ResolveMakeLatest resolves the mark latest mode (true, false or auto), auto only marks the release as latest
+// if the new version is greater than the latest release of the repository which is not the case for maintenance releases
+func ResolveMakeLatest(mode string, newVersion *semver.Version, latestRelease *Release) string {
+	if mode != "auto" {
+		return mode
+	}
+	if newVersion.Prerelease() != "" || !newVersion.GreaterThan(latestRelease.Version) {
+		return "false"
+	}
+	return "true"
+}
+
+// GetLatestReleaseConfig controls which tags are considered as releases
+type GetLatestReleaseConfig struct {
+	VersionRange string
+	Match        *regexp.Regexp
+	// CoerceVersions pads loose tags (e.g. 1.2 to 1.2.0) instead of ignoring them
+	CoerceVersions bool
+	// TagFormat extracts the version of the tags, nil uses the DefaultTagFormat
+	TagFormat *TagFormat
+	// PrereleaseChannel also considers the newer prereleases of the channel (e.g. beta)
+	PrereleaseChannel string
+	// IncludePrereleases also considers the newer prereleases of all channels, a version range is still applied as is
+	IncludePrereleases bool
+}
+
+// selectRelease returns the latest of the releases of the repository, prereleases are ignored unless they are
+// on the channel or included
+func (latest *GetLatestReleaseConfig) selectRelease(releases Releases) (*Release, error) {
+	if !latest.IncludePrereleases || latest.VersionRange != "" {
+		return releases.GetLatestReleaseOnChannel(latest.VersionRange, latest.PrereleaseChannel)
+	}
+	sort.Sort(releases)
+	if len(releases) == 0 {
+		return InitialRelease(), nil
+	}
+	return releases[0], nil
+}
+
+// FilterCommits removes the commits of excluded committers, merge commits if they are ignored and, if a package
+// path is set, the commits not touching the package. The commits of the releases are always kept as boundaries,
+// e.g. the latest prerelease and the latest stable release with --changelog-since last-stable.
+func FilterCommits(conf *config.Config, commits []*Commit, releases ...*Release) []*Commit {
+	if len(conf.ExcludeCommitters) == 0 && conf.PkgPath == "" && !conf.IgnoreMergeCommits {
+		return commits
+	}
+	excluded := make(map[string]bool, len(conf.ExcludeCommitters))
+	for _, committer := range conf.ExcludeCommitters {
+		excluded[committer] = true
+	}
+	boundaries := make(map[string]bool, len(releases))
+	for _, release := range releases {
+		if release.SHA != "" {
+			boundaries[release.SHA] = true
+		}
+	}
+	ret := make([]*Commit, 0, len(commits))
+	for _, commit := range commits {
+		if !boundaries[commit.SHA] && (excluded[commit.Committer] || (conf.IgnoreMergeCommits && len(commit.Parents) > 1) || !touchesPath(commit, conf.PkgPath)) {
+			continue
+		}
+		ret = append(ret, commit)
+	}
+	return ret
+}
+
+// touchesPath returns true if any file of the commit is inside the directory, an empty directory is the whole repository
+func touchesPath(commit *Commit, dir string) bool {
+	dir = strings.Trim(path.Clean("/"+dir), "/")
+	if dir == "" {
+		return true
+	}
+	for _, file := range commit.Files {
+		if file == dir || strings.HasPrefix(file, dir+"/") {
+			return true
+		}
+	}
+	return false
 }
 
 func CalculateChange(commits []*Commit, latestRelease *Release) Change {
@@ -154,8 +303,325 @@ func ApplyChange(version *semver.Version, change Change, allowInitialDevelopment
 	return &newVersion
 }
 
+// BuildMetadata is the data available in the build metadata template
+type BuildMetadata struct {
+	SHA      string
+	ShortSHA string
+	Branch   string
+}
+
+// SetBuildMetadata renders the Go template format (e.g. sha.{{.ShortSHA}} or ci.{{env "BUILD_ID"}}) and sets it as metadata of the version
+func SetBuildMetadata(version *semver.Version, format string, data *BuildMetadata) (*semver.Version, error) {
+	if format == "" {
+		return version, nil
+	}
+	tmpl, err := template.New("metadata").Funcs(template.FuncMap{"env": os.Getenv}).Parse(format)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return nil, err
+	}
+	newVersion, err := version.SetMetadata(buf.String())
+	if err != nil {
+		return nil, fmt.Errorf("invalid build metadata %q: %w", buf.String(), err)
+	}
+	return &newVersion, nil
+}
+
+// ApplyPrereleaseChannel calculates the next prerelease of the channel (e.g. beta), releases of the same channel
+// increment the counter (1.3.0-beta.1 to 1.3.0-beta.2), other versions start the channel (1.2.3 to 1.3.0-beta.1).
+// A change needing a higher version than the prerelease starts the channel over (1.3.0-beta.2 to 2.0.0-beta.1).
+func ApplyPrereleaseChannel(version *semver.Version, change Change, channel string, allowInitialDevelopmentVersions bool) *semver.Version {
+	preRel := version.Prerelease()
+	if preRel == channel || strings.HasPrefix(preRel, channel+".") {
+		if changeLevel(change) <= prereleaseLevel(version) {
+			return ApplyChange(version, change, allowInitialDevelopmentVersions)
+		}
+		core, _ := version.SetPrerelease("")
+		return startChannel(ApplyChange(&core, change, allowInitialDevelopmentVersions), channel)
+	}
+	newVersion := ApplyChange(version, change, allowInitialDevelopmentVersions)
+	if newVersion == nil {
+		return nil
+	}
+	if preRel != "" {
+		// switching channels (e.g. alpha to beta) keeps the version of the previous prerelease
+		core, _ := version.SetPrerelease("")
+		newVersion = &core
+	}
+	return startChannel(newVersion, channel)
+}
+
+// startChannel returns the first prerelease of the channel for the version, e.g. 1.3.0-beta.1
+func startChannel(version *semver.Version, channel string) *semver.Version {
+	if version == nil {
+		return nil
+	}
+	channelVersion, err := version.SetPrerelease(channel + ".1")
+	if err != nil {
+		return nil
+	}
+	return &channelVersion
+}
+
+// changeLevel orders the changes by the bump they need: 0 for none, 1 for patch, 2 for minor and 3 for major
+func changeLevel(change Change) int {
+	switch {
+	case change.Major:
+		return 3
+	case change.Minor:
+		return 2
+	case change.Patch:
+		return 1
+	}
+	return 0
+}
+
+// prereleaseLevel returns the level of the bump the version of a prerelease was created with,
+// e.g. 2 (minor) for 1.3.0-beta.1 and 3 (major) for 2.0.0-beta.1
+func prereleaseLevel(version *semver.Version) int {
+	switch {
+	case version.Patch() > 0:
+		return 1
+	case version.Minor() > 0:
+		return 2
+	}
+	return 3
+}
+
+func isDeprecation(c *Commit) bool {
+	return c.Type == "deprecate" || c.Deprecation != ""
+}
+
+func hasDeprecation(commits []*Commit, latestRelease *Release) bool {
+	for _, commit := range commits {
+		if latestRelease.SHA == commit.SHA {
+			break
+		}
+		if isDeprecation(commit) {
+			return true
+		}
+	}
+	return false
+}
+
+// releasingCommits drops the commits of scopes that must not trigger a release on their own. An entry is a scope
+// (e.g. deps) or a type of a scope (e.g. docs(api)), in a package run of --pkg-path the commits are already
+// filtered to the package so the commits of a type non-releasing for the package can be dropped by its scope.
+func releasingCommits(conf *config.Config, commits []*Commit, latestRelease *Release) []*Commit {
+	if len(conf.NoReleaseScopes) == 0 {
+		return commits
+	}
+	noReleaseScopes := make(map[string]bool, len(conf.NoReleaseScopes))
+	for _, scope := range conf.NoReleaseScopes {
+		noReleaseScopes[scope] = true
+	}
+	ret := make([]*Commit, 0, len(commits))
+	for _, commit := range commits {
+		if commit.SHA != latestRelease.SHA && (noReleaseScopes[commit.Scope] || noReleaseScopes[commit.Type+"("+commit.Scope+")"]) {
+			continue
+		}
+		ret = append(ret, commit)
+	}
+	return ret
+}
+
+// VersionDecision describes the computed bump and the commits that triggered it
+type VersionDecision struct {
+	// Version is nil if no release is necessary
+	Version *semver.Version
+	// Bump is one of major, minor, patch, prerelease or none
+	Bump    string
+	Change  Change
+	Commits []*Commit
+	// Promotes is the prerelease which is released as stable version, if any
+	Promotes *semver.Version
+	// Forced is the level of the forced bump if it determined the version
+	Forced string
+}
+
+// Reason returns a short human readable explanation of the decision, e.g. "3 feat commits"
+func (d *VersionDecision) Reason() string {
+	if d.Version == nil {
+		return "no releasable commits"
+	}
+	if d.Promotes != nil {
+		return "promotion of " + d.Promotes.String()
+	}
+	if d.Forced != "" {
+		return "--force-bump=" + d.Forced
+	}
+	if len(d.Commits) == 0 {
+		return "initial development version"
+	}
+	if len(d.Commits) == 1 {
+		return summarizeTypes(d.Commits) + " commit"
+	}
+	return summarizeTypes(d.Commits) + " commits"
+}
+
+// summarizeTypes counts the commits per type, e.g. "2 chore, 1 docs"
+func summarizeTypes(commits []*Commit) string {
+	count := make(map[string]int)
+	for _, commit := range commits {
+		commitType := commit.Type
+		if commitType == "" {
+			commitType = "untyped"
+		}
+		count[commitType]++
+	}
+	types := make([]string, 0, len(count))
+	for commitType := range count {
+		types = append(types, commitType)
+	}
+	sort.Strings(types)
+	parts := make([]string, 0, len(types))
+	for _, commitType := range types {
+		parts = append(parts, fmt.Sprintf("%d %s", count[commitType], commitType))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// SummarizeCommitTypes counts the types of the commits since the latest release to explain why no release was created
+func SummarizeCommitTypes(commits []*Commit, latestRelease *Release) string {
+	since := make([]*Commit, 0, len(commits))
+	for _, commit := range commits {
+		if latestRelease.SHA == commit.SHA {
+			break
+		}
+		since = append(since, commit)
+	}
+	if len(since) == 0 {
+		return "no commits"
+	}
+	return summarizeTypes(since)
+}
+
+// triggeringCommits returns the commits that caused the highest level of the given change
+func triggeringCommits(commits []*Commit, latestRelease *Release, change Change, majorOnDeprecation bool) []*Commit {
+	ret := make([]*Commit, 0)
+	for _, commit := range commits {
+		if latestRelease.SHA == commit.SHA {
+			break
+		}
+		switch {
+		case change.Major:
+			if commit.Change.Major || (majorOnDeprecation && isDeprecation(commit)) {
+				ret = append(ret, commit)
+			}
+		case change.Minor:
+			if commit.Change.Minor {
+				ret = append(ret, commit)
+			}
+		case change.Patch:
+			if commit.Change.Patch {
+				ret = append(ret, commit)
+			}
+		}
+	}
+	return ret
+}
+
+// GetNewVersionWithReason calculates the new version like GetNewVersion and additionally returns why it was chosen
+func GetNewVersionWithReason(conf *config.Config, commits []*Commit, latestRelease *Release) *VersionDecision {
+	commits = releasingCommits(conf, commits, latestRelease)
+	// a change reverted within the same range does not trigger a release
+	if reverted := revertedCommits(commits, latestRelease); len(reverted) > 0 {
+		ret := make([]*Commit, 0, len(commits))
+		for _, commit := range commits {
+			if !reverted[commit.SHA] {
+				ret = append(ret, commit)
+			}
+		}
+		commits = ret
+	}
+	change := CalculateChange(commits, latestRelease)
+	if conf.MajorOnDeprecation {
+		change.Major = change.Major || hasDeprecation(commits, latestRelease)
+	}
+	forced := ""
+	if conf.ForceBump != "" {
+		forcedChange := Change{Major: conf.ForceBump == "major", Minor: conf.ForceBump == "minor", Patch: conf.ForceBump == "patch"}
+		if conf.ForceBumpStrict || changeLevel(forcedChange) > changeLevel(change) {
+			change = forcedChange
+			forced = conf.ForceBump
+		}
+	}
+	var newVersion *semver.Version
+	if conf.PrereleaseChannel != "" {
+		newVersion = ApplyPrereleaseChannel(latestRelease.Version, change, conf.PrereleaseChannel, conf.AllowInitialDevelopmentVersions)
+	} else {
+		newVersion = ApplyChange(latestRelease.Version, change, conf.AllowInitialDevelopmentVersions)
+	}
+	if newVersion != nil && conf.InitialVersion != "" && isInitialRelease(latestRelease) {
+		newVersion = initialVersion(conf)
+	}
+	// the forced bumps of 0.x versions must not release a package without any commits either, unlike --force-bump
+	if conf.NoReleaseOnScopeMismatch && conf.ForceBump == "" && !hasCommits(commits, latestRelease) {
+		newVersion = nil
+	}
+	return &VersionDecision{
+		Version: newVersion,
+		Bump:    GetBump(latestRelease.Version, newVersion),
+		Change:  change,
+		Commits: triggeringCommits(commits, latestRelease, change, conf.MajorOnDeprecation),
+		Forced:  forced,
+	}
+}
+
+// hasCommits returns true if there are any commits since the latest release
+func hasCommits(commits []*Commit, latestRelease *Release) bool {
+	return len(commits) > 0 && commits[0].SHA != latestRelease.SHA
+}
+
+func isInitialRelease(release *Release) bool {
+	return release.SHA == "" && release.Version.Equal(&semver.Version{})
+}
+
+// initialVersion returns the configured version of the first release, started as the first prerelease of the channel if one is set
+func initialVersion(conf *config.Config) *semver.Version {
+	version, err := semver.NewVersion(conf.InitialVersion)
+	if err != nil {
+		return nil
+	}
+	if conf.PrereleaseChannel == "" || version.Prerelease() != "" {
+		return version
+	}
+	channelVersion, err := version.SetPrerelease(conf.PrereleaseChannel + ".1")
+	if err != nil {
+		return nil
+	}
+	return &channelVersion
+}
+
+// PromoteRelease releases the version of the prerelease without the prerelease identifier (e.g. 1.2.0-beta.3 becomes 1.2.0),
+// the commits are those since the latest stable release
+func PromoteRelease(prerelease, stable *Release, commits []*Commit) (*VersionDecision, error) {
+	if prerelease.Version.Prerelease() == "" {
+		return nil, fmt.Errorf("no prerelease to promote, the latest release is %s", prerelease.Version)
+	}
+	version, err := prerelease.Version.SetPrerelease("")
+	if err != nil {
+		return nil, err
+	}
+	version, err = version.SetMetadata("")
+	if err != nil {
+		return nil, err
+	}
+	change := CalculateChange(commits, stable)
+	return &VersionDecision{
+		Version:  &version,
+		Bump:     GetBump(stable.Version, &version),
+		Change:   change,
+		Commits:  triggeringCommits(commits, stable, change, false),
+		Promotes: prerelease.Version,
+	}, nil
+}
+
 func GetNewVersion(conf *config.Config, commits []*Commit, latestRelease *Release) *semver.Version {
-	return ApplyChange(latestRelease.Version, CalculateChange(commits, latestRelease), conf.AllowInitialDevelopmentVersions)
+	return GetNewVersionWithReason(conf, commits, latestRelease).Version
 }
 
 func trimSHA(sha string) string {
@@ -165,12 +631,122 @@ func trimSHA(sha string) string {
 	return sha[:8]
 }
 
-func formatCommit(c *Commit) string {
+func creditOf(c *Commit, creditBy string) string {
+	switch creditBy {
+	case "author":
+		return c.Author
+	case "committer":
+		return c.Committer
+	}
+	return ""
+}
+
+var markdownPattern = regexp.MustCompile("[\\\\`*_\\[\\]<>]")
+var headingPattern = regexp.MustCompile(`^#([^0-9]|$)`)
+var issuePattern = regexp.MustCompile(`(^|[^\w/])#(\d+)\b`)
+
+// escapeMarkdown escapes the markdown control characters of a commit message, issue references (e.g. #123) are kept
+func escapeMarkdown(msg string) string {
+	msg = markdownPattern.ReplaceAllString(msg, `\$0`)
+	return headingPattern.ReplaceAllString(msg, `\#$1`)
+}
+
+// linkIssues turns issue references into references of the repository (e.g. owner/repo#123) which stay clickable everywhere
+func linkIssues(msg, slug string) string {
+	return issuePattern.ReplaceAllString(msg, "${1}"+slug+"#$2")
+}
+
+func formatCommit(c *Commit, conf *config.Config) string {
 	ret := "* "
 	if c.Scope != "" {
-		ret += fmt.Sprintf("**%s:** ", c.Scope)
+		ret += fmt.Sprintf("**%s:** ", escapeMarkdown(c.Scope))
+	}
+	msg := escapeMarkdown(c.Message)
+	if conf.ChangelogIssueLinks && conf.Slug != "" {
+		msg = linkIssues(msg, conf.Slug)
+	}
+	ret += fmt.Sprintf("%s (%s)", msg, trimSHA(c.SHA))
+	if credit := creditOf(c, conf.CreditBy); credit != "" {
+		ret += fmt.Sprintf(" by %s", credit)
+	}
+	if len(c.Links) > 0 {
+		ret += ", refs " + strings.Join(formatLinks(c.Links, conf), ", ")
+	}
+	ret += "\n"
+	// the body of breaking changes is already listed in a code block
+	if conf.ChangelogIncludeBody && !c.Change.Major {
+		ret += formatBody(c.Body)
+	}
+	return ret
+}
+
+var trailerPattern = regexp.MustCompile(`(?i)^(Signed-off-by|Co-authored-by|Reviewed-by|Acked-by|Tested-by|Reported-by|Helped-by):`)
+
+// isFooterLine reports whether the line is a footer already listed elsewhere in the changelog, a DEPRECATED: note
+// or issue references like Fixes #42
+func isFooterLine(line string) bool {
+	line = strings.TrimSpace(line)
+	if deprecatedPattern.MatchString(line) {
+		return true
+	}
+	ref := issueRefsPattern.FindStringIndex(line)
+	return ref != nil && ref[0] == 0 && ref[1] == len(line)
+}
+
+// formatBody indents the lines of the body below the list entry, the trailers at its end (e.g. Signed-off-by)
+// and the footers parsed into the commit (e.g. DEPRECATED: or Fixes #42) are removed
+func formatBody(body string) string {
+	lines := make([]string, 0)
+	for _, line := range strings.Split(body, "\n") {
+		if isFooterLine(line) {
+			continue
+		}
+		if strings.TrimSpace(line) == "" && (len(lines) == 0 || strings.TrimSpace(lines[len(lines)-1]) == "") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	for len(lines) > 0 {
+		last := strings.TrimSpace(lines[len(lines)-1])
+		if last != "" && !trailerPattern.MatchString(last) {
+			break
+		}
+		lines = lines[:len(lines)-1]
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+	var ret strings.Builder
+	ret.WriteString("\n")
+	for _, line := range lines {
+		line = strings.TrimRight(line, " \t\r")
+		if line != "" {
+			ret.WriteString("  " + line)
+		}
+		ret.WriteString("\n")
+	}
+	return ret.String() + "\n"
+}
+
+// formatLinks keeps the GitHub issue references (e.g. #42) which are linked by GitHub itself, references
+// of external trackers (e.g. JIRA-7) are linked with the IssueURL template
+func formatLinks(links []string, conf *config.Config) []string {
+	ret := make([]string, 0, len(links))
+	for _, link := range links {
+		if strings.HasPrefix(link, "#") {
+			if conf.ChangelogIssueLinks && conf.Slug != "" {
+				link = conf.Slug + link
+			}
+			ret = append(ret, link)
+			continue
+		}
+		var buf bytes.Buffer
+		if conf.IssueURL == nil || conf.IssueURL.Execute(&buf, struct{ Issue string }{link}) != nil {
+			ret = append(ret, link)
+			continue
+		}
+		ret = append(ret, fmt.Sprintf("[%s](%s)", link, buf.String()))
 	}
-	ret += fmt.Sprintf("%s (%s)\n", c.Message, trimSHA(c.SHA))
 	return ret
 }
 
@@ -185,6 +761,7 @@ var typeToText = map[string]string{
 	"test":     "Tests",
 	"chore":    "Chores",
 	"%%bc%%":   "Breaking Changes",
+	"%%dep%%":  "Deprecations",
 }
 
 func getSortedKeys(m *map[string]string) []string {
@@ -198,29 +775,303 @@ func getSortedKeys(m *map[string]string) []string {
 	return keys
 }
 
-func GetChangelog(commits []*Commit, latestRelease *Release, newVersion *semver.Version) string {
-	ret := fmt.Sprintf("## %s (%s)\n\n", newVersion.String(), time.Now().UTC().Format("2006-01-02"))
+// changelogSectionKeys are the names of the breaking changes and deprecations sections in --changelog-section-titles
+var changelogSectionKeys = map[string]string{
+	"%%bc%%":  "breaking",
+	"%%dep%%": "deprecations",
+}
+
+// changelogSectionKey returns the name of a section type in the configuration
+func changelogSectionKey(t string) string {
+	if key, ok := changelogSectionKeys[t]; ok {
+		return key
+	}
+	return t
+}
+
+// changelogSectionTitle returns the configured title of a section type, the default title or the capitalized type
+func changelogSectionTitle(conf *config.Config, t string) string {
+	if title, ok := conf.ChangelogSectionTitles[changelogSectionKey(t)]; ok {
+		return title
+	}
+	if title, ok := typeToText[t]; ok {
+		return title
+	}
+	if t == "" {
+		return t
+	}
+	return strings.ToUpper(t[:1]) + t[1:]
+}
+
+// sortChangelogTypes orders the types of the sections by the configured order, the breaking changes and
+// deprecations stay first and the remaining types follow alphabetically unless they are listed
+func sortChangelogTypes(types, order []string) []string {
+	positions := make(map[string]int, len(order))
+	for i, t := range order {
+		positions[t] = i
+	}
+	position := func(t string) int {
+		if i, ok := positions[changelogSectionKey(t)]; ok {
+			return i
+		}
+		if strings.HasPrefix(t, "%%") {
+			return -1
+		}
+		return len(order)
+	}
+	sort.SliceStable(types, func(i, j int) bool {
+		return position(types[i]) < position(types[j])
+	})
+	return types
+}
+
+// formatScopeGroups renders the entries under a heading per scope, sorted alphabetically with the scope-less entries last
+func formatScopeGroups(scopes map[string]string) string {
+	ret := make([]string, 0, len(scopes))
+	for _, scope := range getSortedKeys(&scopes) {
+		if scope != "" {
+			ret = append(ret, fmt.Sprintf("##### %s\n\n%s", scope, scopes[scope]))
+		}
+	}
+	if general, ok := scopes[""]; ok {
+		ret = append(ret, fmt.Sprintf("##### general\n\n%s", general))
+	}
+	return strings.Join(ret, "\n")
+}
+
+// DefaultChangelogTemplate renders the changelog sections as markdown
+const DefaultChangelogTemplate = `## {{.Version}} ({{.Date}})
+
+{{range .Sections}}#### {{.Title}}
+
+{{.Body}}
+{{end}}{{if .Contributors}}#### Contributors
+
+{{range .Contributors}}* {{.}}
+{{end}}
+{{end}}`
+
+var defaultChangelogTemplate = template.Must(template.New("changelog").Parse(DefaultChangelogTemplate))
+
+// ChangelogSection contains the commits of one change type (e.g. feat) or of the breaking changes (%%bc%%) and deprecations (%%dep%%)
+type ChangelogSection struct {
+	Type  string
+	Title string
+	// Body is the markdown list of the commits as rendered by the default template
+	Body    string
+	Commits []*Commit
+}
+
+// ChangelogData is passed to the changelog template
+type ChangelogData struct {
+	Version         string
+	PreviousVersion string
+	Date            string
+	Sections        []*ChangelogSection
+	// Contributors are the escaped names of the authors and co-authors, only set with ChangelogContributors
+	Contributors []string
+}
+
+// contributorName returns the account mention (e.g. @login) if it is known, otherwise the escaped name
+func contributorName(name, login string) string {
+	if login != "" {
+		return "@" + login
+	}
+	return escapeMarkdown(name)
+}
+
+// contributors returns the sorted and deduplicated authors and co-authors of the commits
+func contributors(commits []*Commit) []string {
+	names := make(map[string]bool)
+	// co-authors are mentioned by their account if they authored another commit
+	logins := make(map[string]string)
+	for _, commit := range commits {
+		if commit.AuthorLogin != "" {
+			logins[commit.Author] = commit.AuthorLogin
+		}
+	}
+	for _, commit := range commits {
+		if commit.Author != "" || commit.AuthorLogin != "" {
+			names[contributorName(commit.Author, commit.AuthorLogin)] = true
+		}
+		for _, coAuthor := range commit.CoAuthors {
+			names[contributorName(coAuthor, logins[coAuthor])] = true
+		}
+	}
+	ret := make([]string, 0, len(names))
+	for name := range names {
+		ret = append(ret, name)
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+// changelogType reports whether the commits of the type are listed in the changelog, if include types are set
+// only they are listed and the exclude types are ignored
+func changelogType(conf *config.Config, commitType string) bool {
+	types, listed := conf.ChangelogExcludeTypes, false
+	if len(conf.ChangelogIncludeTypes) > 0 {
+		types, listed = conf.ChangelogIncludeTypes, true
+	}
+	for _, t := range types {
+		if t == commitType {
+			return listed
+		}
+	}
+	return !listed
+}
+
+// NewChangelogData groups the commits since the latest release by their change type, sorted by type or by the
+// configured section order
+func NewChangelogData(conf *config.Config, commits []*Commit, latestRelease *Release, newVersion *semver.Version) *ChangelogData {
+	releaseDate := conf.ReleaseTimestamp
+	if releaseDate.IsZero() {
+		releaseDate = time.Now()
+	}
+	dateFormat := conf.ChangelogDateFormat
+	if dateFormat == "" {
+		dateFormat = "2006-01-02"
+	}
+	timezone := conf.ChangelogTimezone
+	if timezone == nil {
+		timezone = time.UTC
+	}
+	data := &ChangelogData{
+		Version: newVersion.String(),
+		Date:    releaseDate.In(timezone).Format(dateFormat),
+	}
+	if latestRelease.Version != nil {
+		data.PreviousVersion = latestRelease.Version.String()
+	}
 	typeScopeMap := make(map[string]string)
+	typeScopes := make(map[string]map[string]string)
+	typeCommits := make(map[string][]*Commit)
+	reverted := revertedCommits(commits, latestRelease)
+	released := make([]*Commit, 0, len(commits))
 	for _, commit := range commits {
 		if latestRelease.SHA == commit.SHA {
 			break
 		}
+		released = append(released, commit)
+		// reverted commits are only listed by their revert in the reverts section
+		if reverted[commit.SHA] {
+			continue
+		}
 		if commit.Change.Major {
-			typeScopeMap["%%bc%%"] += fmt.Sprintf("%s\n```%s\n```\n", formatCommit(commit), strings.Join(commit.Raw[1:], "\n"))
+			typeScopeMap["%%bc%%"] += fmt.Sprintf("%s\n```%s\n```\n", formatCommit(commit, conf), strings.Join(commit.Raw[1:], "\n"))
+			typeCommits["%%bc%%"] = append(typeCommits["%%bc%%"], commit)
 			continue
 		}
-		if commit.Type == "" {
+		if isDeprecation(commit) {
+			entry := formatCommit(commit, conf)
+			if commit.Deprecation != "" {
+				entry += fmt.Sprintf("```\n%s\n```\n", commit.Deprecation)
+			}
+			typeScopeMap["%%dep%%"] += entry
+			typeCommits["%%dep%%"] = append(typeCommits["%%dep%%"], commit)
+			// a feature or fix with a DEPRECATED footer is listed in its section as well
+			if commit.Type == "deprecate" {
+				continue
+			}
+		}
+		if commit.Type == "" || !changelogType(conf, commit.Type) {
 			continue
 		}
-		typeScopeMap[commit.Type] += formatCommit(commit)
+		typeCommits[commit.Type] = append(typeCommits[commit.Type], commit)
+		if conf.ChangelogGroupByScope {
+			if typeScopes[commit.Type] == nil {
+				typeScopes[commit.Type] = make(map[string]string)
+			}
+			// the scope is already part of the heading
+			unscoped := *commit
+			unscoped.Scope = ""
+			typeScopes[commit.Type][commit.Scope] += formatCommit(&unscoped, conf)
+			continue
+		}
+		typeScopeMap[commit.Type] += formatCommit(commit, conf)
+	}
+	for t, scopes := range typeScopes {
+		typeScopeMap[t] = formatScopeGroups(scopes)
+	}
+	for _, t := range sortChangelogTypes(getSortedKeys(&typeScopeMap), conf.ChangelogSectionOrder) {
+		data.Sections = append(data.Sections, &ChangelogSection{
+			Type:    t,
+			Title:   changelogSectionTitle(conf, t),
+			Body:    typeScopeMap[t],
+			Commits: typeCommits[t],
+		})
+	}
+	if conf.ChangelogContributors {
+		data.Contributors = contributors(released)
+	}
+	return data
+}
+
+// ChangelogSinceLastStable lists the commits of all prereleases since the latest stable release in the changelog
+// of a prerelease instead of only the commits since the latest prerelease
+const ChangelogSinceLastStable = "last-stable"
+
+// GenerateChangelog renders the changelog with the configured command, the configured template or the DefaultChangelogTemplate
+func GenerateChangelog(conf *config.Config, commits []*Commit, latestRelease *Release, newVersion *semver.Version) (string, error) {
+	if conf.ChangelogCommand != "" {
+		return runChangelogCommand(conf, commits, latestRelease, newVersion)
+	}
+	tmpl := defaultChangelogTemplate
+	if conf.ChangelogTemplate != nil {
+		tmpl = conf.ChangelogTemplate
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, NewChangelogData(conf, commits, latestRelease, newVersion)); err != nil {
+		return "", fmt.Errorf("could not render changelog: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// GetChangelog renders the changelog of the commits since the latest release with the DefaultChangelogTemplate
+func GetChangelog(commits []*Commit, latestRelease *Release, newVersion *semver.Version) string {
+	return GetChangelogWithConfig(&config.Config{}, commits, latestRelease, newVersion, nil)
+}
+
+// GetChangelogWithConfig is like GenerateChangelog but falls back to the DefaultChangelogTemplate if the configured
+// command or template fails, the error is logged as a warning to the logger unless it is nil
+func GetChangelogWithConfig(conf *config.Config, commits []*Commit, latestRelease *Release, newVersion *semver.Version, logger *logging.Logger) string {
+	changelog, err := GenerateChangelog(conf, commits, latestRelease, newVersion)
+	if err != nil {
+		if logger != nil {
+			logger.Warnf("%v, falling back to the default changelog template", err)
+		}
+		fallback := *conf
+		fallback.ChangelogTemplate = nil
+		fallback.ChangelogCommand = ""
+		changelog, _ = GenerateChangelog(&fallback, commits, latestRelease, newVersion)
+	}
+	return changelog
+}
+
+// GetReleaseTitle returns the first non-empty line of the changelog without its heading markers
+func GetReleaseTitle(changelog string) string {
+	for _, line := range strings.Split(changelog, "\n") {
+		line = strings.TrimSpace(strings.TrimLeft(line, "#"))
+		if line != "" {
+			return line
+		}
+	}
+	return ""
+}
+
+// GetMovingTagVersions returns the partial versions (e.g. 1, 1.2) that should point to the new version
+func GetMovingTagVersions(version *semver.Version, levels []string) []string {
+	if version.Prerelease() != "" {
+		return nil
 	}
-	for _, t := range getSortedKeys(&typeScopeMap) {
-		msg := typeScopeMap[t]
-		typeName, found := typeToText[t]
-		if !found {
-			typeName = t
+	ret := make([]string, 0, len(levels))
+	for _, level := range levels {
+		switch level {
+		case "major":
+			ret = append(ret, fmt.Sprintf("%d", version.Major()))
+		case "minor":
+			ret = append(ret, fmt.Sprintf("%d.%d", version.Major(), version.Minor()))
 		}
-		ret += fmt.Sprintf("#### %s\n\n%s\n", typeName, msg)
 	}
 	return ret
 }
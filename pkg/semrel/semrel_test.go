@@ -1,14 +1,62 @@
 package semrel
 
 import (
+	"bytes"
 	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
 	"strings"
 	"testing"
+	"text/template"
+	"time"
 
 	"github.com/Masterminds/semver"
 	"github.com/go-semantic-release/semantic-release/pkg/config"
+	"github.com/go-semantic-release/semantic-release/pkg/logging"
+	"github.com/stretchr/testify/require"
 )
 
+func TestParseTagVersion(t *testing.T) {
+	testCases := []struct {
+		tag             string
+		coerce          bool
+		expectedVersion string
+	}{
+		{"v1.2.3", false, "1.2.3"},
+		{"1.2.3-beta.1", false, "1.2.3-beta.1"},
+		{"1.2", false, ""},
+		{"v1", false, ""},
+		{"1.2", true, "1.2.0"},
+		{"v1", true, "1.0.0"},
+		{"test-tag", true, ""},
+	}
+	for _, tc := range testCases {
+		version, err := parseTagVersion(tc.tag, tc.coerce)
+		if tc.expectedVersion == "" {
+			if err == nil {
+				t.Errorf("expected error for tag %s", tc.tag)
+			}
+			continue
+		}
+		if err != nil || version.String() != tc.expectedVersion {
+			t.Errorf("expected: %s, got: %v (%v)", tc.expectedVersion, version, err)
+		}
+	}
+}
+
+func TestParseSlug(t *testing.T) {
+	for _, slug := range []string{"", "owner", "owner/", "/repo", "a/b/c"} {
+		_, _, err := ParseSlug(slug)
+		require.EqualError(t, err, fmt.Sprintf("invalid slug %q: must be owner/repo", slug))
+	}
+	owner, repo, err := ParseSlug("owner/repo")
+	require.NoError(t, err)
+	require.Equal(t, "owner", owner)
+	require.Equal(t, "repo", repo)
+}
+
 func TestCalculateChange(t *testing.T) {
 	commits := []*Commit{
 		{SHA: "a", Change: Change{true, false, false}},
@@ -30,6 +78,186 @@ func TestCalculateChange(t *testing.T) {
 	}
 }
 
+func TestFilterCommits(t *testing.T) {
+	commits := []*Commit{
+		{SHA: "a", Author: "Jane", Committer: "release-bot"},
+		{SHA: "b", Author: "release-bot", Committer: "Jane"},
+		{SHA: "c", Author: "John", Committer: "release-bot"},
+	}
+	filtered := FilterCommits(&config.Config{ExcludeCommitters: []string{"release-bot"}}, commits, &Release{SHA: "c"})
+	if len(filtered) != 2 || filtered[0].SHA != "b" || filtered[1].SHA != "c" {
+		t.Errorf("invalid filtered commits: %v", filtered)
+	}
+	if filtered := FilterCommits(&config.Config{}, commits, &Release{}); len(filtered) != 3 {
+		t.Errorf("expected all commits, got: %d", len(filtered))
+	}
+}
+
+func TestFilterCommitsPkgPath(t *testing.T) {
+	commits := []*Commit{
+		{SHA: "a", Files: []string{"services/api/main.go", "README.md"}},
+		{SHA: "b", Files: []string{"services/web/index.js"}},
+		{SHA: "c", Files: []string{"services/api-client/client.go"}},
+		{SHA: "d", Files: []string{"services/api"}},
+		{SHA: "e", Files: []string{"docs/index.md"}},
+	}
+	for _, pkgPath := range []string{"services/api", "./services/api/", "/services/api"} {
+		filtered := FilterCommits(&config.Config{PkgPath: pkgPath}, commits, &Release{SHA: "e"})
+		shas := make([]string, len(filtered))
+		for i, commit := range filtered {
+			shas[i] = commit.SHA
+		}
+		require.Equal(t, []string{"a", "d", "e"}, shas, pkgPath)
+	}
+	require.Len(t, FilterCommits(&config.Config{PkgPath: "."}, commits, &Release{}), 5)
+}
+
+func TestFilterCommitsMergeCommits(t *testing.T) {
+	commits := []*Commit{
+		{SHA: "merge", Raw: []string{"Merge pull request #12 from owner/search"}, Parents: []string{"release", "search"}},
+		{SHA: "search", Type: "feat", Parents: []string{"release"}},
+		{SHA: "fix", Type: "fix", Parents: []string{"release"}},
+		{SHA: "release", Raw: []string{"Merge branch 'next'"}, Parents: []string{"a", "b"}},
+	}
+	// the merge commit of the latest release is kept as boundary
+	filtered := FilterCommits(&config.Config{IgnoreMergeCommits: true}, commits, &Release{SHA: "release"})
+	require.Equal(t, []string{"search", "fix", "release"}, commitSHAs(filtered))
+	require.Len(t, FilterCommits(&config.Config{}, commits, &Release{SHA: "release"}), 4)
+	// commits without parents (e.g. on providers not listing them) are kept
+	require.Len(t, FilterCommits(&config.Config{IgnoreMergeCommits: true}, []*Commit{{SHA: "a"}, {SHA: "b"}}, &Release{}), 2)
+}
+
+func TestGetNewVersionNoReleaseOnScopeMismatch(t *testing.T) {
+	// the commits since the latest release only touch package a
+	commits := []*Commit{
+		{SHA: "a", Type: "feat", Scope: "a", Change: Change{Minor: true}, Files: []string{"packages/a/index.js"}},
+		{SHA: "b", Type: "fix", Scope: "a", Change: Change{Patch: true}, Files: []string{"packages/a/util.js"}},
+		{SHA: "release", Type: "chore", Files: []string{"CHANGELOG.md"}},
+	}
+	for _, release := range []*Release{{SHA: "release", Version: semver.MustParse("0.3.0")}, InitialRelease()} {
+		conf := &config.Config{PkgPath: "packages/b"}
+		filtered := FilterCommits(conf, commits, release)
+		// 0.x versions are bumped to 1.0.0 without any commits
+		require.Equal(t, "1.0.0", GetNewVersion(conf, filtered, release).String())
+
+		conf.NoReleaseOnScopeMismatch = true
+		decision := GetNewVersionWithReason(conf, filtered, release)
+		require.Nil(t, decision.Version)
+		require.Equal(t, "none", decision.Bump)
+
+		conf.PkgPath = "packages/a"
+		require.Equal(t, "1.0.0", GetNewVersion(conf, FilterCommits(conf, commits, release), release).String())
+	}
+}
+
+func TestGetNewVersionNoReleaseScopes(t *testing.T) {
+	conf := &config.Config{NoReleaseScopes: []string{"docs", "deps"}}
+	version, _ := semver.NewVersion("1.0.0")
+	release := &Release{SHA: "stop", Version: version}
+	commits := []*Commit{
+		{SHA: "a", Type: "docs", Scope: "api"},
+		{SHA: "b", Type: "fix", Scope: "deps", Change: Change{Patch: true}},
+		{SHA: "c", Type: "feat", Scope: "docs", Change: Change{Minor: true}},
+		{SHA: "stop", Type: "feat", Scope: "app", Change: Change{Minor: true}},
+	}
+	if newVersion := GetNewVersion(conf, commits, release); newVersion != nil {
+		t.Errorf("expected no release, got: %s", newVersion)
+	}
+	commits = append([]*Commit{{SHA: "d", Type: "fix", Scope: "api", Change: Change{Patch: true}}}, commits...)
+	if newVersion := GetNewVersion(conf, commits, release); newVersion == nil || newVersion.String() != "1.0.1" {
+		t.Errorf("expected: 1.0.1, got: %s", newVersion)
+	}
+}
+
+func TestGetNewVersionNoReleaseTypesOfPackage(t *testing.T) {
+	// docs release a patch in general but not for the api package
+	conf := &config.Config{PkgPath: "api", NoReleaseScopes: []string{"docs(api)"}, BumpRules: map[string]string{"docs": "patch"}}
+	version, _ := semver.NewVersion("1.0.0")
+	release := &Release{SHA: "stop", Version: version}
+	commits := []*Commit{
+		ParseCommitWithRules("a", "docs(api): endpoints", NewBumpRules(conf.BumpRules)),
+		ParseCommitWithRules("b", "fix(web): layout", NewBumpRules(conf.BumpRules)),
+		ParseCommitWithRules("c", "docs(web): theme", NewBumpRules(conf.BumpRules)),
+		{SHA: "stop", Type: "feat", Scope: "api", Files: []string{"api/main.go"}},
+	}
+	commits[0].Files = []string{"api/README.md"}
+	commits[1].Files = []string{"web/main.go"}
+	commits[2].Files = []string{"web/README.md"}
+	// the package only has docs commits
+	if newVersion := GetNewVersion(conf, FilterCommits(conf, commits, release), release); newVersion != nil {
+		t.Errorf("expected no release, got: %s", newVersion)
+	}
+	// the docs of another package still release it
+	conf.PkgPath = "web"
+	if newVersion := GetNewVersion(conf, FilterCommits(conf, commits[2:], release), release); newVersion == nil || newVersion.String() != "1.0.1" {
+		t.Errorf("expected: 1.0.1, got: %s", newVersion)
+	}
+	conf.PkgPath = "api"
+	commits = append([]*Commit{ParseCommitWithRules("d", "fix(api): status code", NewBumpRules(conf.BumpRules))}, commits...)
+	commits[0].Files = []string{"api/main.go"}
+	if newVersion := GetNewVersion(conf, FilterCommits(conf, commits, release), release); newVersion == nil || newVersion.String() != "1.0.1" {
+		t.Errorf("expected: 1.0.1, got: %s", newVersion)
+	}
+}
+
+func TestGetNewVersionWithReason(t *testing.T) {
+	version, _ := semver.NewVersion("1.0.0")
+	release := &Release{SHA: "stop", Version: version}
+	commits := []*Commit{
+		{SHA: "a", Type: "feat", Change: Change{Minor: true}},
+		{SHA: "b", Type: "fix", Change: Change{Patch: true}},
+		{SHA: "c", Type: "feat", Change: Change{Minor: true}},
+		{SHA: "stop", Type: "feat", Change: Change{Minor: true}},
+	}
+	decision := GetNewVersionWithReason(&config.Config{}, commits, release)
+	require.Equal(t, "1.1.0", decision.Version.String())
+	require.Equal(t, "minor", decision.Bump)
+	require.Len(t, decision.Commits, 2)
+	require.Equal(t, "2 feat commits", decision.Reason())
+
+	decision = GetNewVersionWithReason(&config.Config{}, commits[3:], release)
+	require.Nil(t, decision.Version)
+	require.Equal(t, "none", decision.Bump)
+	require.Empty(t, decision.Commits)
+
+	commits = append([]*Commit{{SHA: "d", Type: "chore"}, {SHA: "e", Type: "docs"}, {SHA: "f", Type: "chore"}}, commits[3:]...)
+	require.Equal(t, "2 chore, 1 docs", SummarizeCommitTypes(commits, release))
+	require.Equal(t, "no commits", SummarizeCommitTypes(commits[3:], release))
+
+	version, _ = semver.NewVersion("0.1.0")
+	decision = GetNewVersionWithReason(&config.Config{}, commits[3:], &Release{SHA: "stop", Version: version})
+	require.Equal(t, "1.0.0", decision.Version.String())
+	require.Equal(t, "major", decision.Bump)
+	require.Equal(t, "initial development version", decision.Reason())
+}
+
+func TestGetNewVersionInitialVersion(t *testing.T) {
+	commits := []*Commit{
+		{SHA: "a", Type: "fix", Change: Change{Patch: true}},
+		{SHA: "b", Type: "feat", Change: Change{Minor: true}},
+	}
+	decision := GetNewVersionWithReason(&config.Config{}, commits, InitialRelease())
+	require.Equal(t, "1.0.0", decision.Version.String())
+	decision = GetNewVersionWithReason(&config.Config{AllowInitialDevelopmentVersions: true}, commits, InitialRelease())
+	require.Equal(t, "0.1.0", decision.Version.String())
+
+	decision = GetNewVersionWithReason(&config.Config{InitialVersion: "2.3.0"}, commits, InitialRelease())
+	require.Equal(t, "2.3.0", decision.Version.String())
+	require.Equal(t, "major", decision.Bump)
+	decision = GetNewVersionWithReason(&config.Config{InitialVersion: "0.0.1", AllowInitialDevelopmentVersions: true}, commits, InitialRelease())
+	require.Equal(t, "0.0.1", decision.Version.String())
+	decision = GetNewVersionWithReason(&config.Config{InitialVersion: "1.0.0", PrereleaseChannel: "beta"}, commits, InitialRelease())
+	require.Equal(t, "1.0.0-beta.1", decision.Version.String())
+
+	// the initial version is ignored once a release exists
+	release := &Release{SHA: "stop", Version: semver.MustParse("1.0.0")}
+	commits = append(commits, &Commit{SHA: "stop", Type: "feat", Change: Change{Minor: true}})
+	decision = GetNewVersionWithReason(&config.Config{InitialVersion: "2.3.0"}, commits, release)
+	require.Equal(t, "1.1.0", decision.Version.String())
+	decision = GetNewVersionWithReason(&config.Config{InitialVersion: "2.3.0"}, commits[2:], release)
+	require.Nil(t, decision.Version)
+}
+
 func TestApplyChange(t *testing.T) {
 	NoChange := Change{false, false, false}
 	PatchChange := Change{false, false, true}
@@ -85,6 +313,256 @@ func TestApplyChange(t *testing.T) {
 	}
 }
 
+func TestSetBuildMetadata(t *testing.T) {
+	os.Setenv("BUILD_ID", "4567")
+	defer os.Unsetenv("BUILD_ID")
+	data := &BuildMetadata{SHA: "abc1234def", ShortSHA: "abc1234", Branch: "master"}
+	testCases := []struct {
+		version         string
+		format          string
+		expectedVersion string
+	}{
+		{"1.2.3", "", "1.2.3"},
+		{"1.2.3", "sha.{{.ShortSHA}}", "1.2.3+sha.abc1234"},
+		{"2.0.0-beta.1", "sha.{{.ShortSHA}}", "2.0.0-beta.1+sha.abc1234"},
+		{"1.2.3", `ci.{{env "BUILD_ID"}}`, "1.2.3+ci.4567"},
+	}
+	for _, tc := range testCases {
+		version, err := SetBuildMetadata(semver.MustParse(tc.version), tc.format, data)
+		require.NoError(t, err)
+		require.Equal(t, tc.expectedVersion, version.String())
+	}
+
+	_, err := SetBuildMetadata(semver.MustParse("1.2.3"), "{{.Branch}}/x", data)
+	require.Error(t, err)
+}
+
+func TestGetLatestReleaseVersionRange(t *testing.T) {
+	releases := Releases{
+		{SHA: "a", Version: semver.MustParse("1.0.0")},
+		{SHA: "b", Version: semver.MustParse("1.2.0")},
+		{SHA: "c", Version: semver.MustParse("1.2.5")},
+		{SHA: "d", Version: semver.MustParse("1.3.0")},
+		{SHA: "e", Version: semver.MustParse("2.0.0")},
+		{SHA: "f", Version: semver.MustParse("2.1.0-beta.1")},
+	}
+	testCases := []struct {
+		vrange          string
+		expectedSHA     string
+		expectedVersion string
+	}{
+		{"", "e", "2.0.0"},
+		{"~1.2", "c", "1.2.5"},
+		{"^1", "d", "1.3.0"},
+		{"1.2.0", "b", "1.2.0"},
+		{">=1.2.0 <1.3.0", "c", "1.2.5"},
+		{">=1.0.0, <1.2.0", "a", "1.0.0"},
+		{"<1.0.0 || 1.3.x", "d", "1.3.0"},
+		// the first release of a new maintenance line starts at the latest release
+		{"1.4", "e", "1.4.0"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.vrange, func(t *testing.T) {
+			release, err := releases.GetLatestRelease(tc.vrange)
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedSHA, release.SHA)
+			require.Equal(t, tc.expectedVersion, release.Version.String())
+		})
+	}
+
+	_, err := releases.GetLatestRelease("~3.1")
+	require.EqualError(t, err, "no release satisfies the version range ~3.1")
+	_, err = releases.GetLatestRelease(">=a")
+	require.Error(t, err)
+
+	release, err := Releases{}.GetLatestRelease("1.0")
+	require.NoError(t, err)
+	require.Equal(t, "", release.SHA)
+	require.Equal(t, "1.0.0", release.Version.String())
+}
+
+func TestGetLatestReleaseIncludePrereleases(t *testing.T) {
+	releases := Releases{
+		{SHA: "a", Version: semver.MustParse("1.2.3")},
+		{SHA: "b", Version: semver.MustParse("2.0.0-beta.2")},
+		{SHA: "c", Version: semver.MustParse("1.3.0")},
+		{SHA: "d", Version: semver.MustParse("1.4.0-rc.1")},
+		{SHA: "e", Version: semver.MustParse("1.2.4")},
+	}
+	testCases := []struct {
+		name            string
+		latest          *GetLatestReleaseConfig
+		expectedVersion string
+	}{
+		// a stable release is based on the latest stable release
+		{"stable", &GetLatestReleaseConfig{}, "1.3.0"},
+		{"included", &GetLatestReleaseConfig{IncludePrereleases: true}, "2.0.0-beta.2"},
+		{"channel", &GetLatestReleaseConfig{PrereleaseChannel: "rc"}, "1.4.0-rc.1"},
+		// the maintained version keeps its range
+		{"range", &GetLatestReleaseConfig{VersionRange: "1.2.x", IncludePrereleases: true}, "1.2.4"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			release, err := tc.latest.selectRelease(releases)
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedVersion, release.Version.String())
+		})
+	}
+
+	release, err := (&GetLatestReleaseConfig{IncludePrereleases: true}).selectRelease(Releases{})
+	require.NoError(t, err)
+	require.Equal(t, InitialRelease(), release)
+}
+
+func TestPrereleaseChannel(t *testing.T) {
+	releases := Releases{
+		{SHA: "a", Version: semver.MustParse("1.2.3")},
+		{SHA: "b", Version: semver.MustParse("1.3.0-alpha.4")},
+		{SHA: "c", Version: semver.MustParse("1.3.0-beta.1")},
+		{SHA: "d", Version: semver.MustParse("1.1.0")},
+	}
+	commits := []*Commit{{SHA: "e", Type: "feat", Change: Change{Minor: true}}}
+	testCases := []struct {
+		channel         string
+		expectedRelease string
+		expectedVersion string
+	}{
+		// promotion from the prereleases to the final version
+		{"", "1.2.3", "1.3.0"},
+		{"beta", "1.3.0-beta.1", "1.3.0-beta.2"},
+		{"alpha", "1.3.0-alpha.4", "1.3.0-alpha.5"},
+		// the first prerelease of a channel
+		{"rc", "1.2.3", "1.3.0-rc.1"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.channel, func(t *testing.T) {
+			release, err := releases.GetLatestReleaseOnChannel("", tc.channel)
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedRelease, release.Version.String())
+			newVersion := GetNewVersion(&config.Config{PrereleaseChannel: tc.channel}, commits, release)
+			require.Equal(t, tc.expectedVersion, newVersion.String())
+		})
+	}
+
+	// switching from alpha to beta keeps the version of the alpha
+	newVersion := ApplyPrereleaseChannel(semver.MustParse("1.3.0-alpha.4"), Change{Patch: true}, "beta", false)
+	require.Equal(t, "1.3.0-beta.1", newVersion.String())
+	require.Nil(t, ApplyPrereleaseChannel(semver.MustParse("1.2.3"), Change{}, "beta", false))
+}
+
+func TestGetNewVersionForceBump(t *testing.T) {
+	release := &Release{SHA: "release", Version: semver.MustParse("1.2.3")}
+	patch := []*Commit{{SHA: "a", Type: "fix", Change: Change{Patch: true}}, {SHA: "release"}}
+	feature := []*Commit{{SHA: "b", Type: "feat", Change: Change{Minor: true}}, {SHA: "release"}}
+	none := []*Commit{{SHA: "c", Type: "chore"}, {SHA: "release"}}
+
+	decision := GetNewVersionWithReason(&config.Config{ForceBump: "major"}, patch, release)
+	require.Equal(t, "2.0.0", decision.Version.String())
+	require.Equal(t, "major", decision.Bump)
+	require.Equal(t, "--force-bump=major", decision.Reason())
+
+	// the higher computed level wins unless the bump is strict
+	decision = GetNewVersionWithReason(&config.Config{ForceBump: "patch"}, feature, release)
+	require.Equal(t, "1.3.0", decision.Version.String())
+	require.Equal(t, "1 feat commit", decision.Reason())
+	require.Equal(t, "1.2.4", GetNewVersion(&config.Config{ForceBump: "patch", ForceBumpStrict: true}, feature, release).String())
+
+	// a forced bump releases without releasable commits
+	require.Nil(t, GetNewVersion(&config.Config{}, none, release))
+	require.Equal(t, "1.2.4", GetNewVersion(&config.Config{ForceBump: "patch"}, none, release).String())
+
+	// prerelease channels are respected
+	require.Equal(t, "2.0.0-beta.1", GetNewVersion(&config.Config{ForceBump: "major", PrereleaseChannel: "beta"}, patch, release).String())
+	beta := &Release{SHA: "release", Version: semver.MustParse("2.0.0-beta.1")}
+	require.Equal(t, "2.0.0-beta.2", GetNewVersion(&config.Config{ForceBump: "major", PrereleaseChannel: "beta"}, patch, beta).String())
+}
+
+func TestPrereleaseChannelCounter(t *testing.T) {
+	testCases := []struct {
+		version  string
+		change   Change
+		expected string
+	}{
+		{"1.3.0-beta.1", Change{Patch: true}, "1.3.0-beta.2"},
+		{"1.3.0-beta.1", Change{Minor: true}, "1.3.0-beta.2"},
+		{"1.3.0-beta.9", Change{Minor: true}, "1.3.0-beta.10"},
+		{"1.2.4-beta.2", Change{Patch: true}, "1.2.4-beta.3"},
+		{"2.0.0-beta.1", Change{Major: true}, "2.0.0-beta.2"},
+		// a change needing a higher version starts the channel over
+		{"1.3.0-beta.2", Change{Major: true}, "2.0.0-beta.1"},
+		{"1.2.4-beta.2", Change{Minor: true}, "1.3.0-beta.1"},
+		{"1.2.4-beta.2", Change{Major: true}, "2.0.0-beta.1"},
+	}
+	for _, tc := range testCases {
+		newVersion := ApplyPrereleaseChannel(semver.MustParse(tc.version), tc.change, "beta", false)
+		require.Equal(t, tc.expected, newVersion.String(), tc.version)
+	}
+	require.Nil(t, ApplyPrereleaseChannel(semver.MustParse("1.3.0-beta.1"), Change{}, "beta", false))
+
+	release := &Release{SHA: "beta1", Version: semver.MustParse("1.3.0-beta.1")}
+	commits := []*Commit{{SHA: "a", Type: "fix", Change: Change{Patch: true}}, {SHA: "beta1"}}
+	require.Equal(t, "1.3.0-beta.2", GetNewVersion(&config.Config{PrereleaseChannel: "beta"}, commits, release).String())
+	commits = append([]*Commit{{SHA: "b", Type: "feat", Change: Change{Major: true, Minor: true}}}, commits...)
+	require.Equal(t, "2.0.0-beta.1", GetNewVersion(&config.Config{PrereleaseChannel: "beta"}, commits, release).String())
+}
+
+func TestPromoteRelease(t *testing.T) {
+	releases := Releases{
+		{SHA: "a", Version: semver.MustParse("1.2.3")},
+		{SHA: "b", Version: semver.MustParse("1.3.0-beta.1")},
+		{SHA: "c", Version: semver.MustParse("1.3.0-beta.3")},
+		{SHA: "d", Version: semver.MustParse("1.3.0-beta.2")},
+		{SHA: "e", Version: semver.MustParse("1.3.0-alpha.7")},
+	}
+	commits := []*Commit{
+		{SHA: "c", Type: "fix", Change: Change{Patch: true}},
+		{SHA: "d", Type: "feat", Change: Change{Minor: true}},
+		{SHA: "a"},
+	}
+	prerelease, err := releases.GetLatestReleaseOnChannel("", "beta")
+	require.NoError(t, err)
+	stable, err := releases.GetLatestRelease("")
+	require.NoError(t, err)
+	decision, err := PromoteRelease(prerelease, stable, commits)
+	require.NoError(t, err)
+	require.Equal(t, "1.3.0", decision.Version.String())
+	require.Equal(t, "minor", decision.Bump)
+	require.Equal(t, "promotion of 1.3.0-beta.3", decision.Reason())
+	require.Len(t, decision.Commits, 1)
+	require.Equal(t, "d", decision.Commits[0].SHA)
+
+	// the prerelease is already promoted
+	releases = append(releases, &Release{SHA: "c", Version: semver.MustParse("1.3.0")})
+	prerelease, err = releases.GetLatestReleaseOnChannel("", "beta")
+	require.NoError(t, err)
+	_, err = PromoteRelease(prerelease, prerelease, nil)
+	require.EqualError(t, err, "no prerelease to promote, the latest release is 1.3.0")
+
+	// no prerelease of the channel
+	_, err = PromoteRelease(InitialRelease(), InitialRelease(), nil)
+	require.EqualError(t, err, "no prerelease to promote, the latest release is 0.0.0")
+}
+
+func TestResolveMakeLatest(t *testing.T) {
+	latestRelease := &Release{SHA: "a", Version: semver.MustParse("2.0.0")}
+	testCases := []struct {
+		mode       string
+		newVersion string
+		expected   string
+	}{
+		{"", "2.1.0", ""},
+		{"true", "1.5.1", "true"},
+		{"false", "2.1.0", "false"},
+		{"auto", "2.1.0", "true"},
+		// a maintenance release of an older major must not become the latest release
+		{"auto", "1.5.1", "false"},
+		{"auto", "3.0.0-beta.1", "false"},
+	}
+	for _, tc := range testCases {
+		require.Equal(t, tc.expected, ResolveMakeLatest(tc.mode, semver.MustParse(tc.newVersion), latestRelease), "%s %s", tc.mode, tc.newVersion)
+	}
+}
+
 func TestGetChangelog(t *testing.T) {
 	commits := []*Commit{
 		{},
@@ -99,7 +577,7 @@ func TestGetChangelog(t *testing.T) {
 	changelog := GetChangelog(commits, latestRelease, newVersion)
 	if !strings.Contains(changelog, "* **app:** commit message (12345678)") ||
 		!strings.Contains(changelog, "* commit message (abcd)") ||
-		!strings.Contains(changelog, "#### yolo") ||
+		!strings.Contains(changelog, "#### Yolo") ||
 		!strings.Contains(changelog, "```BREAKING CHANGE: test\n```") ||
 		strings.Contains(changelog, "not included") {
 		t.Fail()
@@ -117,3 +595,427 @@ func compareCommit(c *Commit, t, s string, change Change) bool {
 	}
 	return true
 }
+
+func TestGetChangelogSinceLastStable(t *testing.T) {
+	stable := &Release{SHA: "v120", Version: semver.MustParse("1.2.0")}
+	beta2 := &Release{SHA: "beta2", Version: semver.MustParse("1.3.0-beta.2")}
+	// the commits since the latest stable release, newest first
+	commits := []*Commit{
+		{SHA: "fix3", Type: "fix", Message: "after beta 2", Change: Change{Patch: true}},
+		{SHA: "beta2", Type: "feat", Message: "released in beta 2", Change: Change{Minor: true}},
+		{SHA: "fix1", Type: "fix", Message: "released in beta 2 too", Change: Change{Patch: true}},
+		{SHA: "beta1", Type: "feat", Message: "released in beta 1", Change: Change{Minor: true}},
+		{SHA: "v120", Type: "fix", Message: "released in 1.2.0", Change: Change{Patch: true}},
+	}
+	conf := &config.Config{PrereleaseChannel: "beta"}
+
+	// the version is calculated from the latest prerelease in both modes
+	newVersion := GetNewVersion(conf, commits, beta2)
+	require.Equal(t, "1.3.0-beta.3", newVersion.String())
+	require.Equal(t, newVersion, GetNewVersion(conf, commits[:2], beta2))
+
+	sincePrevious := GetChangelogWithConfig(conf, commits, beta2, newVersion, nil)
+	require.Contains(t, sincePrevious, "after beta 2")
+	require.NotContains(t, sincePrevious, "released in beta")
+
+	sinceStable := GetChangelogWithConfig(conf, commits, stable, newVersion, nil)
+	require.Contains(t, sinceStable, "after beta 2")
+	require.Contains(t, sinceStable, "released in beta 2 too")
+	require.Contains(t, sinceStable, "released in beta 1")
+	require.NotContains(t, sinceStable, "released in 1.2.0")
+
+	// the merge commit of the prerelease is kept as boundary when merge commits are filtered
+	merged := []*Commit{
+		{SHA: "fix3", Type: "fix", Message: "after beta 2", Change: Change{Patch: true}},
+		{SHA: "beta2", Message: "Merge branch 'feature'", Parents: []string{"fix1", "feature"}},
+		{SHA: "fix1", Type: "fix", Message: "released in beta 2 too", Change: Change{Patch: true}},
+		{SHA: "v120", Type: "fix", Message: "released in 1.2.0", Change: Change{Patch: true}},
+	}
+	conf.IgnoreMergeCommits = true
+	filtered := FilterCommits(conf, merged, stable, beta2)
+	require.Len(t, filtered, 4)
+	require.Equal(t, "1.3.0-beta.3", GetNewVersion(conf, filtered, beta2).String())
+	require.Len(t, FilterCommits(conf, merged, stable), 3)
+
+	// only commits already released in beta 2 do not release a new prerelease
+	require.Nil(t, GetNewVersion(conf, FilterCommits(conf, merged[1:], stable, beta2), beta2))
+}
+
+func TestEscapeMarkdown(t *testing.T) {
+	testCases := []struct {
+		msg      string
+		expected string
+	}{
+		{"support **kwargs", `support \*\*kwargs`},
+		{"rename snake_case_name", `rename snake\_case\_name`},
+		{"quote `code` blocks", "quote \\`code\\` blocks"},
+		{"# not a heading", `\# not a heading`},
+		{"#123 fixed", "#123 fixed"},
+		{"closes #123", "closes #123"},
+		{"escape [link](url) and <html>", `escape \[link\](url) and \<html\>`},
+	}
+	for _, tc := range testCases {
+		require.Equal(t, tc.expected, escapeMarkdown(tc.msg))
+	}
+}
+
+func TestGetChangelogIssueLinks(t *testing.T) {
+	commits := []*Commit{
+		{SHA: "aaaa", Type: "fix", Message: "crash on start (#12), see other/repo#3"},
+		{SHA: "stop"},
+	}
+	newVersion, _ := semver.NewVersion("1.0.1")
+	changelog := GetChangelogWithConfig(&config.Config{}, commits, &Release{SHA: "stop"}, newVersion, nil)
+	require.Contains(t, changelog, "* crash on start (#12), see other/repo#3 (aaaa)")
+
+	changelog = GetChangelogWithConfig(&config.Config{Slug: "owner/repo", ChangelogIssueLinks: true}, commits, &Release{SHA: "stop"}, newVersion, nil)
+	require.Contains(t, changelog, "* crash on start (owner/repo#12), see other/repo#3 (aaaa)")
+}
+
+func TestGetChangelogLinks(t *testing.T) {
+	commits := []*Commit{
+		{SHA: "aaaa", Type: "fix", Message: "login", Links: []string{"#42", "JIRA-7", "#43"}},
+		{SHA: "bbbb", Type: "fix", Message: "logout", Author: "Jane", Links: []string{"#44"}},
+		{SHA: "stop"},
+	}
+	newVersion, _ := semver.NewVersion("1.0.1")
+	changelog := GetChangelogWithConfig(&config.Config{CreditBy: "author"}, commits, &Release{SHA: "stop"}, newVersion, nil)
+	require.Contains(t, changelog, "* login (aaaa), refs #42, JIRA-7, #43\n")
+	require.Contains(t, changelog, "* logout (bbbb) by Jane, refs #44\n")
+
+	conf := &config.Config{Slug: "owner/repo", ChangelogIssueLinks: true, IssueURL: template.Must(template.New("issue").Parse("https://jira.example.com/browse/{{.Issue}}"))}
+	changelog = GetChangelogWithConfig(conf, commits, &Release{SHA: "stop"}, newVersion, nil)
+	require.Contains(t, changelog, "* login (aaaa), refs owner/repo#42, [JIRA-7](https://jira.example.com/browse/JIRA-7), owner/repo#43\n")
+}
+
+func TestGetChangelogIncludeBody(t *testing.T) {
+	commits := []*Commit{
+		ParseCommit("aaaa", "feat: search\n\nSearches the title\nand the description.\n\n  indented example\n\nSigned-off-by: Jane Doe <jane@example.com>\nCo-authored-by: John Roe <john@example.com>\n"),
+		ParseCommit("bbbb", "fix: login\n\nSigned-off-by: Jane Doe <jane@example.com>"),
+		ParseCommit("cccc", "feat!: drop v1\n\nBREAKING CHANGE: the v1 api is removed"),
+		{SHA: "stop"},
+	}
+	newVersion, _ := semver.NewVersion("2.0.0")
+	changelog := GetChangelogWithConfig(&config.Config{}, commits, &Release{SHA: "stop"}, newVersion, nil)
+	require.Contains(t, changelog, "* search (aaaa)\n\n#### Bug Fixes")
+	require.NotContains(t, changelog, "Searches the title")
+
+	changelog = GetChangelogWithConfig(&config.Config{ChangelogIncludeBody: true}, commits, &Release{SHA: "stop"}, newVersion, nil)
+	require.Contains(t, changelog, "* search (aaaa)\n\n  Searches the title\n  and the description.\n\n    indented example\n\n")
+	require.NotContains(t, changelog, "Signed-off-by")
+	require.NotContains(t, changelog, "Co-authored-by")
+	// a body of trailers only is omitted
+	require.True(t, strings.HasSuffix(changelog, "* login (bbbb)\n\n"), changelog)
+	require.Equal(t, 1, strings.Count(changelog, "the v1 api is removed"))
+
+	// the footers parsed into the commit are not repeated in the body
+	commits = []*Commit{
+		ParseCommit("dddd", "feat: export\n\nExports the results.\n\nDEPRECATED: the csv flag\nFixes #42, #43\nRefs JIRA-7\n"),
+		ParseCommit("eeee", "fix: import\n\nFixes #44\n\nKeeps the order, fixes #45 as well."),
+		{SHA: "stop"},
+	}
+	changelog = GetChangelogWithConfig(&config.Config{ChangelogIncludeBody: true}, commits, &Release{SHA: "stop"}, newVersion, nil)
+	require.Contains(t, changelog, "* export (dddd), refs #42, #43, JIRA-7\n\n  Exports the results.\n\n")
+	require.NotContains(t, changelog, "DEPRECATED")
+	require.NotContains(t, changelog, "Fixes #42")
+	require.Contains(t, changelog, "* import (eeee), refs #44, #45\n\n  Keeps the order, fixes #45 as well.\n\n")
+}
+
+func TestGetChangelogGroupByScope(t *testing.T) {
+	commits := []*Commit{
+		{SHA: "aaaa", Type: "feat", Scope: "ui", Message: "dark mode"},
+		{SHA: "bbbb", Type: "feat", Message: "faster startup"},
+		{SHA: "cccc", Type: "feat", Scope: "api", Message: "users endpoint"},
+		{SHA: "dddd", Type: "fix", Scope: "api", Message: "status code"},
+		{SHA: "eeee", Type: "feat", Scope: "api", Message: "groups endpoint"},
+		{SHA: "stop", Type: "feat", Message: "not included"},
+	}
+	newVersion, _ := semver.NewVersion("2.0.0")
+	conf := &config.Config{ChangelogGroupByScope: true, ReleaseTimestamp: time.Date(2020, 5, 1, 0, 0, 0, 0, time.UTC)}
+	changelog := GetChangelogWithConfig(conf, commits, &Release{SHA: "stop"}, newVersion, nil)
+	expected := "## 2.0.0 (2020-05-01)\n\n" +
+		"#### Feature\n\n" +
+		"##### api\n\n* users endpoint (cccc)\n* groups endpoint (eeee)\n\n" +
+		"##### ui\n\n* dark mode (aaaa)\n\n" +
+		"##### general\n\n* faster startup (bbbb)\n\n" +
+		"#### Bug Fixes\n\n" +
+		"##### api\n\n* status code (dddd)\n\n"
+	require.Equal(t, expected, changelog)
+}
+
+func TestGetChangelogTypes(t *testing.T) {
+	commits := []*Commit{
+		{SHA: "aaaa", Type: "feat", Message: "dark mode"},
+		{SHA: "bbbb", Type: "fix", Message: "status code"},
+		{SHA: "cccc", Type: "chore", Message: "bump deps"},
+		{SHA: "dddd", Type: "docs", Message: "usage"},
+		{SHA: "eeee", Type: "chore", Message: "drop go 1.13", Change: Change{Major: true}, Raw: []string{"", "BREAKING CHANGE: go 1.14 is required"}},
+		{SHA: "stop", Type: "feat", Message: "not included"},
+	}
+	newVersion, _ := semver.NewVersion("2.0.0")
+	sectionTypes := func(conf *config.Config) []string {
+		types := make([]string, 0)
+		for _, section := range NewChangelogData(conf, commits, &Release{SHA: "stop"}, newVersion).Sections {
+			types = append(types, section.Type)
+		}
+		return types
+	}
+
+	require.Equal(t, []string{"%%bc%%", "chore", "docs", "feat", "fix"}, sectionTypes(&config.Config{}))
+	// breaking changes are listed regardless of their type
+	require.Equal(t, []string{"%%bc%%", "feat", "fix"}, sectionTypes(&config.Config{ChangelogExcludeTypes: []string{"chore", "docs"}}))
+	require.Equal(t, []string{"%%bc%%", "fix"}, sectionTypes(&config.Config{ChangelogIncludeTypes: []string{"fix"}}))
+	require.Equal(t, []string{"%%bc%%", "docs", "fix"}, sectionTypes(&config.Config{
+		ChangelogIncludeTypes: []string{"fix", "docs"},
+		ChangelogExcludeTypes: []string{"docs", "feat"},
+	}))
+
+	changelog := GetChangelogWithConfig(&config.Config{ChangelogExcludeTypes: []string{"chore"}}, commits, &Release{SHA: "stop"}, newVersion, nil)
+	require.NotContains(t, changelog, "bump deps")
+	require.Contains(t, changelog, "drop go 1.13")
+}
+
+func TestGetChangelogSectionTitles(t *testing.T) {
+	commits := []*Commit{
+		{SHA: "aaaa", Type: "feat", Message: "dark mode"},
+		{SHA: "bbbb", Type: "fix", Message: "status code"},
+		{SHA: "cccc", Type: "chore", Message: "bump deps"},
+		{SHA: "dddd", Type: "perf", Message: "cache", Change: Change{Major: true}, Raw: []string{"", "BREAKING CHANGE: the cache is required"}},
+		{SHA: "stop", Type: "feat", Message: "not included"},
+	}
+	newVersion, _ := semver.NewVersion("2.0.0")
+	conf := &config.Config{
+		ChangelogSectionTitles: map[string]string{"fix": "Patches", "feat": "New Features"},
+		ChangelogSectionOrder:  []string{"fix", "feat"},
+	}
+	titles := make([]string, 0)
+	for _, section := range NewChangelogData(conf, commits, &Release{SHA: "stop"}, newVersion).Sections {
+		titles = append(titles, section.Title)
+	}
+	// the breaking changes stay first, the unmapped types keep their default title
+	require.Equal(t, []string{"Breaking Changes", "Patches", "New Features", "Chores"}, titles)
+
+	// the breaking changes are moved and renamed by their breaking key
+	conf.ChangelogSectionTitles["breaking"] = "Breaking"
+	conf.ChangelogSectionOrder = append(conf.ChangelogSectionOrder, "breaking")
+	changelog := GetChangelogWithConfig(conf, commits, &Release{SHA: "stop"}, newVersion, nil)
+	require.True(t, strings.Index(changelog, "#### Patches") < strings.Index(changelog, "#### New Features"), changelog)
+	require.True(t, strings.Index(changelog, "#### New Features") < strings.Index(changelog, "#### Breaking\n"), changelog)
+	require.True(t, strings.Index(changelog, "#### Breaking\n") < strings.Index(changelog, "#### Chores"), changelog)
+
+	// types without a default title are capitalized
+	commits = append([]*Commit{{SHA: "eeee", Type: "build", Message: "go 1.14"}}, commits...)
+	changelog = GetChangelogWithConfig(conf, commits, &Release{SHA: "stop"}, newVersion, nil)
+	require.Contains(t, changelog, "#### Build\n")
+}
+
+func TestGetChangelogReverts(t *testing.T) {
+	version, _ := semver.NewVersion("1.0.0")
+	release := &Release{SHA: "stop", Version: version}
+	commits := []*Commit{
+		{SHA: "cccc1234", Type: "revert", Message: "new endpoint", Reverts: "aaaa1234"},
+		{SHA: "bbbb1234", Type: "fix", Message: "typo", Change: Change{Patch: true}},
+		{SHA: "aaaa1234beef", Type: "feat", Message: "new endpoint", Change: Change{Minor: true}},
+		{SHA: "stop", Type: "feat", Message: "released", Change: Change{Minor: true}},
+	}
+	newVersion := GetNewVersion(&config.Config{}, commits, release)
+	require.Equal(t, "1.0.1", newVersion.String())
+
+	changelog := GetChangelogWithConfig(&config.Config{}, commits, release, newVersion, nil)
+	require.Contains(t, changelog, "#### Reverts\n\n* new endpoint (cccc1234)\n")
+	require.Contains(t, changelog, "#### Bug Fixes")
+	require.NotContains(t, changelog, "#### Feature")
+
+	// a revert of a commit of a previous release does not neutralize anything
+	newVersion = GetNewVersion(&config.Config{}, commits[:3], &Release{SHA: "aaaa1234beef", Version: version})
+	require.Equal(t, "1.0.1", newVersion.String())
+}
+
+func TestGenerateChangelogTemplate(t *testing.T) {
+	commits := []*Commit{
+		{SHA: "aaaa", Type: "feat", Scope: "api", Message: "users endpoint"},
+		{SHA: "bbbb", Type: "fix", Message: "status code"},
+		{SHA: "cccc", Type: "feat", Message: "dark mode"},
+		{SHA: "stop", Type: "feat", Message: "not included"},
+	}
+	release := &Release{SHA: "stop", Version: semver.MustParse("1.0.0")}
+	newVersion := semver.MustParse("1.1.0")
+	tmpl := template.Must(template.New("keepachangelog").Parse(
+		"## [{{.Version}}] - {{.Date}} (since {{.PreviousVersion}})\n{{range .Sections}}### {{.Title}}\n{{range .Commits}}- {{.Message}}\n{{end}}{{end}}"))
+	conf := &config.Config{ChangelogTemplate: tmpl, ReleaseTimestamp: time.Date(2020, 5, 1, 0, 0, 0, 0, time.UTC)}
+	changelog, err := GenerateChangelog(conf, commits, release, newVersion)
+	require.NoError(t, err)
+	require.Equal(t, "## [1.1.0] - 2020-05-01 (since 1.0.0)\n### Feature\n- users endpoint\n- dark mode\n### Bug Fixes\n- status code\n", changelog)
+
+	// the default template renders the same changelog as before
+	conf.ChangelogTemplate = nil
+	changelog, err = GenerateChangelog(conf, commits, release, newVersion)
+	require.NoError(t, err)
+	require.Equal(t, "## 1.1.0 (2020-05-01)\n\n#### Feature\n\n* **api:** users endpoint (aaaa)\n* dark mode (cccc)\n\n#### Bug Fixes\n\n* status code (bbbb)\n\n", changelog)
+
+	conf.ChangelogTemplate = template.Must(template.New("broken").Parse("{{.Unknown}}"))
+	_, err = GenerateChangelog(conf, commits, release, newVersion)
+	require.Error(t, err)
+	var logged bytes.Buffer
+	logger := logging.New(&logged, "semantic-release", logging.Warn, "text")
+	require.Equal(t, changelog, GetChangelogWithConfig(conf, commits, release, newVersion, logger))
+	require.Contains(t, logged.String(), "[semantic-release]: warn: could not render changelog")
+	require.Contains(t, logged.String(), "falling back to the default changelog template")
+}
+
+func TestGetChangelogDeprecations(t *testing.T) {
+	commits := []*Commit{
+		{SHA: "123456789", Type: "feat", Scope: "api", Message: "new endpoint", Deprecation: "use /v2/users instead", Change: Change{Minor: true}},
+		{SHA: "abcd", Type: "deprecate", Scope: "", Message: "old flag"},
+		{SHA: "dcba", Type: "fix", Scope: "", Message: "commit message", Change: Change{Patch: true}},
+	}
+	latestRelease := &Release{SHA: "stop"}
+	newVersion, _ := semver.NewVersion("2.0.0")
+	changelog := GetChangelogWithConfig(&config.Config{}, commits, latestRelease, newVersion, nil)
+	if !strings.Contains(changelog, "#### Deprecations\n\n* **api:** new endpoint (12345678)\n```\nuse /v2/users instead\n```\n* old flag (abcd)\n") ||
+		!strings.Contains(changelog, "#### Feature\n\n* **api:** new endpoint (12345678)\n") ||
+		!strings.Contains(changelog, "#### Bug Fixes") ||
+		strings.Contains(changelog, "#### Deprecate\n") {
+		t.Fail()
+	}
+
+	version, _ := semver.NewVersion("1.0.0")
+	release := &Release{SHA: "stop", Version: version}
+	if newVersion := GetNewVersion(&config.Config{}, commits, release); newVersion.String() != "1.1.0" {
+		t.Errorf("expected: 1.1.0, got: %s", newVersion)
+	}
+	if newVersion := GetNewVersion(&config.Config{MajorOnDeprecation: true}, commits, release); newVersion.String() != "2.0.0" {
+		t.Errorf("expected: 2.0.0, got: %s", newVersion)
+	}
+}
+
+func TestGetChangelogReleaseTimestamp(t *testing.T) {
+	conf := &config.Config{ReleaseTimestamp: time.Date(2020, 4, 19, 23, 30, 0, 0, time.UTC)}
+	newVersion, _ := semver.NewVersion("2.0.0")
+	changelog := GetChangelogWithConfig(conf, []*Commit{}, &Release{}, newVersion, nil)
+	if !strings.HasPrefix(changelog, "## 2.0.0 (2020-04-19)\n") {
+		t.Errorf("invalid changelog header: %s", changelog)
+	}
+}
+
+func TestGetChangelogDateFormat(t *testing.T) {
+	newVersion, _ := semver.NewVersion("2.0.0")
+	conf := &config.Config{
+		ReleaseTimestamp:    time.Date(2020, 4, 19, 23, 30, 0, 0, time.UTC),
+		ChangelogDateFormat: "02.01.2006 15:04 MST",
+		ChangelogTimezone:   time.FixedZone("CEST", 2*60*60),
+	}
+	changelog := GetChangelogWithConfig(conf, []*Commit{}, &Release{}, newVersion, nil)
+	require.True(t, strings.HasPrefix(changelog, "## 2.0.0 (20.04.2020 01:30 CEST)\n"), changelog)
+
+	conf.ChangelogDateFormat = ""
+	require.Equal(t, "2020-04-20", NewChangelogData(conf, []*Commit{}, &Release{}, newVersion).Date)
+	conf.ChangelogTimezone = time.FixedZone("PDT", -7*60*60)
+	require.Equal(t, "2020-04-19", NewChangelogData(conf, []*Commit{}, &Release{}, newVersion).Date)
+}
+
+func TestGetChangelogCredits(t *testing.T) {
+	commits := []*Commit{
+		{SHA: "abcd", Type: "fix", Message: "commit message", Author: "Jane", Committer: "release-bot"},
+	}
+	newVersion, _ := semver.NewVersion("2.0.0")
+	changelog := GetChangelogWithConfig(&config.Config{CreditBy: "author"}, commits, &Release{}, newVersion, nil)
+	if !strings.Contains(changelog, "* commit message (abcd) by Jane\n") {
+		t.Errorf("invalid author credit: %s", changelog)
+	}
+	changelog = GetChangelogWithConfig(&config.Config{CreditBy: "committer"}, commits, &Release{}, newVersion, nil)
+	if !strings.Contains(changelog, "* commit message (abcd) by release-bot\n") {
+		t.Errorf("invalid committer credit: %s", changelog)
+	}
+	changelog = GetChangelogWithConfig(&config.Config{}, commits, &Release{}, newVersion, nil)
+	if !strings.Contains(changelog, "* commit message (abcd)\n") {
+		t.Errorf("unexpected credit: %s", changelog)
+	}
+}
+
+func TestGetChangelogContributors(t *testing.T) {
+	commits := []*Commit{
+		{SHA: "aaaa", Type: "feat", Message: "dark mode", Author: "Jane Doe", AuthorLogin: "jane", Committer: "GitHub", CoAuthors: []string{"John Roe", "Max_Mustermann"}},
+		{SHA: "bbbb", Type: "fix", Message: "crash", Author: "John Roe", CoAuthors: []string{"Jane Doe"}},
+		{SHA: "cccc", Type: "chore", Message: "deps", Author: "Jane Doe", AuthorLogin: "jane"},
+		{SHA: "stop", Type: "feat", Message: "not included", Author: "Old Author"},
+	}
+	newVersion, _ := semver.NewVersion("1.1.0")
+	conf := &config.Config{ReleaseTimestamp: time.Date(2020, 5, 1, 0, 0, 0, 0, time.UTC)}
+	changelog := GetChangelogWithConfig(conf, commits, &Release{SHA: "stop"}, newVersion, nil)
+	require.NotContains(t, changelog, "Contributors")
+
+	conf.ChangelogContributors = true
+	changelog = GetChangelogWithConfig(conf, commits, &Release{SHA: "stop"}, newVersion, nil)
+	// the sections are sorted by their type
+	expected := "## 1.1.0 (2020-05-01)\n\n" +
+		"#### Chores\n\n* deps (cccc)\n\n" +
+		"#### Feature\n\n* dark mode (aaaa)\n\n" +
+		"#### Bug Fixes\n\n* crash (bbbb)\n\n" +
+		"#### Contributors\n\n* @jane\n* John Roe\n* Max\\_Mustermann\n\n"
+	require.Equal(t, expected, changelog)
+}
+
+func TestGetReleaseTitle(t *testing.T) {
+	testCases := []struct {
+		changelog     string
+		expectedTitle string
+	}{
+		{"## 2.0.0 (2020-04-19)\n\n#### Feature\n", "2.0.0 (2020-04-19)"},
+		{"\n\n  Curated release notes  \nmore details", "Curated release notes"},
+		{"## Unreleased\n", "Unreleased"},
+		{"", ""},
+		{"\n##\n", ""},
+	}
+	for _, tc := range testCases {
+		if title := GetReleaseTitle(tc.changelog); title != tc.expectedTitle {
+			t.Errorf("expected: %q, got: %q", tc.expectedTitle, title)
+		}
+	}
+}
+
+func TestGetMovingTagVersions(t *testing.T) {
+	version := semver.MustParse("1.2.3")
+	versions := GetMovingTagVersions(version, []string{"major", "minor"})
+	if len(versions) != 2 || versions[0] != "1" || versions[1] != "1.2" {
+		t.Errorf("invalid moving tag versions: %v", versions)
+	}
+	if versions := GetMovingTagVersions(semver.MustParse("1.2.3-beta.1"), []string{"major", "minor"}); len(versions) != 0 {
+		t.Errorf("expected no moving tags for prerelease, got: %v", versions)
+	}
+	if versions := GetMovingTagVersions(version, nil); len(versions) != 0 {
+		t.Errorf("expected no moving tags, got: %v", versions)
+	}
+}
+
+func TestGenerateChangelogCommand(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh is not installed")
+	}
+	dir, err := ioutil.TempDir("", "semrel-changelog-command")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	// the fake command echoes its input and writes a diagnostic to stderr
+	script := filepath.Join(dir, "changelog.sh")
+	require.NoError(t, ioutil.WriteFile(script, []byte("#!/bin/sh\necho 'rendering changelog' >&2\necho '## changes'\ncat\n"), 0700))
+	failing := filepath.Join(dir, "failing.sh")
+	require.NoError(t, ioutil.WriteFile(failing, []byte("#!/bin/sh\necho 'unknown commit type' >&2\nexit 3\n"), 0700))
+
+	commits := []*Commit{
+		{SHA: "aaaa", Raw: []string{"feat(api)!: users endpoint"}, Type: "feat", Scope: "api", Message: "users endpoint", Author: "Jane", Change: Change{Major: true}},
+		{SHA: "bbbb", Raw: []string{"fix: status code"}, Type: "fix", Message: "status code", Author: "John"},
+		{SHA: "stop", Type: "feat", Message: "not included"},
+	}
+	release := &Release{SHA: "stop", Version: semver.MustParse("1.0.0")}
+	newVersion := semver.MustParse("2.0.0")
+	conf := &config.Config{ChangelogCommand: script, ReleaseTimestamp: time.Date(2020, 5, 1, 0, 0, 0, 0, time.UTC)}
+	changelog, err := GenerateChangelog(conf, commits, release, newVersion)
+	require.NoError(t, err)
+	require.Equal(t, "## changes\n"+`{"version":"2.0.0","previousVersion":"1.0.0","date":"2020-05-01","commits":[`+
+		`{"sha":"aaaa","type":"feat","scope":"api","message":"users endpoint","raw":"feat(api)!: users endpoint","breaking":true,"author":"Jane","date":"0001-01-01T00:00:00Z"},`+
+		`{"sha":"bbbb","type":"fix","scope":"","message":"status code","raw":"fix: status code","breaking":false,"author":"John","date":"0001-01-01T00:00:00Z"}]}`, changelog)
+
+	conf.ChangelogCommand = failing
+	_, err = GenerateChangelog(conf, commits, release, newVersion)
+	require.EqualError(t, err, "changelog command failed: exit status 3: unknown commit type")
+}
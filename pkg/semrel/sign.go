@@ -0,0 +1,62 @@
+package semrel
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// TagSigner returns an armored detached signature of the tag object payload
+type TagSigner func(payload []byte) ([]byte, error)
+
+// NewGPGSigner signs with the local gpg installation using the given key id
+func NewGPGSigner(key string) (TagSigner, error) {
+	if key == "" {
+		return nil, errors.New("signing tags requires a signing key")
+	}
+	gpg, err := exec.LookPath("gpg")
+	if err != nil {
+		return nil, errors.New("signing tags requires gpg to be installed")
+	}
+	return func(payload []byte) ([]byte, error) {
+		var stdout, stderr bytes.Buffer
+		cmd := exec.Command(gpg, "--batch", "--armor", "--detach-sign", "--local-user", key)
+		cmd.Stdin = bytes.NewReader(payload)
+		cmd.Stdout = &stdout
+		cmd.Stderr = &stderr
+		if err := cmd.Run(); err != nil {
+			return nil, fmt.Errorf("could not sign tag: %w: %s", err, bytes.TrimSpace(stderr.Bytes()))
+		}
+		return stdout.Bytes(), nil
+	}, nil
+}
+
+// tagPayload renders the tag object as git stores it, this is the content covered by the signature
+func tagPayload(tag, sha, message string, timestamp time.Time) []byte {
+	return []byte(fmt.Sprintf("object %s\ntype commit\ntag %s\ntagger %s <%s> %d +0000\n\n%s",
+		sha, tag, taggerName, taggerEmail, timestamp.Unix(), message))
+}
+
+// CheckCommitSignatures returns an error listing the commits since the latest release without a verified signature,
+// commits without a Verification are reported as unknown
+func CheckCommitSignatures(commits []*Commit, latestRelease *Release) error {
+	failed := make([]string, 0)
+	for _, commit := range commits {
+		if latestRelease.SHA == commit.SHA {
+			break
+		}
+		switch {
+		case commit.Verification == nil:
+			failed = append(failed, fmt.Sprintf("%s (unknown)", trimSHA(commit.SHA)))
+		case !commit.Verification.Verified:
+			failed = append(failed, fmt.Sprintf("%s (%s)", trimSHA(commit.SHA), commit.Verification.Reason))
+		}
+	}
+	if len(failed) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%d commit(s) without a verified signature: %s", len(failed), strings.Join(failed, ", "))
+}
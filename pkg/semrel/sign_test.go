@@ -0,0 +1,29 @@
+package semrel
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewGPGSigner(t *testing.T) {
+	_, err := NewGPGSigner("")
+	require.EqualError(t, err, "signing tags requires a signing key")
+}
+
+func TestTagPayload(t *testing.T) {
+	payload := tagPayload("v1.0.0", "deadbeef", "v1.0.0\n", time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC))
+	expected := "object deadbeef\ntype commit\ntag v1.0.0\ntagger semantic-release <semantic-release@users.noreply.github.com> 1577934245 +0000\n\nv1.0.0\n"
+	require.Equal(t, expected, string(payload))
+}
+
+func TestCheckCommitSignatures(t *testing.T) {
+	commits := []*Commit{
+		{SHA: "a", Verification: &Verification{Verified: true, Reason: "valid"}},
+		{SHA: "b"},
+		{SHA: "release"},
+	}
+	require.EqualError(t, CheckCommitSignatures(commits, &Release{SHA: "release"}), "1 commit(s) without a verified signature: b (unknown)")
+	require.NoError(t, CheckCommitSignatures(commits[:1], &Release{SHA: "release"}))
+}
@@ -0,0 +1,22 @@
+package semrel
+
+// SplitRepository reads from one repository and creates the release with another, e.g. to read with a token that
+// can not push tags and only use the token with write access for the release
+type SplitRepository struct {
+	Repository
+	Write Repository
+}
+
+// NewSplitRepository creates a repository that reads from read and creates releases with write
+func NewSplitRepository(read, write Repository) *SplitRepository {
+	return &SplitRepository{Repository: read, Write: write}
+}
+
+func (repo *SplitRepository) CreateRelease(release *CreateReleaseConfig) error {
+	return repo.Write.CreateRelease(release)
+}
+
+// GetCommitsUntil reads the commits with the read repository, which may not implement CommitsUntilRepository
+func (repo *SplitRepository) GetCommitsUntil(sha, releaseSHA string) ([]*Commit, error) {
+	return GetCommitsUntil(repo.Repository, sha, releaseSHA)
+}
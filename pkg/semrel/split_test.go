@@ -0,0 +1,55 @@
+package semrel
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Masterminds/semver"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitRepository(t *testing.T) {
+	var mu sync.Mutex
+	tokens := make(map[string]string)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		tokens[r.Method+" "+r.URL.Path] = r.Header.Get("Authorization")
+		mu.Unlock()
+		r.Header.Set("Authorization", "Bearer token")
+		githubHandler(w, r)
+	}))
+	defer ts.Close()
+	newRepo := func(token string) *GitHubRepository {
+		repo, err := NewGitHubRepository(context.TODO(), "", "", "", "owner/test-repo", token)
+		require.NoError(t, err)
+		repo.Client.BaseURL, _ = url.Parse(ts.URL + "/")
+		return repo
+	}
+	repo := NewSplitRepository(newRepo("read"), newRepo("write"))
+
+	_, _, err := repo.GetInfo()
+	require.NoError(t, err)
+	_, err = repo.GetCommits("deadbeef")
+	require.NoError(t, err)
+	_, err = repo.GetLatestRelease(&GetLatestReleaseConfig{})
+	require.NoError(t, err)
+	err = repo.CreateRelease(&CreateReleaseConfig{Changelog: "changelog", NewVersion: semver.MustParse("2.0.0"), SHA: "deadbeef"})
+	require.NoError(t, err)
+
+	require.Equal(t, "Bearer read", tokens["GET /repos/owner/test-repo"])
+	require.Equal(t, "Bearer read", tokens["GET /repos/owner/test-repo/commits"])
+	require.Equal(t, "Bearer write", tokens["POST /repos/owner/test-repo/git/refs"])
+	require.Equal(t, "Bearer write", tokens["POST /repos/owner/test-repo/releases"])
+	for request, token := range tokens {
+		if strings.HasPrefix(request, "GET ") {
+			require.Equal(t, "Bearer read", token, request)
+		}
+	}
+	require.Equal(t, "owner", repo.Owner())
+	require.Equal(t, "GitHub", repo.Provider())
+}
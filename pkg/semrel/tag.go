@@ -0,0 +1,82 @@
+package semrel
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"text/template"
+)
+
+// DefaultTagFormat is the tag of a release, e.g. v1.2.3
+const DefaultTagFormat = "v{{.Version}}"
+
+// DefaultGitHubTagFormat prefixes the tag with the package name on GitHub if one is set, e.g. app-v1.2.3 or v1.2.3
+const DefaultGitHubTagFormat = "{{if .Pkg}}{{.Pkg}}-{{end}}v{{.Version}}"
+
+// versionPlaceholder is rendered in place of the version to find the fixed parts of a tag format
+const versionPlaceholder = "\x00version\x00"
+
+// TagFormat renders and parses release tags, a nil TagFormat uses the DefaultTagFormat without a package name
+type TagFormat struct {
+	tmpl   *template.Template
+	pkg    string
+	prefix string
+	suffix string
+}
+
+var defaultTagFormat, _ = NewTagFormat("", "")
+
+// NewTagFormat parses the Go template format which has .Version and .Pkg available, an empty format uses the DefaultTagFormat
+func NewTagFormat(format, pkg string) (*TagFormat, error) {
+	if format == "" {
+		format = DefaultTagFormat
+	}
+	tmpl, err := template.New("tag").Option("missingkey=error").Parse(format)
+	if err != nil {
+		return nil, err
+	}
+	f := &TagFormat{tmpl: tmpl, pkg: pkg}
+	rendered, err := f.render(versionPlaceholder)
+	if err != nil {
+		return nil, err
+	}
+	if strings.Count(rendered, versionPlaceholder) != 1 {
+		return nil, errors.New("tag format must contain {{.Version}} exactly once")
+	}
+	split := strings.SplitN(rendered, versionPlaceholder, 2)
+	f.prefix, f.suffix = split[0], split[1]
+	return f, nil
+}
+
+func (f *TagFormat) render(version string) (string, error) {
+	var buf bytes.Buffer
+	err := f.tmpl.Execute(&buf, struct {
+		Version string
+		Pkg     string
+	}{version, f.pkg})
+	return buf.String(), err
+}
+
+// Format returns the tag of the given version, the version may be partial (e.g. 1 or 1.2) for moving tags
+func (f *TagFormat) Format(version string) string {
+	if f == nil {
+		f = defaultTagFormat
+	}
+	// the fixed parts are known and executing the template can not fail anymore
+	return f.prefix + version + f.suffix
+}
+
+// Version extracts the version of the tag, ok is false if the tag was not created with this format.
+// A format rendering tags like v1.2.3 also accepts plain versions like 1.2.3 as the v prefix is optional for versions.
+func (f *TagFormat) Version(tag string) (version string, ok bool) {
+	if f == nil {
+		f = defaultTagFormat
+	}
+	if len(tag) > len(f.prefix)+len(f.suffix) && strings.HasPrefix(tag, f.prefix) && strings.HasSuffix(tag, f.suffix) {
+		return tag[len(f.prefix) : len(tag)-len(f.suffix)], true
+	}
+	if f.prefix == "v" && f.suffix == "" && tag != "" && tag[0] >= '0' && tag[0] <= '9' {
+		return tag, true
+	}
+	return "", false
+}
@@ -0,0 +1,57 @@
+package semrel
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTagFormat(t *testing.T) {
+	testCases := []struct {
+		format          string
+		pkg             string
+		version         string
+		expectedTag     string
+		foreignTag      string
+		acceptedForeign bool
+	}{
+		{"", "", "1.2.3", "v1.2.3", "1.2.3", true},
+		{"", "", "1.2.4", "v1.2.4", "test-tag", false},
+		{"", "app", "1.2.5", "v1.2.5", "app-v1.2.5", false},
+		{DefaultGitHubTagFormat, "", "1.2.3", "v1.2.3", "1.2.3", true},
+		{DefaultGitHubTagFormat, "app", "1.2.3", "app-v1.2.3", "v1.2.3", false},
+		{"{{.Version}}", "", "1.2.3", "1.2.3", "1.2.3", true},
+		{"{{.Pkg}}@{{.Version}}", "app", "1.2.3-beta.1", "app@1.2.3-beta.1", "lib@1.2.3", false},
+		{"release-{{.Version}}-final", "", "2.0.0", "release-2.0.0-final", "v2.0.0", false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.expectedTag, func(t *testing.T) {
+			f, err := NewTagFormat(tc.format, tc.pkg)
+			require.NoError(t, err)
+			tag := f.Format(tc.version)
+			require.Equal(t, tc.expectedTag, tag)
+			version, ok := f.Version(tag)
+			require.True(t, ok)
+			require.Equal(t, tc.version, version)
+			_, ok = f.Version(tc.foreignTag)
+			require.Equal(t, tc.acceptedForeign, ok)
+		})
+	}
+}
+
+func TestTagFormatNil(t *testing.T) {
+	var f *TagFormat
+	require.Equal(t, "v1.2", f.Format("1.2"))
+	version, ok := f.Version("v1.2.3")
+	require.True(t, ok)
+	require.Equal(t, "1.2.3", version)
+}
+
+func TestNewTagFormatErrors(t *testing.T) {
+	_, err := NewTagFormat("{{.Version", "")
+	require.Error(t, err)
+	_, err = NewTagFormat("release", "")
+	require.EqualError(t, err, "tag format must contain {{.Version}} exactly once")
+	_, err = NewTagFormat("{{.Name}}-{{.Version}}", "")
+	require.Error(t, err)
+}
@@ -0,0 +1,65 @@
+package semrel
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ChecksumsFile is the name of the uploaded checksums of the release files
+const ChecksumsFile = "SHA256SUMS"
+
+// ExpandUploads returns the files matching the glob patterns, every pattern must match at least one file
+func ExpandUploads(patterns []string) ([]string, error) {
+	seen := make(map[string]bool)
+	files := make([]string, 0, len(patterns))
+	for _, pattern := range patterns {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid upload pattern %q: %w", pattern, err)
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("no files match the upload pattern %q", pattern)
+		}
+		for _, file := range matches {
+			if !seen[file] {
+				seen[file] = true
+				files = append(files, file)
+			}
+		}
+	}
+	return files, nil
+}
+
+// Checksums returns the sha256 checksums of the files in the format of sha256sum, sorted by file name
+func Checksums(files []string) (string, error) {
+	lines := make([]string, 0, len(files))
+	for _, file := range files {
+		sum, err := sha256File(file)
+		if err != nil {
+			return "", err
+		}
+		lines = append(lines, fmt.Sprintf("%x  %s\n", sum, filepath.Base(file)))
+	}
+	sort.Slice(lines, func(i, j int) bool {
+		return lines[i][2*sha256.Size:] < lines[j][2*sha256.Size:]
+	})
+	return strings.Join(lines, ""), nil
+}
+
+func sha256File(file string) ([]byte, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
@@ -0,0 +1,47 @@
+package semrel
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func writeUploadFiles(t *testing.T, files map[string]string) string {
+	dir, err := ioutil.TempDir("", "semantic-release-upload")
+	require.NoError(t, err)
+	for name, content := range files {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+	}
+	return dir
+}
+
+func TestExpandUploads(t *testing.T) {
+	dir := writeUploadFiles(t, map[string]string{"app-linux": "", "app-darwin": "", "README.md": ""})
+	defer os.RemoveAll(dir)
+
+	files, err := ExpandUploads([]string{filepath.Join(dir, "app-*"), filepath.Join(dir, "app-linux")})
+	require.NoError(t, err)
+	require.Equal(t, []string{filepath.Join(dir, "app-darwin"), filepath.Join(dir, "app-linux")}, files)
+
+	_, err = ExpandUploads([]string{filepath.Join(dir, "*.zip")})
+	require.EqualError(t, err, `no files match the upload pattern "`+filepath.Join(dir, "*.zip")+`"`)
+
+	_, err = ExpandUploads([]string{"["})
+	require.Error(t, err)
+}
+
+func TestChecksums(t *testing.T) {
+	dir := writeUploadFiles(t, map[string]string{"b.txt": "b", "a.txt": "a"})
+	defer os.RemoveAll(dir)
+
+	sums, err := Checksums([]string{filepath.Join(dir, "b.txt"), filepath.Join(dir, "a.txt")})
+	require.NoError(t, err)
+	require.Equal(t, "ca978112ca1bbdcafac231b39a23dc4da786eff8147c4e72b9807785afee48bb  a.txt\n"+
+		"3e23e8160039594a33894f6564e1b1348bbd7a0088d42c4acb73eeaed59c009d  b.txt\n", sums)
+
+	_, err = Checksums([]string{filepath.Join(dir, "missing")})
+	require.Error(t, err)
+}
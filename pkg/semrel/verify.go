@@ -0,0 +1,86 @@
+package semrel
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Check is the result of a single step of the verification
+type Check struct {
+	Name string
+	Err  error
+}
+
+// Checks is the checklist printed by --verify
+type Checks []Check
+
+// Failed returns true if any of the checks failed
+func (checks Checks) Failed() bool {
+	for _, check := range checks {
+		if check.Err != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// Write prints the checklist with a green check mark for passed and a red cross for failed checks
+func (checks Checks) Write(w io.Writer) error {
+	for _, check := range checks {
+		var err error
+		if check.Err != nil {
+			_, err = fmt.Fprintf(w, "\033[31m✗\033[0m %s: %v\n", check.Name, check.Err)
+		} else {
+			_, err = fmt.Fprintf(w, "\033[32m✓\033[0m %s\n", check.Name)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// VerifyConfig is the branch and commit detected by the CI and the options to find the latest release
+type VerifyConfig struct {
+	Branch string
+	SHA    string
+	Latest *GetLatestReleaseConfig
+}
+
+// Verify checks that the repository is accessible and the tags and commits can be read, it only
+// sends read requests to the provider. The remaining API checks are skipped once the repository is not accessible.
+func Verify(repo Repository, verify *VerifyConfig) Checks {
+	checks := Checks{}
+	check := func(name string, err error) {
+		checks = append(checks, Check{name, err})
+	}
+
+	if verify.Branch == "" {
+		check("current branch detected", errors.New("current branch not found"))
+	} else {
+		check(fmt.Sprintf("current branch detected (%s)", verify.Branch), nil)
+	}
+
+	name := fmt.Sprintf("repository %s/%s accessible on %s", repo.Owner(), repo.Repo(), repo.Provider())
+	defaultBranch, _, err := repo.GetInfo()
+	if err != nil {
+		check(name, err)
+		return checks
+	}
+	check(fmt.Sprintf("%s (default branch %s)", name, defaultBranch), nil)
+
+	release, err := repo.GetLatestRelease(verify.Latest)
+	if errors.Is(err, ErrNoReleases) {
+		release, err = InitialRelease(), nil
+	}
+	if err != nil {
+		check("tags readable", err)
+		return checks
+	}
+	check(fmt.Sprintf("tags readable (latest version %s)", release.Version), nil)
+
+	_, err = GetCommitsUntil(repo, verify.SHA, release.SHA)
+	check("commits readable", err)
+	return checks
+}
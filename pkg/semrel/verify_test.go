@@ -0,0 +1,49 @@
+package semrel
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerify(t *testing.T) {
+	repo, ts := getNewGithubTestRepo(t)
+	defer ts.Close()
+
+	checks := Verify(repo, &VerifyConfig{Branch: "master", SHA: "", Latest: &GetLatestReleaseConfig{}})
+	require.False(t, checks.Failed())
+	require.Equal(t, Checks{
+		{Name: "current branch detected (master)"},
+		{Name: "repository owner/test-repo accessible on GitHub (default branch master)"},
+		{Name: "tags readable (latest version 2020.4.19)"},
+		{Name: "commits readable"},
+	}, checks)
+
+	var buf bytes.Buffer
+	require.NoError(t, checks.Write(&buf))
+	require.Contains(t, buf.String(), "\033[32m✓\033[0m commits readable\n")
+}
+
+func TestVerifyBadToken(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(githubHandler))
+	defer ts.Close()
+	repo, err := NewGitHubRepository(context.TODO(), "", "", "", "owner/test-repo", "invalid")
+	require.NoError(t, err)
+	repo.Client.BaseURL, _ = url.Parse(ts.URL + "/")
+
+	checks := Verify(repo, &VerifyConfig{Latest: &GetLatestReleaseConfig{}})
+	require.True(t, checks.Failed())
+	require.Len(t, checks, 2, "the remaining api checks must be skipped")
+	require.EqualError(t, checks[0].Err, "current branch not found")
+	require.Equal(t, "repository owner/test-repo accessible on GitHub", checks[1].Name)
+	require.Error(t, checks[1].Err)
+
+	var buf bytes.Buffer
+	require.NoError(t, checks.Write(&buf))
+	require.Contains(t, buf.String(), "\033[31m✗\033[0m current branch detected: current branch not found\n")
+}
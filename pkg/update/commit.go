@@ -0,0 +1,144 @@
+package update
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"strings"
+	"text/template"
+)
+
+// DefaultCommitMessage is the message of the commit of the updated files, [skip ci] keeps the push from
+// starting another pipeline
+const DefaultCommitMessage = "chore(release): {{.Tag}} [skip ci]"
+
+// CommitMessageData is passed to the commit message template
+type CommitMessageData struct {
+	Version string
+	Tag     string
+}
+
+// ParseCommitMessage parses the commit message template
+func ParseCommitMessage(message string) (*template.Template, error) {
+	return template.New("commit").Option("missingkey=error").Parse(message)
+}
+
+// RenderCommitMessage renders the commit message template with the version and tag
+func RenderCommitMessage(message string, data *CommitMessageData) (string, error) {
+	tmpl, err := ParseCommitMessage(message)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// FallbackName and FallbackEmail are the identity of the update commit if git has no user configured, e.g. on a
+// fresh CI runner
+const (
+	FallbackName  = "semantic-release"
+	FallbackEmail = "semantic-release@users.noreply.github.com"
+)
+
+// Files returns the files of the targets (e.g. the file of file:regex) and the existing files the updaters write
+// next to them (e.g. package-lock.json)
+func Files(targets []string, opts *Options) ([]string, error) {
+	files := make([]string, 0, len(targets))
+	for _, target := range targets {
+		file, pattern, err := ParseTarget(target)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, file)
+		if pattern == nil {
+			files = append(files, sideFiles(file, opts)...)
+		}
+	}
+	return files, nil
+}
+
+// sideFiles returns the existing files written by the updater of the file besides the file itself
+func sideFiles(file string, opts *Options) []string {
+	name := path.Base(file)
+	if opts != nil && opts.Type != "" {
+		name = opts.Type
+	}
+	if name != "package.json" {
+		return nil
+	}
+	lockFile := path.Join(path.Dir(file), "package-lock.json")
+	if _, err := os.Stat(lockFile); err != nil {
+		return nil
+	}
+	return []string{lockFile}
+}
+
+func git(dir string, args ...string) error {
+	var stderr bytes.Buffer
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("git %s: %w: %s", args[0], err, bytes.TrimSpace(stderr.Bytes()))
+	}
+	return nil
+}
+
+// identity returns the config options of the fallback identity for the user name and email git has not configured
+func identity(dir string) []string {
+	var args []string
+	if git(dir, "config", "user.name") != nil {
+		args = append(args, "-c", "user.name="+FallbackName)
+	}
+	if git(dir, "config", "user.email") != nil {
+		args = append(args, "-c", "user.email="+FallbackEmail)
+	}
+	return args
+}
+
+// CommitFiles commits the changes of the files in the git repository in dir, nothing is committed if the files
+// did not change. It reports whether a commit was created.
+func CommitFiles(dir string, files []string, message string) (bool, error) {
+	if err := git(dir, append([]string{"add", "--"}, files...)...); err != nil {
+		return false, err
+	}
+	// exits with 1 if there are staged changes
+	err := git(dir, append([]string{"diff", "--cached", "--quiet", "--"}, files...)...)
+	var exitErr *exec.ExitError
+	if err == nil {
+		return false, nil
+	}
+	if !errors.As(err, &exitErr) || exitErr.ExitCode() != 1 {
+		return false, err
+	}
+	// only the files are committed, other staged changes are left as they are
+	args := append(identity(dir), "commit", "--quiet", "-m", strings.TrimSpace(message), "--")
+	if err := git(dir, append(args, files...)...); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// HeadSHA returns the sha of the current commit of the git repository in dir
+func HeadSHA(dir string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("git", "rev-parse", "HEAD")
+	cmd.Dir = dir
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("git rev-parse: %w: %s", err, bytes.TrimSpace(stderr.Bytes()))
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// PushCommit pushes the current commit to the branch of the remote, it also works on the detached HEAD of most CIs
+func PushCommit(dir, remote, branch string) error {
+	return git(dir, "push", "--quiet", remote, "HEAD:refs/heads/"+branch)
+}
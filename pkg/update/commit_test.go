@@ -0,0 +1,122 @@
+package update
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderCommitMessage(t *testing.T) {
+	message, err := RenderCommitMessage(DefaultCommitMessage, &CommitMessageData{Version: "1.2.0", Tag: "v1.2.0"})
+	require.NoError(t, err)
+	require.Equal(t, "chore(release): v1.2.0 [skip ci]", message)
+
+	_, err = RenderCommitMessage("release {{.Unknown}}", &CommitMessageData{})
+	require.Error(t, err)
+}
+
+func TestFiles(t *testing.T) {
+	files, err := Files([]string{"package.json", `Dockerfile:ARG VERSION=([\d.]+)`}, nil)
+	require.NoError(t, err)
+	require.Equal(t, []string{"package.json", "Dockerfile"}, files)
+
+	// the package-lock.json written by the package.json updater is committed as well
+	files, err = Files([]string{"../../test/package.json"}, nil)
+	require.NoError(t, err)
+	require.Equal(t, []string{"../../test/package.json", "../../test/package-lock.json"}, files)
+	files, err = Files([]string{"../../test/manifest.json"}, &Options{Type: "package.json"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"../../test/manifest.json", "../../test/package-lock.json"}, files)
+}
+
+func TestCommitFiles(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	dir, err := ioutil.TempDir("", "update-commit")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	git := func(args ...string) string {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+		return strings.TrimSpace(string(out))
+	}
+	git("init", "-q")
+	git("config", "user.name", "CI")
+	git("config", "user.email", "ci@example.com")
+	file := filepath.Join(dir, "package.json")
+	require.NoError(t, ioutil.WriteFile(file, []byte(`{"version": "1.0.0"}`), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "other.txt"), []byte("other"), 0644))
+	git("add", ".")
+	git("commit", "-q", "-m", "feat: initial")
+
+	// the file did not change
+	committed, err := CommitFiles(dir, []string{"package.json"}, "chore(release): v1.0.0 [skip ci]")
+	require.NoError(t, err)
+	require.False(t, committed)
+	require.Equal(t, "feat: initial", git("log", "-1", "--format=%s"))
+
+	require.NoError(t, ioutil.WriteFile(file, []byte(`{"version": "1.1.0"}`), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "other.txt"), []byte("changed"), 0644))
+	committed, err = CommitFiles(dir, []string{"package.json"}, "chore(release): v1.1.0 [skip ci]\n")
+	require.NoError(t, err)
+	require.True(t, committed)
+	require.Equal(t, "chore(release): v1.1.0 [skip ci]", git("log", "-1", "--format=%s"))
+	require.Equal(t, "package.json", git("show", "--name-only", "--format=", "HEAD"))
+	// other changes of the working tree are not committed
+	require.Equal(t, "M other.txt", git("status", "--porcelain"))
+}
+
+func TestCommitFilesWithoutIdentity(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	home, err := ioutil.TempDir("", "update-home")
+	require.NoError(t, err)
+	defer os.RemoveAll(home)
+	// a CI runner without the global and system git config
+	for name, value := range map[string]string{"HOME": home, "XDG_CONFIG_HOME": home, "GIT_CONFIG_NOSYSTEM": "1"} {
+		defer os.Setenv(name, os.Getenv(name))
+		require.NoError(t, os.Setenv(name, value))
+	}
+	for _, name := range []string{"GIT_AUTHOR_NAME", "GIT_AUTHOR_EMAIL", "GIT_COMMITTER_NAME", "GIT_COMMITTER_EMAIL", "EMAIL"} {
+		if value, ok := os.LookupEnv(name); ok {
+			defer os.Setenv(name, value)
+			require.NoError(t, os.Unsetenv(name))
+		}
+	}
+
+	dir, err := ioutil.TempDir("", "update-commit")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	git := func(args ...string) string {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+		return strings.TrimSpace(string(out))
+	}
+	git("init", "-q")
+	file := filepath.Join(dir, "VERSION")
+	require.NoError(t, ioutil.WriteFile(file, []byte("1.0.0"), 0644))
+	git("add", ".")
+	git("-c", "user.name=CI", "-c", "user.email=ci@example.com", "commit", "-q", "-m", "feat: initial")
+
+	require.NoError(t, ioutil.WriteFile(file, []byte("1.1.0"), 0644))
+	committed, err := CommitFiles(dir, []string{"VERSION"}, "chore(release): v1.1.0 [skip ci]")
+	require.NoError(t, err)
+	require.True(t, committed)
+	require.Equal(t, FallbackName+" <"+FallbackEmail+">", git("log", "-1", "--format=%an <%ae>"))
+	require.Equal(t, FallbackName+" <"+FallbackEmail+">", git("log", "-1", "--format=%cn <%ce>"))
+
+	sha, err := HeadSHA(dir)
+	require.NoError(t, err)
+	require.Equal(t, git("rev-parse", "HEAD"), sha)
+}
@@ -0,0 +1,94 @@
+package update
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Plan returns the changes Apply would make as a diff of the changed lines, the file is not modified
+func Plan(file, newVersion string) (string, error) {
+	return PlanWithOptions(file, newVersion, nil)
+}
+
+// PlanWithOptions runs the updater on a copy of the file in a temporary directory,
+// files written next to the target by the updater (e.g. .npmrc) end up there as well
+func PlanWithOptions(target, newVersion string, opts *Options) (string, error) {
+	file, ufn, err := lookupTarget(target, opts)
+	if err != nil {
+		return "", err
+	}
+	original, err := ioutil.ReadFile(file)
+	if err != nil {
+		return "", err
+	}
+
+	dir, err := ioutil.TempDir("", "semantic-release-plan")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(dir)
+	f, err := os.OpenFile(filepath.Join(dir, filepath.Base(file)), os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	if _, err := f.Write(original); err != nil {
+		return "", err
+	}
+	if _, err := f.Seek(0, 0); err != nil {
+		return "", err
+	}
+	if err := ufn(newVersion, f); err != nil {
+		return "", err
+	}
+	if _, err := f.Seek(0, 0); err != nil {
+		return "", err
+	}
+	updated, err := ioutil.ReadAll(f)
+	if err != nil {
+		return "", err
+	}
+	return diffLines(string(original), string(updated)), nil
+}
+
+// diffLines renders the removed lines with a - and the added lines with a + prefix
+func diffLines(a, b string) string {
+	x := strings.Split(strings.TrimSuffix(a, "\n"), "\n")
+	y := strings.Split(strings.TrimSuffix(b, "\n"), "\n")
+
+	// longest common subsequence of the lines
+	lcs := make([][]int, len(x)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(y)+1)
+	}
+	for i := len(x) - 1; i >= 0; i-- {
+		for j := len(y) - 1; j >= 0; j-- {
+			if x[i] == y[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var diff strings.Builder
+	i, j := 0, 0
+	for i < len(x) || j < len(y) {
+		switch {
+		case i < len(x) && j < len(y) && x[i] == y[j]:
+			i++
+			j++
+		case j == len(y) || (i < len(x) && lcs[i+1][j] >= lcs[i][j+1]):
+			diff.WriteString("-" + x[i] + "\n")
+			i++
+		default:
+			diff.WriteString("+" + y[j] + "\n")
+			j++
+		}
+	}
+	return diff.String()
+}
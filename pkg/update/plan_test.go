@@ -0,0 +1,58 @@
+package update
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlan(t *testing.T) {
+	require := require.New(t)
+	file, cleanup := copyFixture(t, "../../test/Chart.yaml", "Chart.yaml")
+	defer cleanup()
+	before, err := os.Stat(file)
+	require.NoError(err)
+	// the modification time has to change if the file is written
+	time.Sleep(10 * time.Millisecond)
+
+	diff, err := Plan(file, "1.2.3")
+	require.NoError(err)
+	require.Equal("-version: 0.1.0 # managed by semantic-release\n+version: 1.2.3 # managed by semantic-release\n", diff)
+
+	diff, err = PlanWithOptions(file, "1.2.3", &Options{YAMLKey: "image.tag"})
+	require.NoError(err)
+	require.Equal("-  tag: '1.16.0'\n+  tag: '1.2.3'\n", diff)
+
+	after, err := os.Stat(file)
+	require.NoError(err)
+	require.Equal(before.ModTime(), after.ModTime())
+	original, err := ioutil.ReadFile("../../test/Chart.yaml")
+	require.NoError(err)
+	content, err := ioutil.ReadFile(file)
+	require.NoError(err)
+	require.Equal(original, content)
+}
+
+func TestPlanPackageJson(t *testing.T) {
+	require := require.New(t)
+	file, cleanup := copyFixture(t, "../../test/package.json", "package.json")
+	defer cleanup()
+	// the fixture is modified by the other tests
+	require.NoError(ioutil.WriteFile(file, []byte("{\n  \"name\": \"test-package\",\n  \"version\": \"1.0.0\"\n}\n"), 0644))
+
+	Register("package.json", packageJson)
+	diff, err := Plan(file, "1.2.3")
+	require.NoError(err)
+	require.Equal("-  \"version\": \"1.0.0\"\n+  \"version\": \"1.2.3\"\n", diff)
+
+	// the .npmrc is only created in the temporary directory
+	_, err = os.Stat(filepath.Join(filepath.Dir(file), ".npmrc"))
+	require.True(os.IsNotExist(err))
+
+	_, err = Plan(filepath.Join(filepath.Dir(file), "unknown.txt"), "1.2.3")
+	require.Equal(ErrNoUpdater, err)
+}
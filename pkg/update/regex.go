@@ -0,0 +1,90 @@
+package update
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"regexp"
+)
+
+// volumePattern matches a Windows drive letter at the start of a path, e.g. C:\ or C:/
+var volumePattern = regexp.MustCompile(`^[A-Za-z]:[\\/]`)
+
+// splitTarget returns the index of the colon separating the file from the regex or -1 without a regex. The colon
+// of a Windows drive letter is skipped and a file name containing colons is kept if the file exists.
+func splitTarget(target string) int {
+	if _, err := os.Stat(target); err == nil {
+		return -1
+	}
+	start := len(volumePattern.FindString(target))
+	first := -1
+	for i := start; i < len(target); i++ {
+		if target[i] != ':' {
+			continue
+		}
+		if first < 0 {
+			first = i
+		}
+		if _, err := os.Stat(target[:i]); err == nil {
+			return i
+		}
+	}
+	return first
+}
+
+// ParseTarget splits an update target of the form file:regex into the file and the pattern, the pattern is nil
+// for targets without a regex. The version is the first capture group of the pattern.
+func ParseTarget(target string) (string, *regexp.Regexp, error) {
+	i := splitTarget(target)
+	if i < 0 {
+		return target, nil, nil
+	}
+	file, expr := target[:i], target[i+1:]
+	if file == "" || expr == "" {
+		return "", nil, fmt.Errorf("invalid update target %s (must be file or file:regex)", target)
+	}
+	pattern, err := regexp.Compile(expr)
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid update pattern %s: %w", expr, err)
+	}
+	if pattern.NumSubexp() == 0 {
+		return "", nil, fmt.Errorf("invalid update pattern %s: the version must be matched by a capture group", expr)
+	}
+	return file, pattern, nil
+}
+
+// RegexUpdater replaces the first capture group of every match of the pattern with the version, the rest of
+// the matches is kept. It fails if the pattern does not match at all.
+func RegexUpdater(pattern *regexp.Regexp) Updater {
+	return func(newVersion string, file *os.File) error {
+		content, err := ioutil.ReadAll(file)
+		if err != nil {
+			return err
+		}
+		matches := pattern.FindAllSubmatchIndex(content, -1)
+		if len(matches) == 0 {
+			return errors.New("pattern " + pattern.String() + " did not match")
+		}
+		updated := make([]byte, 0, len(content))
+		last := 0
+		for _, match := range matches {
+			// the group may not participate in the match, e.g. (\d+)?
+			if match[2] < 0 {
+				continue
+			}
+			updated = append(updated, content[last:match[2]]...)
+			updated = append(updated, newVersion...)
+			last = match[3]
+		}
+		updated = append(updated, content[last:]...)
+		if _, err := file.Seek(0, 0); err != nil {
+			return err
+		}
+		if err := file.Truncate(0); err != nil {
+			return err
+		}
+		_, err = file.Write(updated)
+		return err
+	}
+}
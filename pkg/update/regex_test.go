@@ -0,0 +1,119 @@
+package update
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testDockerfile = `FROM golang:1.14 AS build
+ARG VERSION=0.1.0
+RUN go build -ldflags "-X main.version=${VERSION}" ./...
+
+FROM alpine:3.12
+ARG VERSION=0.1.0
+LABEL org.opencontainers.image.version=0.1.0
+`
+
+func writeTestFile(t *testing.T, name, content string) (string, func()) {
+	dir, err := ioutil.TempDir("", "update")
+	require.NoError(t, err)
+	file := filepath.Join(dir, name)
+	require.NoError(t, ioutil.WriteFile(file, []byte(content), 0644))
+	return file, func() { os.RemoveAll(dir) }
+}
+
+func TestRegexUpdater(t *testing.T) {
+	require := require.New(t)
+	file, cleanup := writeTestFile(t, "Dockerfile", testDockerfile)
+	defer cleanup()
+
+	// only the capture group is replaced
+	require.NoError(Apply(file+`:image.version=([\d.]+)`, "1.2.3"))
+	content, err := ioutil.ReadFile(file)
+	require.NoError(err)
+	require.Contains(string(content), "\nLABEL org.opencontainers.image.version=1.2.3\n")
+	require.Contains(string(content), "\nARG VERSION=0.1.0\n")
+
+	// every match is replaced
+	require.NoError(Apply(file+`:ARG VERSION=([\d.]+)`, "1.2.3"))
+	content, err = ioutil.ReadFile(file)
+	require.NoError(err)
+	require.Equal(`FROM golang:1.14 AS build
+ARG VERSION=1.2.3
+RUN go build -ldflags "-X main.version=${VERSION}" ./...
+
+FROM alpine:3.12
+ARG VERSION=1.2.3
+LABEL org.opencontainers.image.version=1.2.3
+`, string(content))
+
+	err = Apply(file+`:ENV VERSION=([\d.]+)`, "1.2.3")
+	require.EqualError(err, `pattern ENV VERSION=([\d.]+) did not match`)
+}
+
+func TestParseTarget(t *testing.T) {
+	file, pattern, err := ParseTarget("package.json")
+	require.NoError(t, err)
+	require.Equal(t, "package.json", file)
+	require.Nil(t, pattern)
+
+	file, pattern, err = ParseTarget(`Makefile:VERSION := (\S+)`)
+	require.NoError(t, err)
+	require.Equal(t, "Makefile", file)
+	require.Equal(t, `VERSION := (\S+)`, pattern.String())
+
+	_, _, err = ParseTarget("Makefile:")
+	require.EqualError(t, err, "invalid update target Makefile: (must be file or file:regex)")
+	_, _, err = ParseTarget("Makefile:VERSION=(")
+	require.Error(t, err)
+	_, _, err = ParseTarget(`Makefile:VERSION=\S+`)
+	require.EqualError(t, err, `invalid update pattern VERSION=\S+: the version must be matched by a capture group`)
+
+	// the colon of a drive letter does not separate the pattern
+	file, pattern, err = ParseTarget(`C:\project\version.sh`)
+	require.NoError(t, err)
+	require.Equal(t, `C:\project\version.sh`, file)
+	require.Nil(t, pattern)
+	file, pattern, err = ParseTarget(`C:/project/version.sh:VERSION=(.*)`)
+	require.NoError(t, err)
+	require.Equal(t, `C:/project/version.sh`, file)
+	require.Equal(t, `VERSION=(.*)`, pattern.String())
+
+	// existing files may contain colons
+	colonFile, cleanup := writeTestFile(t, "version:1.sh", "VERSION=0.1.0\n")
+	defer cleanup()
+	file, pattern, err = ParseTarget(colonFile)
+	require.NoError(t, err)
+	require.Equal(t, colonFile, file)
+	require.Nil(t, pattern)
+	file, pattern, err = ParseTarget(colonFile + `:VERSION=(.*)`)
+	require.NoError(t, err)
+	require.Equal(t, colonFile, file)
+	require.Equal(t, `VERSION=(.*)`, pattern.String())
+}
+
+func TestApplyAllRegex(t *testing.T) {
+	require := require.New(t)
+	file, cleanup := writeTestFile(t, "version.sh", "VERSION=0.1.0\n")
+	defer cleanup()
+
+	require.NoError(ApplyAll([]string{file + `:VERSION=(.*)`}, "1.2.3", nil))
+	content, err := ioutil.ReadFile(file)
+	require.NoError(err)
+	require.Equal("VERSION=1.2.3\n", string(content))
+
+	// the file is restored if a pattern does not match
+	err = ApplyAll([]string{file + `:VERSION=(.*)`, file + `:RELEASE=(.*)`}, "2.0.0", nil)
+	require.EqualError(err, "could not update "+file+": pattern RELEASE=(.*) did not match")
+	content, err = ioutil.ReadFile(file)
+	require.NoError(err)
+	require.Equal("VERSION=1.2.3\n", string(content))
+
+	diff, err := PlanWithOptions(file+`:VERSION=(.*)`, "2.0.0", nil)
+	require.NoError(err)
+	require.Equal("-VERSION=1.2.3\n+VERSION=2.0.0\n", diff)
+}
@@ -0,0 +1,162 @@
+package update
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strings"
+)
+
+func init() {
+	Register("toml", TOMLUpdater("version"))
+	Register("Cargo.toml", TOMLUpdater("package.version"))
+	// PEP 621 metadata or the metadata of poetry
+	Register("pyproject.toml", TOMLUpdater("project.version", "tool.poetry.version"))
+}
+
+var tomlTablePattern = regexp.MustCompile(`^\s*\[\s*([^\[\]]+?)\s*\]\s*(#.*)?$`)
+var tomlArrayTablePattern = regexp.MustCompile(`^\s*\[\[`)
+var tomlKeyPattern = regexp.MustCompile(`^\s*((?:[A-Za-z0-9_-]+|"[^"]*"|'[^']*')(?:\s*\.\s*(?:[A-Za-z0-9_-]+|"[^"]*"|'[^']*'))*)\s*=\s*`)
+var tomlStringPattern = regexp.MustCompile(`^("(?:[^"\\]|\\.)*"|'[^']*')(.*)$`)
+
+// TOMLUpdater sets the string at the first existing dot separated key path, the table is part of the path
+// (e.g. package.version sets version in the [package] table). The file is edited line by line so comments,
+// ordering and formatting are preserved.
+func TOMLUpdater(keyPaths ...string) Updater {
+	return func(newVersion string, file *os.File) error {
+		content, err := ioutil.ReadAll(file)
+		if err != nil {
+			return err
+		}
+		var updated string
+		for _, keyPath := range keyPaths {
+			if updated, err = setTOMLValue(string(content), keyPath, newVersion); err != errTOMLKeyNotFound {
+				break
+			}
+		}
+		if err == errTOMLKeyNotFound {
+			return fmt.Errorf("key %s not found", strings.Join(keyPaths, " or "))
+		}
+		if err != nil {
+			return err
+		}
+		if _, err := file.Seek(0, 0); err != nil {
+			return err
+		}
+		if err := file.Truncate(0); err != nil {
+			return err
+		}
+		_, err = file.WriteString(updated)
+		return err
+	}
+}
+
+var errTOMLKeyNotFound = errors.New("key not found")
+
+// splitTOMLKey splits a dotted key into its parts without the quotes, e.g. tool."poetry".version
+func splitTOMLKey(key string) []string {
+	parts := make([]string, 0)
+	var current strings.Builder
+	var quote rune
+	for _, r := range key {
+		switch {
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote != 0:
+			current.WriteRune(r)
+		case r == '"' || r == '\'':
+			quote = r
+		case r == '.':
+			parts = append(parts, strings.TrimSpace(current.String()))
+			current.Reset()
+		default:
+			current.WriteRune(r)
+		}
+	}
+	return append(parts, strings.TrimSpace(current.String()))
+}
+
+// tomlBracketDepth returns the number of brackets opened and not closed in the value, brackets in strings are ignored
+func tomlBracketDepth(value string) int {
+	depth := 0
+	var quote rune
+	for _, r := range value {
+		switch {
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote != 0:
+		case r == '"' || r == '\'':
+			quote = r
+		case r == '#':
+			return depth
+		case r == '[' || r == '{':
+			depth++
+		case r == ']' || r == '}':
+			depth--
+		}
+	}
+	return depth
+}
+
+func setTOMLValue(content, keyPath, value string) (string, error) {
+	lines := strings.Split(content, "\n")
+	table := ""
+	// keys of arrays of tables (e.g. [[bin]]) are never matched
+	inArrayTable := false
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		if tomlArrayTablePattern.MatchString(line) {
+			inArrayTable = true
+			continue
+		}
+		if match := tomlTablePattern.FindStringSubmatch(line); match != nil {
+			table = strings.Join(splitTOMLKey(match[1]), ".")
+			inArrayTable = false
+			continue
+		}
+		match := tomlKeyPattern.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		path := strings.Join(splitTOMLKey(match[1]), ".")
+		if table != "" {
+			path = table + "." + path
+		}
+		rest := line[len(match[0]):]
+
+		// multi-line strings and arrays are skipped as their lines may look like keys or tables
+		if strings.HasPrefix(rest, `"""`) || strings.HasPrefix(rest, "'''") {
+			if path == keyPath && !inArrayTable {
+				return "", fmt.Errorf("key %s is a multi-line string", keyPath)
+			}
+			delimiter := rest[:3]
+			end := strings.Contains(rest[3:], delimiter)
+			for !end && i+1 < len(lines) {
+				i++
+				end = strings.Contains(lines[i], delimiter)
+			}
+			continue
+		}
+		for depth := tomlBracketDepth(rest); depth > 0 && i+1 < len(lines); depth += tomlBracketDepth(lines[i]) {
+			i++
+		}
+
+		if inArrayTable || path != keyPath {
+			continue
+		}
+		valueMatch := tomlStringPattern.FindStringSubmatch(rest)
+		if valueMatch == nil {
+			return "", fmt.Errorf("key %s is not a string", keyPath)
+		}
+		quote := valueMatch[1][:1]
+		lines[i] = line[:len(line)-len(rest)] + quote + value + quote + valueMatch[2]
+		return strings.Join(lines, "\n"), nil
+	}
+	return "", errTOMLKeyNotFound
+}
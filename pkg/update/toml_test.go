@@ -0,0 +1,74 @@
+package update
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTOMLUpdaterCargo(t *testing.T) {
+	require := require.New(t)
+	original, err := ioutil.ReadFile("../../test/Cargo.toml")
+	require.NoError(err)
+
+	file, cleanup := copyFixture(t, "../../test/Cargo.toml", "Cargo.toml")
+	defer cleanup()
+	require.NoError(Apply(file, "1.2.3"))
+	content, err := ioutil.ReadFile(file)
+	require.NoError(err)
+	expected := strings.Replace(string(original), `version = "0.1.0" # managed`, `version = "1.2.3" # managed`, 1)
+	require.Equal(expected, string(content))
+
+	// keys in multi-line strings and arrays of tables are not matched
+	err = ApplyWithOptions(file, "1.2.3", &Options{TOMLKey: "dependencies.version"})
+	require.EqualError(err, "key dependencies.version not found")
+	err = ApplyWithOptions(file, "1.2.3", &Options{TOMLKey: "bin.name"})
+	require.EqualError(err, "key bin.name not found")
+
+	require.NoError(ApplyWithOptions(file, "1.2.3", &Options{TOMLKey: "dependencies.rand"}))
+	content, err = ioutil.ReadFile(file)
+	require.NoError(err)
+	require.Contains(string(content), "\nrand = \"1.2.3\"\n")
+	require.Contains(string(content), `serde = { version = "1.0", features = ["derive"] }`)
+}
+
+func TestTOMLUpdaterPyproject(t *testing.T) {
+	require := require.New(t)
+	original, err := ioutil.ReadFile("../../test/pyproject.toml")
+	require.NoError(err)
+
+	file, cleanup := copyFixture(t, "../../test/pyproject.toml", "pyproject.toml")
+	defer cleanup()
+	// without a [project] table the version of poetry is set, the quotes are kept
+	require.NoError(Apply(file, "1.2.3"))
+	content, err := ioutil.ReadFile(file)
+	require.NoError(err)
+	expected := strings.Replace(string(original), "version = '0.1.0'", "version = '1.2.3'", 1)
+	require.Equal(expected, string(content))
+
+	err = ApplyWithOptions(file, "1.2.3", &Options{TOMLKey: "tool.poetry.dependencies.requests"})
+	require.EqualError(err, "key tool.poetry.dependencies.requests is not a string")
+}
+
+func TestTOMLUpdaterKeys(t *testing.T) {
+	testCases := []struct {
+		content  string
+		keyPath  string
+		expected string
+	}{
+		{"version = \"1.0.0\"\n", "version", "version = \"2.0.0\"\n"},
+		{"[project]\nname = \"a\"\nversion = \"1.0.0\"\n", "project.version", "[project]\nname = \"a\"\nversion = \"2.0.0\"\n"},
+		{"[ tool . \"poetry\" ] # comment\nversion=\"1.0.0\"\n", "tool.poetry.version", "[ tool . \"poetry\" ] # comment\nversion=\"2.0.0\"\n"},
+		{"package.version = '1.0.0'\n", "package.version", "package.version = '2.0.0'\n"},
+		{"[package]\nversion = \"1.0.0\"\n[other]\nversion = \"1.0.0\"\n", "other.version", "[package]\nversion = \"1.0.0\"\n[other]\nversion = \"2.0.0\"\n"},
+	}
+	for _, tc := range testCases {
+		updated, err := setTOMLValue(tc.content, tc.keyPath, "2.0.0")
+		require.NoError(t, err, tc.content)
+		require.Equal(t, tc.expected, updated)
+	}
+	_, err := setTOMLValue("[package]\nversion = \"1.0.0\"\n", "version", "2.0.0")
+	require.Equal(t, errTOMLKeyNotFound, err)
+}
@@ -2,8 +2,12 @@ package update
 
 import (
 	"errors"
+	"fmt"
+	"io/ioutil"
 	"os"
 	"path"
+	"sort"
+	"strings"
 	"sync"
 )
 
@@ -20,11 +24,74 @@ func Register(name string, u Updater) {
 	updaters[name] = u
 }
 
-func Apply(file, newVersion string) error {
+// Options override how the files are updated
+type Options struct {
+	// Type is the updater used instead of detecting it by the file name
+	Type string
+	// YAMLKey is the dot separated path of the key set by the yaml updater, defaults to version
+	YAMLKey string
+	// TOMLKey is the dot separated path of the key set by the toml updaters including the table (e.g. package.version),
+	// defaults to the key of the file type
+	TOMLKey string
+}
+
+// SupportedTypes returns the names of all registered updaters
+func SupportedTypes() []string {
+	updatersMu.RLock()
+	defer updatersMu.RUnlock()
+	types := make([]string, 0, len(updaters))
+	for name := range updaters {
+		types = append(types, name)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// lookupTarget returns the file of the target and its updater, targets with a regex (file:regex) use the RegexUpdater
+func lookupTarget(target string, opts *Options) (string, Updater, error) {
+	file, pattern, err := ParseTarget(target)
+	if err != nil {
+		return "", nil, err
+	}
+	if pattern != nil {
+		return file, RegexUpdater(pattern), nil
+	}
+	ufn, ok := lookup(file, opts)
+	if !ok {
+		return file, nil, ErrNoUpdater
+	}
+	return file, ufn, nil
+}
+
+// lookup finds the updater by the type override, the file name or the file extension
+func lookup(file string, opts *Options) (Updater, bool) {
+	updatersMu.RLock()
+	defer updatersMu.RUnlock()
 	name := path.Base(file)
+	if opts != nil && opts.Type != "" {
+		name = opts.Type
+	} else if _, ok := updaters[name]; !ok {
+		name = strings.TrimPrefix(path.Ext(file), ".")
+	}
 	ufn, ok := updaters[name]
-	if !ok {
-		return ErrNoUpdater
+	if ok && (name == "yaml" || name == "yml") && opts != nil && opts.YAMLKey != "" {
+		ufn = YAMLUpdater(opts.YAMLKey)
+	}
+	if ok && (name == "toml" || strings.HasSuffix(name, ".toml")) && opts != nil && opts.TOMLKey != "" {
+		ufn = TOMLUpdater(opts.TOMLKey)
+	}
+	return ufn, ok
+}
+
+func Apply(file, newVersion string) error {
+	return ApplyWithOptions(file, newVersion, nil)
+}
+
+// ApplyWithOptions updates the target, either a file or a file and a regex matching the version (e.g. Dockerfile:ARG VERSION=([\d.]+))
+func ApplyWithOptions(target, newVersion string, opts *Options) error {
+	file, ufn, err := lookupTarget(target, opts)
+	if err != nil {
+		return err
 	}
 	f, err := os.OpenFile(file, os.O_RDWR, 0)
 	if err != nil {
@@ -33,3 +100,55 @@ func Apply(file, newVersion string) error {
 	defer f.Close()
 	return ufn(newVersion, f)
 }
+
+// Errors collects the errors of all failed update targets
+type Errors []error
+
+func (e Errors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// ApplyAll updates all files, no file is written if any of them has no updater or can not be opened.
+// If an updater fails the targets are restored to their previous content, files written next to them
+// by the updaters (e.g. package-lock.json) are kept.
+func ApplyAll(targets []string, newVersion string, opts *Options) error {
+	files := make([]string, len(targets))
+	originals := make([][]byte, len(targets))
+	var errs Errors
+	for i, target := range targets {
+		file, _, err := lookupTarget(target, opts)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("could not update %s: %w", target, err))
+			continue
+		}
+		files[i] = file
+		content, err := ioutil.ReadFile(file)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("could not update %s: %w", file, err))
+			continue
+		}
+		originals[i] = content
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+
+	for i, target := range targets {
+		if err := ApplyWithOptions(target, newVersion, opts); err != nil {
+			errs = append(errs, fmt.Errorf("could not update %s: %w", files[i], err))
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	for i, file := range files {
+		if err := ioutil.WriteFile(file, originals[i], 0); err != nil {
+			errs = append(errs, fmt.Errorf("could not restore %s: %w", file, err))
+		}
+	}
+	return errs
+}
@@ -2,7 +2,9 @@ package update
 
 import (
 	"errors"
+	"io/ioutil"
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -36,3 +38,55 @@ func TestApply(t *testing.T) {
 		require.NoError(err)
 	}
 }
+
+func TestApplyAll(t *testing.T) {
+	require := require.New(t)
+	dir, err := ioutil.TempDir("", "update")
+	require.NoError(err)
+	defer os.RemoveAll(dir)
+
+	Register("version.txt", func(newVersion string, file *os.File) error {
+		_, err := file.WriteString(newVersion)
+		return err
+	})
+	Register("broken.txt", func(newVersion string, file *os.File) error {
+		if _, err := file.WriteString("partial"); err != nil {
+			return err
+		}
+		return errors.New("invalid file")
+	})
+
+	first := filepath.Join(dir, "a", "version.txt")
+	second := filepath.Join(dir, "b", "version.txt")
+	broken := filepath.Join(dir, "broken.txt")
+	for _, file := range []string{first, second, broken} {
+		require.NoError(os.MkdirAll(filepath.Dir(file), 0755))
+		require.NoError(ioutil.WriteFile(file, []byte("0.0.0"), 0644))
+	}
+
+	require.NoError(ApplyAll([]string{first, second}, "1.2.3", nil))
+	content, err := ioutil.ReadFile(second)
+	require.NoError(err)
+	require.Equal("1.2.3", string(content))
+
+	// the first target is restored when the second one fails
+	err = ApplyAll([]string{first, broken}, "2.0.0", nil)
+	require.EqualError(err, "could not update "+broken+": invalid file")
+	content, err = ioutil.ReadFile(first)
+	require.NoError(err)
+	require.Equal("1.2.3", string(content))
+	content, err = ioutil.ReadFile(broken)
+	require.NoError(err)
+	require.Equal("0.0.0", string(content))
+
+	// nothing is written if a target is not supported
+	err = ApplyAll([]string{first, filepath.Join(dir, "unknown.txt")}, "3.0.0", nil)
+	require.EqualError(err, "could not update "+filepath.Join(dir, "unknown.txt")+": no updater registered")
+	var errs Errors
+	require.True(errors.As(err, &errs))
+	require.Len(errs, 1)
+	require.True(errors.Is(errs[0], ErrNoUpdater))
+	content, err = ioutil.ReadFile(first)
+	require.NoError(err)
+	require.Equal("1.2.3", string(content))
+}
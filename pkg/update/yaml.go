@@ -0,0 +1,91 @@
+package update
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strings"
+)
+
+func init() {
+	Register("yaml", YAMLUpdater("version"))
+	Register("yml", YAMLUpdater("version"))
+}
+
+var yamlKeyPattern = regexp.MustCompile(`^(\s*)("[^"]*"|'[^']*'|[^\s#'"-][^:#]*?)\s*:(\s+|$)`)
+var yamlValuePattern = regexp.MustCompile(`^("[^"]*"|'[^']*'|[^\s#]+)(.*)$`)
+
+type yamlKey struct {
+	indent int
+	name   string
+}
+
+// YAMLUpdater sets the scalar at the dot separated key path (e.g. image.tag), the file is edited
+// line by line so comments, ordering and formatting are preserved
+func YAMLUpdater(keyPath string) Updater {
+	return func(newVersion string, file *os.File) error {
+		content, err := ioutil.ReadAll(file)
+		if err != nil {
+			return err
+		}
+		updated, err := setYAMLValue(string(content), keyPath, newVersion)
+		if err != nil {
+			return err
+		}
+		if _, err := file.Seek(0, 0); err != nil {
+			return err
+		}
+		if err := file.Truncate(0); err != nil {
+			return err
+		}
+		_, err = file.WriteString(updated)
+		return err
+	}
+}
+
+func setYAMLValue(content, keyPath, value string) (string, error) {
+	lines := strings.Split(content, "\n")
+	var parents []yamlKey
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "---" {
+			parents = nil
+			continue
+		}
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		match := yamlKeyPattern.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		indent := len(match[1])
+		for len(parents) > 0 && parents[len(parents)-1].indent >= indent {
+			parents = parents[:len(parents)-1]
+		}
+		name := strings.Trim(match[2], `"'`)
+		path := make([]string, 0, len(parents)+1)
+		for _, p := range parents {
+			path = append(path, p.name)
+		}
+		path = append(path, name)
+
+		rest := line[len(match[0]):]
+		if strings.TrimSpace(rest) == "" || strings.HasPrefix(strings.TrimSpace(rest), "#") {
+			parents = append(parents, yamlKey{indent, name})
+			continue
+		}
+		if strings.Join(path, ".") != keyPath {
+			continue
+		}
+		valueMatch := yamlValuePattern.FindStringSubmatch(rest)
+		newValue := value
+		if quote := valueMatch[1][0]; quote == '"' || quote == '\'' {
+			newValue = string(quote) + value + string(quote)
+		}
+		lines[i] = line[:len(match[0])] + newValue + valueMatch[2]
+		return strings.Join(lines, "\n"), nil
+	}
+	return "", fmt.Errorf("key %s not found", keyPath)
+}
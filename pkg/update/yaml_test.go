@@ -0,0 +1,69 @@
+package update
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func copyFixture(t *testing.T, fixture, name string) (string, func()) {
+	dir, err := ioutil.TempDir("", "update")
+	require.NoError(t, err)
+	content, err := ioutil.ReadFile(fixture)
+	require.NoError(t, err)
+	file := filepath.Join(dir, name)
+	require.NoError(t, ioutil.WriteFile(file, content, 0644))
+	return file, func() { os.RemoveAll(dir) }
+}
+
+func TestYAMLUpdater(t *testing.T) {
+	require := require.New(t)
+	original, err := ioutil.ReadFile("../../test/Chart.yaml")
+	require.NoError(err)
+
+	file, cleanup := copyFixture(t, "../../test/Chart.yaml", "Chart.yaml")
+	defer cleanup()
+	require.NoError(Apply(file, "1.2.3"))
+	content, err := ioutil.ReadFile(file)
+	require.NoError(err)
+	expected := strings.Replace(string(original), "version: 0.1.0 # managed", "version: 1.2.3 # managed", 1)
+	require.Equal(expected, string(content))
+
+	// quotes are kept
+	require.NoError(ApplyWithOptions(file, "1.2.3", &Options{YAMLKey: "appVersion"}))
+	content, err = ioutil.ReadFile(file)
+	require.NoError(err)
+	require.Contains(string(content), "\nappVersion: \"1.2.3\"\n")
+
+	require.NoError(ApplyWithOptions(file, "1.2.3", &Options{YAMLKey: "image.tag"}))
+	content, err = ioutil.ReadFile(file)
+	require.NoError(err)
+	require.Contains(string(content), "\n  tag: '1.2.3'\n")
+	require.Contains(string(content), "\n    version: 10.5.7\n")
+
+	err = ApplyWithOptions(file, "1.2.3", &Options{YAMLKey: "image.version"})
+	require.EqualError(err, "key image.version not found")
+}
+
+func TestApplyType(t *testing.T) {
+	require := require.New(t)
+	file, cleanup := copyFixture(t, "../../test/Chart.yaml", "Chart.tpl")
+	defer cleanup()
+
+	require.Equal(ErrNoUpdater, Apply(file, "1.2.3"))
+	require.NoError(ApplyWithOptions(file, "1.2.3", &Options{Type: "yaml"}))
+	content, err := ioutil.ReadFile(file)
+	require.NoError(err)
+	require.Contains(string(content), "\nversion: 1.2.3 # managed by semantic-release\n")
+}
+
+func TestSupportedTypes(t *testing.T) {
+	types := SupportedTypes()
+	require.Subset(t, types, []string{"Cargo.toml", "package.json", "pyproject.toml", "toml", "yaml", "yml"})
+	require.True(t, sort.StringsAreSorted(types))
+}